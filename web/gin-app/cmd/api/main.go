@@ -13,9 +13,13 @@ import (
 	"syscall"
 	"time"
 
+	"go_learning/web/gin-app/internal/auth"
 	"go_learning/web/gin-app/internal/config"
 	"go_learning/web/gin-app/internal/database"
+	"go_learning/web/gin-app/internal/mailer"
 	"go_learning/web/gin-app/internal/router"
+	"go_learning/web/gin-app/internal/storage"
+	"go_learning/web/gin-app/internal/utils"
 )
 
 func main() {
@@ -40,11 +44,48 @@ func main() {
 		log.Fatalf("マイグレーションに失敗しました: %v", err)
 	}
 
-	// 4. ルーターのセットアップ
+	// 組み込みのロールと権限を作成します
+	if err := database.SeedRoles(db); err != nil {
+		log.Fatalf("ロールの初期化に失敗しました: %v", err)
+	}
+
+	// 4. メール送信の初期化
+	// MAIL_DRIVER に応じてファイル/標準出力またはSMTPで送信します
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("メール送信の初期化に失敗しました: %v", err)
+	}
+
+	// アップロードしたファイル（商品画像）の保存先を初期化します
+	// STORAGE_DRIVER に応じてローカルのディレクトリまたはS3互換のストレージに保存します
+	blobs, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("ファイルの保存先の初期化に失敗しました: %v", err)
+	}
+
+	// 5. JWT署名鍵の初期化
+	// RS256/EdDSA の場合は鍵ペアを生成・読み込みし、定期的にローテーションします
+	keys, err := auth.NewKeyManager(db, cfg.JWT, cfg.MaxTokenTTL())
+	if err != nil {
+		log.Fatalf("JWT署名鍵の初期化に失敗しました: %v", err)
+	}
+
+	// パスワードポリシーを初期化します（禁止パスワードの一覧ファイルを読み込みます）
+	passwordPolicy, err := auth.NewPasswordPolicy(db, cfg.Auth)
+	if err != nil {
+		log.Fatalf("パスワードポリシーの初期化に失敗しました: %v", err)
+	}
+
+	// リクエストのバリデーションにカスタムルール（username, strong_password, sku）を登録します
+	if err := utils.RegisterValidators(passwordPolicy.CheckRules); err != nil {
+		log.Fatalf("バリデーターの登録に失敗しました: %v", err)
+	}
+
+	// 6. ルーターのセットアップ
 	// Ginのルーターを作成し、全てのエンドポイントとミドルウェアを設定します
-	r := router.SetupRouter(db, cfg)
+	r := router.SetupRouter(db, cfg, mail, blobs, keys, passwordPolicy)
 
-	// 5. HTTPサーバーの作成
+	// 7. HTTPサーバーの作成
 	// タイムアウトやポート設定を含むHTTPサーバーを構成します
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
@@ -54,7 +95,7 @@ func main() {
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	// 6. サーバーをゴルーチンで起動
+	// 8. サーバーをゴルーチンで起動
 	// メインゴルーチンをブロックせずにサーバーを起動します
 	go func() {
 		log.Printf("サーバーを起動します: http://localhost%s", srv.Addr)
@@ -63,7 +104,7 @@ func main() {
 		}
 	}()
 
-	// 7. グレースフルシャットダウンの設定
+	// 9. グレースフルシャットダウンの設定
 	// SIGINT (Ctrl+C) や SIGTERM シグナルを受信したときに、
 	// 既存のリクエストを処理完了してから安全にサーバーを停止します
 	quit := make(chan os.Signal, 1)
@@ -72,7 +113,7 @@ func main() {
 
 	log.Println("サーバーをシャットダウンしています...")
 
-	// 8. シャットダウンのタイムアウト設定
+	// 10. シャットダウンのタイムアウト設定
 	// 最大5秒間、既存のリクエストの完了を待ちます
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
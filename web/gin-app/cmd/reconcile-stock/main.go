@@ -0,0 +1,72 @@
+// Package main は在庫の照合コマンドです
+// 商品・バリエーションの在庫を在庫の移動（台帳）の合計から再計算し、一致しない在庫を表示します
+//
+// 使い方:
+//
+//	go run ./cmd/reconcile-stock        # 一致しない在庫を表示する（見つかった場合は終了コード1）
+//	go run ./cmd/reconcile-stock -fix   # 在庫を在庫の移動の合計に修正する
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/database"
+	"go_learning/web/gin-app/internal/inventory"
+
+	"gorm.io/gorm"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "在庫を在庫の移動の合計に修正する")
+	flag.Parse()
+
+	// 1. 設定の読み込みとデータベース接続（マイグレーションはAPIサーバーの起動時に実行します）
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("設定の読み込みに失敗しました: %v", err)
+	}
+	db, err := database.NewConnection(cfg.Database)
+	if err != nil {
+		log.Fatalf("データベース接続に失敗しました: %v", err)
+	}
+	defer database.Close(db)
+
+	// 2. 在庫の照合
+	drifts, err := inventory.Reconcile(db)
+	if err != nil {
+		log.Fatalf("在庫の照合に失敗しました: %v", err)
+	}
+	if len(drifts) == 0 {
+		fmt.Println("全ての在庫が在庫の移動の合計と一致しています")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "PRODUCT\tVARIANT\tSKU\tSTOCK\tLEDGER\tDIFF\t")
+	for _, d := range drifts {
+		variant := "-"
+		if d.VariantID != nil {
+			variant = fmt.Sprint(*d.VariantID)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%+d\t\n", d.ProductID, variant, d.SKU, d.Stock, d.LedgerStock, d.Diff())
+	}
+	w.Flush()
+	fmt.Printf("在庫の移動の合計と一致しない在庫が %d 件あります\n", len(drifts))
+
+	if !*fix {
+		os.Exit(1)
+	}
+
+	// 3. 在庫の修正（在庫の移動は正しいものとして、在庫を在庫の移動の合計にする）
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return inventory.Repair(tx, drifts)
+	}); err != nil {
+		log.Fatalf("在庫の修正に失敗しました: %v", err)
+	}
+	fmt.Printf("在庫 %d 件を在庫の移動の合計に修正しました\n", len(drifts))
+}
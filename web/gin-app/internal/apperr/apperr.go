@@ -0,0 +1,152 @@
+// Package apperr はアプリケーション全体で使用する型付きのエラーとエラーコードの一覧を提供します
+// ハンドラーは AppError を Abort に渡すだけで、レスポンスの形式（RFC 7807 の problem+json）は
+// エラーハンドリングミドルウェアが統一して決定します
+package apperr
+
+import (
+	"errors"
+	"sort"
+
+	"go_learning/web/gin-app/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AppError はクライアントに返すエラーを表します
+// Code はクライアントが分岐に使用する安定したエラーコードで、メッセージの文言を変更しても変わりません
+// Cause はログ出力用の元のエラーで、クライアントには返しません
+type AppError struct {
+	Code       string                 // エラーコード（例: ORDER_NOT_CANCELLABLE）
+	Status     int                    // HTTPステータスコード
+	MessageKey string                 // メッセージカタログのキー
+	Args       []interface{}          // メッセージに埋め込む値
+	Details    interface{}            // 詳細情報（入力エラーの一覧等）
+	Extensions map[string]interface{} // レスポンスに追加するメンバー
+	Cause      error                  // 元のエラー
+}
+
+// catalog は定義済みのエラーコードの一覧です（コードごとに最初の定義を保持します）
+var catalog = map[string]*AppError{}
+
+// Define はエラーコードを定義し、エラーコードの一覧に登録します
+// 同じコードを異なるメッセージで複数定義した場合、一覧には最初の定義が使用されます
+func Define(code string, status int, messageKey string) *AppError {
+	e := &AppError{Code: code, Status: status, MessageKey: messageKey}
+	if existing, ok := catalog[code]; ok {
+		if existing.Status != status {
+			panic("apperr: エラーコード " + code + " のステータスが一致しません")
+		}
+		return e
+	}
+	catalog[code] = e
+	return e
+}
+
+// Catalog は定義済みのエラーコードをコード順に返します
+func Catalog() []*AppError {
+	list := make([]*AppError, 0, len(catalog))
+	for _, e := range catalog {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Code < list[j].Code
+	})
+	return list
+}
+
+// Lookup はエラーコードの定義を返します
+func Lookup(code string) (*AppError, bool) {
+	e, ok := catalog[code]
+	return e, ok
+}
+
+// Error はメッセージと元のエラーを連結した文字列を返します（ログ出力用のため、デフォルトの言語を使用します）
+func (e *AppError) Error() string {
+	if e.Cause != nil {
+		return e.Code + ": " + e.Message(i18n.DefaultLocale) + ": " + e.Cause.Error()
+	}
+	return e.Code + ": " + e.Message(i18n.DefaultLocale)
+}
+
+// Unwrap は元のエラーを返します
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// Is はエラーコードとメッセージキーが一致する場合に true を返します
+// WithCause 等で作成したコピーも errors.Is で定義済みのエラーと比較できます
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code && t.MessageKey == e.MessageKey
+}
+
+// Message はクライアントに返す指定した言語のメッセージを返します
+func (e *AppError) Message(locale string) string {
+	return i18n.Translate(locale, e.MessageKey, e.Args...)
+}
+
+// WithCause は元のエラーを設定したコピーを返します
+func (e *AppError) WithCause(err error) *AppError {
+	copied := e.clone()
+	copied.Cause = err
+	return copied
+}
+
+// WithArgs はメッセージに埋め込む値を設定したコピーを返します
+func (e *AppError) WithArgs(args ...interface{}) *AppError {
+	copied := e.clone()
+	copied.Args = args
+	return copied
+}
+
+// WithDetails は詳細情報を設定したコピーを返します
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	copied := e.clone()
+	copied.Details = details
+	return copied
+}
+
+// With はレスポンスに追加するメンバーを設定したコピーを返します
+func (e *AppError) With(key string, value interface{}) *AppError {
+	copied := e.clone()
+	copied.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		copied.Extensions[k] = v
+	}
+	copied.Extensions[key] = value
+	return copied
+}
+
+// clone は定義済みのエラーを変更しないようにコピーを作成します
+func (e *AppError) clone() *AppError {
+	copied := *e
+	return &copied
+}
+
+// From は任意のエラーを AppError に変換します
+// AppError 以外のエラーは、詳細をクライアントに返さないよう INTERNAL_ERROR として扱います
+func From(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return ErrInternal.WithCause(err)
+}
+
+// Abort はエラーをコンテキストに記録し、以降のハンドラーの実行を中断します
+// レスポンスはエラーハンドリングミドルウェアが返します
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// AbortOr は err に含まれる AppError でリクエストを中断します
+// トランザクション内で返されたエラー等、AppError 以外のエラーは fallback の原因として扱います
+func AbortOr(c *gin.Context, err error, fallback *AppError) {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		Abort(c, appErr)
+		return
+	}
+	Abort(c, fallback.WithCause(err))
+}
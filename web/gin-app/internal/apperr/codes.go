@@ -0,0 +1,237 @@
+// Package apperr はアプリケーション全体で使用する型付きのエラーとエラーコードの一覧を提供します
+package apperr
+
+import "net/http"
+
+// エラーコードの一覧
+// コードはクライアントが分岐に使用するため、一度公開したコードの意味は変更しないでください
+// 500 Internal Server Error は全て INTERNAL_ERROR とし、メッセージのみ処理ごとに分けます
+
+// 共通
+var (
+	ErrInternal              = Define("INTERNAL_ERROR", http.StatusInternalServerError, "common.internal")
+	ErrValidation            = Define("VALIDATION_FAILED", http.StatusBadRequest, "common.validation_failed")
+	ErrEndpointNotFound      = Define("ENDPOINT_NOT_FOUND", http.StatusNotFound, "common.endpoint_not_found")
+	ErrRateLimited           = Define("RATE_LIMITED", http.StatusTooManyRequests, "common.rate_limited")
+	ErrInvalidQueryParameter = Define("INVALID_QUERY_PARAMETER", http.StatusBadRequest, "common.invalid_query_parameter")
+	ErrInvalidUserID         = Define("INVALID_USER_ID", http.StatusBadRequest, "common.invalid_user_id")
+	ErrInvalidSessionID      = Define("INVALID_SESSION_ID", http.StatusBadRequest, "common.invalid_session_id")
+	ErrErrorCodeNotFound     = Define("ERROR_CODE_NOT_FOUND", http.StatusNotFound, "common.error_code_not_found")
+)
+
+// 認証・認可
+var (
+	ErrAuthHeaderMissing      = Define("AUTH_HEADER_MISSING", http.StatusUnauthorized, "auth.header_missing")
+	ErrAuthHeaderInvalid      = Define("AUTH_HEADER_INVALID", http.StatusUnauthorized, "auth.header_invalid")
+	ErrAuthRequired           = Define("AUTH_REQUIRED", http.StatusUnauthorized, "auth.required")
+	ErrTokenInvalid           = Define("TOKEN_INVALID", http.StatusUnauthorized, "auth.token_invalid")
+	ErrTokenExpired           = Define("TOKEN_EXPIRED", http.StatusUnauthorized, "auth.token_expired")
+	ErrTokenRevoked           = Define("TOKEN_REVOKED", http.StatusUnauthorized, "auth.token_revoked")
+	ErrSessionRevoked         = Define("SESSION_REVOKED", http.StatusUnauthorized, "auth.session_revoked")
+	ErrTokenInvalidated       = Define("TOKEN_INVALIDATED", http.StatusUnauthorized, "auth.token_invalidated")
+	ErrAccountUnavailable     = Define("ACCOUNT_UNAVAILABLE", http.StatusUnauthorized, "auth.account_unavailable")
+	ErrAPIKeyInvalid          = Define("API_KEY_INVALID", http.StatusUnauthorized, "auth.api_key_invalid")
+	ErrAPIKeyExpired          = Define("API_KEY_EXPIRED", http.StatusUnauthorized, "auth.api_key_expired")
+	ErrAPIKeyRevoked          = Define("API_KEY_REVOKED", http.StatusUnauthorized, "auth.api_key_revoked")
+	ErrAPIKeyVerifyFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "auth.api_key_verify_failed")
+	ErrPermissionDenied       = Define("PERMISSION_DENIED", http.StatusForbidden, "auth.permission_denied")
+	ErrMFARequired            = Define("MFA_REQUIRED", http.StatusForbidden, "auth.mfa_required")
+	ErrUserLoginRequired      = Define("USER_LOGIN_REQUIRED", http.StatusForbidden, "auth.user_login_required")
+	ErrImpersonationForbidden = Define("IMPERSONATION_FORBIDDEN", http.StatusForbidden, "auth.impersonation_forbidden")
+)
+
+// ログイン・トークン
+var (
+	ErrInvalidCredentials  = Define("INVALID_CREDENTIALS", http.StatusUnauthorized, "login.invalid_credentials")
+	ErrAccountDisabled     = Define("ACCOUNT_DISABLED", http.StatusForbidden, "login.account_disabled")
+	ErrEmailNotVerified    = Define("EMAIL_NOT_VERIFIED", http.StatusForbidden, "login.email_not_verified")
+	ErrLoginLocked         = Define("LOGIN_LOCKED", http.StatusTooManyRequests, "login.locked")
+	ErrRefreshTokenInvalid = Define("REFRESH_TOKEN_INVALID", http.StatusUnauthorized, "login.refresh_token_invalid")
+	ErrRefreshTokenExpired = Define("REFRESH_TOKEN_EXPIRED", http.StatusUnauthorized, "login.refresh_token_expired")
+	ErrRefreshTokenReused  = Define("REFRESH_TOKEN_REUSED", http.StatusUnauthorized, "login.refresh_token_reused")
+	ErrMFATokenInvalid     = Define("MFA_TOKEN_INVALID", http.StatusUnauthorized, "login.mfa_token_invalid")
+	ErrUserTokenInvalid    = Define("USER_TOKEN_INVALID", http.StatusBadRequest, "login.user_token_invalid")
+	ErrTokenGenerateFailed = Define("INTERNAL_ERROR", http.StatusInternalServerError, "login.token_generate_failed")
+	ErrTokenRefreshFailed  = Define("INTERNAL_ERROR", http.StatusInternalServerError, "login.token_refresh_failed")
+	ErrLogoutFailed        = Define("INTERNAL_ERROR", http.StatusInternalServerError, "login.logout_failed")
+	ErrEmailVerifyFailed   = Define("INTERNAL_ERROR", http.StatusInternalServerError, "login.email_verify_failed")
+)
+
+// パスワード
+var (
+	ErrPasswordIncorrect        = Define("PASSWORD_INCORRECT", http.StatusUnauthorized, "password.incorrect")
+	ErrCurrentPasswordIncorrect = Define("CURRENT_PASSWORD_INCORRECT", http.StatusUnauthorized, "password.current_incorrect")
+	ErrPasswordPolicy           = Define("PASSWORD_POLICY_VIOLATION", http.StatusBadRequest, "password.policy_violation")
+	ErrPasswordCheckFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "password.check_failed")
+	ErrPasswordChangeFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "password.change_failed")
+	ErrPasswordResetFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "password.reset_failed")
+)
+
+// 二要素認証
+var (
+	ErrTwoFactorCodeInvalid      = Define("TWO_FACTOR_CODE_INVALID", http.StatusUnauthorized, "two_factor.code_invalid")
+	ErrTwoFactorSetupCodeInvalid = Define("TWO_FACTOR_SETUP_CODE_INVALID", http.StatusBadRequest, "two_factor.code_invalid")
+	ErrTwoFactorNotEnabled       = Define("TWO_FACTOR_NOT_ENABLED", http.StatusBadRequest, "two_factor.not_enabled")
+	ErrTwoFactorAlreadyEnabled   = Define("TWO_FACTOR_ALREADY_ENABLED", http.StatusConflict, "two_factor.already_enabled")
+	ErrTwoFactorSetupRequired    = Define("TWO_FACTOR_SETUP_REQUIRED", http.StatusBadRequest, "two_factor.setup_required")
+	ErrTwoFactorSecretFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "two_factor.secret_failed")
+	ErrTwoFactorSetupFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "two_factor.setup_failed")
+	ErrTwoFactorEnableFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "two_factor.enable_failed")
+	ErrTwoFactorDisableFailed    = Define("INTERNAL_ERROR", http.StatusInternalServerError, "two_factor.disable_failed")
+	ErrTwoFactorVerifyFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "two_factor.verify_failed")
+	ErrRecoveryCodesFailed       = Define("INTERNAL_ERROR", http.StatusInternalServerError, "two_factor.recovery_codes_failed")
+)
+
+// ユーザー
+var (
+	ErrUserNotFound         = Define("USER_NOT_FOUND", http.StatusNotFound, "user.not_found")
+	ErrDeletedUserNotFound  = Define("DELETED_USER_NOT_FOUND", http.StatusNotFound, "user.deleted_not_found")
+	ErrUsernameTaken        = Define("USERNAME_TAKEN", http.StatusConflict, "user.username_taken")
+	ErrEmailTaken           = Define("EMAIL_TAKEN", http.StatusConflict, "user.email_taken")
+	ErrSelfOperation        = Define("SELF_OPERATION_FORBIDDEN", http.StatusBadRequest, "user.self_operation")
+	ErrLastAdmin            = Define("LAST_ADMIN", http.StatusConflict, "user.last_admin")
+	ErrLastAdminRole        = Define("LAST_ADMIN", http.StatusConflict, "user.last_admin_role")
+	ErrUserHasOrders        = Define("USER_HAS_ORDERS", http.StatusConflict, "user.has_orders")
+	ErrUserAnonymized       = Define("USER_ANONYMIZED", http.StatusConflict, "user.anonymized")
+	ErrInvalidExportFormat  = Define("INVALID_EXPORT_FORMAT", http.StatusBadRequest, "user.invalid_export_format")
+	ErrUserFetchFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.fetch_failed")
+	ErrUserCreateFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.create_failed")
+	ErrProfileUpdateFailed  = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.profile_update_failed")
+	ErrUserDeleteFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.delete_failed")
+	ErrUserActivateFailed   = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.activate_failed")
+	ErrUserDeactivateFailed = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.deactivate_failed")
+	ErrUserRestoreFailed    = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.restore_failed")
+	ErrUserPurgeFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.purge_failed")
+	ErrUnlockFailed         = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.unlock_failed")
+	ErrExportFailed         = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.export_failed")
+	ErrEraseFailed          = Define("INTERNAL_ERROR", http.StatusInternalServerError, "user.erase_failed")
+)
+
+// なりすまし・セッション・監査ログ
+var (
+	ErrImpersonationSelf           = Define("IMPERSONATION_SELF", http.StatusBadRequest, "impersonation.self")
+	ErrImpersonationTargetInactive = Define("IMPERSONATION_TARGET_INACTIVE", http.StatusForbidden, "impersonation.target_inactive")
+	ErrImpersonationEscalation     = Define("IMPERSONATION_PRIVILEGE_ESCALATION", http.StatusForbidden, "impersonation.privilege_escalation")
+	ErrSessionNotFound             = Define("SESSION_NOT_FOUND", http.StatusNotFound, "session.not_found")
+	ErrSessionFetchFailed          = Define("INTERNAL_ERROR", http.StatusInternalServerError, "session.fetch_failed")
+	ErrSessionRevokeFailed         = Define("INTERNAL_ERROR", http.StatusInternalServerError, "session.revoke_failed")
+	ErrAuditLogFetchFailed         = Define("INTERNAL_ERROR", http.StatusInternalServerError, "audit_log.fetch_failed")
+	ErrAuditLogRecordFailed        = Define("INTERNAL_ERROR", http.StatusInternalServerError, "audit_log.record_failed")
+)
+
+// ロール・APIキー
+var (
+	ErrRoleNotFound          = Define("ROLE_NOT_FOUND", http.StatusNotFound, "role.not_found")
+	ErrRoleUnknown           = Define("ROLE_UNKNOWN", http.StatusBadRequest, "role.unknown")
+	ErrRoleNameInvalid       = Define("ROLE_NAME_INVALID", http.StatusBadRequest, "role.name_invalid")
+	ErrRoleNameTaken         = Define("ROLE_NAME_TAKEN", http.StatusConflict, "role.name_taken")
+	ErrRoleInUse             = Define("ROLE_IN_USE", http.StatusConflict, "role.in_use")
+	ErrRoleBuiltin           = Define("ROLE_BUILTIN", http.StatusBadRequest, "role.builtin")
+	ErrAdminRoleImmutable    = Define("ADMIN_ROLE_IMMUTABLE", http.StatusBadRequest, "role.admin_immutable")
+	ErrRoleSelfAssign        = Define("ROLE_SELF_ASSIGN", http.StatusBadRequest, "role.self_assign")
+	ErrPermissionUnknown     = Define("PERMISSION_UNKNOWN", http.StatusBadRequest, "role.permission_unknown")
+	ErrRoleFetchFailed       = Define("INTERNAL_ERROR", http.StatusInternalServerError, "role.fetch_failed")
+	ErrRoleCreateFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "role.create_failed")
+	ErrRoleUpdateFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "role.update_failed")
+	ErrRoleDeleteFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "role.delete_failed")
+	ErrRoleAssignFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "role.assign_failed")
+	ErrPermissionFetchFailed = Define("INTERNAL_ERROR", http.StatusInternalServerError, "role.permission_fetch_failed")
+	ErrAPIKeyNotFound        = Define("API_KEY_NOT_FOUND", http.StatusNotFound, "api_key.not_found")
+	ErrAPIKeyScopeForbidden  = Define("API_KEY_SCOPE_FORBIDDEN", http.StatusForbidden, "api_key.scope_forbidden")
+	ErrAPIKeyFetchFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "api_key.fetch_failed")
+	ErrAPIKeyGenerateFailed  = Define("INTERNAL_ERROR", http.StatusInternalServerError, "api_key.generate_failed")
+	ErrAPIKeyCreateFailed    = Define("INTERNAL_ERROR", http.StatusInternalServerError, "api_key.create_failed")
+	ErrAPIKeyRevokeFailed    = Define("INTERNAL_ERROR", http.StatusInternalServerError, "api_key.revoke_failed")
+)
+
+// 商品・注文
+var (
+	ErrProductNotFound         = Define("PRODUCT_NOT_FOUND", http.StatusNotFound, "product.not_found")
+	ErrOrderProductNotFound    = Define("PRODUCT_NOT_FOUND", http.StatusNotFound, "product.not_found_with_id")
+	ErrSKUTaken                = Define("SKU_TAKEN", http.StatusConflict, "product.sku_taken")
+	ErrProductFetchFailed      = Define("INTERNAL_ERROR", http.StatusInternalServerError, "product.fetch_failed")
+	ErrProductCreateFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "product.create_failed")
+	ErrProductUpdateFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "product.update_failed")
+	ErrProductDeleteFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "product.delete_failed")
+	ErrStockUpdateFailed       = Define("INTERNAL_ERROR", http.StatusInternalServerError, "product.stock_update_failed")
+	ErrCategoryFetchFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "product.category_fetch_failed")
+	ErrOrderNotFound           = Define("ORDER_NOT_FOUND", http.StatusNotFound, "order.not_found")
+	ErrOrderNotCancellable     = Define("ORDER_NOT_CANCELLABLE", http.StatusBadRequest, "order.not_cancellable")
+	ErrOrderCancelRequired     = Define("ORDER_CANCEL_REQUIRED", http.StatusBadRequest, "order.cancel_required")
+	ErrInsufficientStock       = Define("INSUFFICIENT_STOCK", http.StatusBadRequest, "order.insufficient_stock")
+	ErrOrderFetchFailed        = Define("INTERNAL_ERROR", http.StatusInternalServerError, "order.fetch_failed")
+	ErrOrderCreateFailed       = Define("INTERNAL_ERROR", http.StatusInternalServerError, "order.create_failed")
+	ErrOrderItemCreateFailed   = Define("INTERNAL_ERROR", http.StatusInternalServerError, "order.item_create_failed")
+	ErrOrderCommitFailed       = Define("INTERNAL_ERROR", http.StatusInternalServerError, "order.commit_failed")
+	ErrOrderUpdateFailed       = Define("INTERNAL_ERROR", http.StatusInternalServerError, "order.update_failed")
+	ErrOrderStatusUpdateFailed = Define("INTERNAL_ERROR", http.StatusInternalServerError, "order.status_update_failed")
+	ErrOrderCancelFailed       = Define("INTERNAL_ERROR", http.StatusInternalServerError, "order.cancel_failed")
+)
+
+// カテゴリー
+var (
+	ErrCategoryNotFound      = Define("CATEGORY_NOT_FOUND", http.StatusNotFound, "category.not_found")
+	ErrCategoryUnknown       = Define("CATEGORY_UNKNOWN", http.StatusBadRequest, "category.unknown")
+	ErrCategorySlugTaken     = Define("CATEGORY_SLUG_TAKEN", http.StatusConflict, "category.slug_taken")
+	ErrCategoryParentInvalid = Define("CATEGORY_PARENT_INVALID", http.StatusBadRequest, "category.parent_invalid")
+	ErrCategoryInUse         = Define("CATEGORY_IN_USE", http.StatusConflict, "category.in_use")
+	ErrCategoryCreateFailed  = Define("INTERNAL_ERROR", http.StatusInternalServerError, "category.create_failed")
+	ErrCategoryUpdateFailed  = Define("INTERNAL_ERROR", http.StatusInternalServerError, "category.update_failed")
+	ErrCategoryDeleteFailed  = Define("INTERNAL_ERROR", http.StatusInternalServerError, "category.delete_failed")
+)
+
+// バリエーション
+var (
+	ErrVariantNotFound       = Define("VARIANT_NOT_FOUND", http.StatusNotFound, "variant.not_found")
+	ErrOrderVariantNotFound  = Define("VARIANT_NOT_FOUND", http.StatusNotFound, "variant.not_found_with_id")
+	ErrVariantRequired       = Define("VARIANT_REQUIRED", http.StatusBadRequest, "variant.required")
+	ErrVariantOptionsInvalid = Define("VARIANT_OPTIONS_INVALID", http.StatusBadRequest, "variant.options_invalid")
+	ErrVariantOptionsTaken   = Define("VARIANT_OPTIONS_TAKEN", http.StatusConflict, "variant.options_taken")
+	ErrVariantStockManaged   = Define("PRODUCT_HAS_VARIANTS", http.StatusBadRequest, "variant.stock_managed")
+	ErrVariantFetchFailed    = Define("INTERNAL_ERROR", http.StatusInternalServerError, "variant.fetch_failed")
+	ErrVariantCreateFailed   = Define("INTERNAL_ERROR", http.StatusInternalServerError, "variant.create_failed")
+	ErrVariantUpdateFailed   = Define("INTERNAL_ERROR", http.StatusInternalServerError, "variant.update_failed")
+	ErrVariantDeleteFailed   = Define("INTERNAL_ERROR", http.StatusInternalServerError, "variant.delete_failed")
+)
+
+// 商品画像・ファイル
+var (
+	ErrImageNotFound            = Define("IMAGE_NOT_FOUND", http.StatusNotFound, "image.not_found")
+	ErrImageRequired            = Define("IMAGE_REQUIRED", http.StatusBadRequest, "image.required")
+	ErrImageTooLarge            = Define("FILE_TOO_LARGE", http.StatusRequestEntityTooLarge, "image.too_large")
+	ErrImageTypeUnsupported     = Define("UNSUPPORTED_MEDIA_TYPE", http.StatusUnsupportedMediaType, "image.type_unsupported")
+	ErrImageInvalid             = Define("IMAGE_INVALID", http.StatusBadRequest, "image.invalid")
+	ErrImageLimitExceeded       = Define("IMAGE_LIMIT_EXCEEDED", http.StatusBadRequest, "image.limit_exceeded")
+	ErrImageUploadLimitExceeded = Define("IMAGE_LIMIT_EXCEEDED", http.StatusBadRequest, "image.upload_limit_exceeded")
+	ErrImageOrderInvalid        = Define("IMAGE_ORDER_INVALID", http.StatusBadRequest, "image.order_invalid")
+	ErrFileNotFound             = Define("FILE_NOT_FOUND", http.StatusNotFound, "file.not_found")
+	ErrImageFetchFailed         = Define("INTERNAL_ERROR", http.StatusInternalServerError, "image.fetch_failed")
+	ErrImageUploadFailed        = Define("INTERNAL_ERROR", http.StatusInternalServerError, "image.upload_failed")
+	ErrImageUpdateFailed        = Define("INTERNAL_ERROR", http.StatusInternalServerError, "image.update_failed")
+	ErrImageDeleteFailed        = Define("INTERNAL_ERROR", http.StatusInternalServerError, "image.delete_failed")
+	ErrFileFetchFailed          = Define("INTERNAL_ERROR", http.StatusInternalServerError, "file.fetch_failed")
+)
+
+// 商品のインポート・エクスポート
+var (
+	ErrImportFileRequired      = Define("IMPORT_FILE_REQUIRED", http.StatusBadRequest, "import.file_required")
+	ErrImportFormatUnsupported = Define("UNSUPPORTED_MEDIA_TYPE", http.StatusUnsupportedMediaType, "import.format_unsupported")
+	ErrImportTooLarge          = Define("FILE_TOO_LARGE", http.StatusRequestEntityTooLarge, "import.too_large")
+	ErrImportFileInvalid       = Define("IMPORT_FILE_INVALID", http.StatusBadRequest, "import.file_invalid")
+	ErrImportHeaderInvalid     = Define("IMPORT_FILE_INVALID", http.StatusBadRequest, "import.header_invalid")
+	ErrImportColumnCount       = Define("IMPORT_ROW_INVALID", http.StatusBadRequest, "import.column_count")
+	ErrImportDuplicateSKU      = Define("IMPORT_DUPLICATE_SKU", http.StatusConflict, "import.duplicate_sku")
+	ErrImportFailed            = Define("INTERNAL_ERROR", http.StatusInternalServerError, "import.failed")
+	ErrProductExportFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "product.export_failed")
+)
+
+// 在庫
+var (
+	ErrStockNegative        = Define("INSUFFICIENT_STOCK", http.StatusBadRequest, "stock.negative")
+	ErrStockVariantRequired = Define("VARIANT_REQUIRED", http.StatusBadRequest, "stock.variant_required")
+	ErrStockReturnDelta     = Define("STOCK_RETURN_INVALID", http.StatusBadRequest, "stock.return_delta")
+	ErrStockReturnOrder     = Define("STOCK_RETURN_INVALID", http.StatusBadRequest, "stock.return_order")
+	ErrStockReturnExceeded  = Define("STOCK_RETURN_INVALID", http.StatusBadRequest, "stock.return_exceeded")
+	ErrStockFetchFailed     = Define("INTERNAL_ERROR", http.StatusInternalServerError, "stock.fetch_failed")
+	ErrStockAdjustFailed    = Define("INTERNAL_ERROR", http.StatusInternalServerError, "stock.adjust_failed")
+)
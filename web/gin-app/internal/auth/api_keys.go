@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// APIキーの形式
+// "gk_" に続く8文字がプレフィックス（一覧表示用）、"_" 以降が秘密部分です
+const (
+	apiKeyScheme      = "gk_"
+	apiKeyPrefixBytes = 6  // プレフィックスの乱数部分のバイト数（Base64で8文字）
+	apiKeySecretBytes = 32 // 秘密部分の乱数のバイト数
+)
+
+// lastUsedInterval は最終使用日時を更新する最小間隔です
+// リクエストのたびに書き込みが発生しないよう、この間隔より短い更新は省略します
+const lastUsedInterval = 1 * time.Minute
+
+// APIキー認証で返されるエラー
+var (
+	ErrAPIKeyInvalid = errors.New("無効なAPIキーです")
+	ErrAPIKeyExpired = errors.New("APIキーの有効期限が切れています")
+	ErrAPIKeyRevoked = errors.New("このAPIキーは失効しています")
+)
+
+// APIKeyStore はAPIキーの発行と認証を行う構造体です
+// 失効が即座に反映されるよう、認証のたびにデータベースで確認します
+type APIKeyStore struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyStore は新しいAPIKeyStoreを作成します
+func NewAPIKeyStore(db *gorm.DB) *APIKeyStore {
+	return &APIKeyStore{db: db}
+}
+
+// GenerateKey は新しいAPIキーを生成します
+// キー本体と、データベースに保存する表示用プレフィックスを返します
+func GenerateKey() (key, prefix string, err error) {
+	p, err := utils.GenerateSecureToken(apiKeyPrefixBytes)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := utils.GenerateSecureToken(apiKeySecretBytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix = apiKeyScheme + p
+	return prefix + "_" + secret, prefix, nil
+}
+
+// Authenticate はAPIキーを検証し、対応するレコードを返します
+// 存在しない・失効済み・有効期限切れのキーはエラーになります
+func (s *APIKeyStore) Authenticate(key string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	if err := s.db.Where("key_hash = ?", utils.HashToken(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+
+	if apiKey.IsRevoked() {
+		return nil, ErrAPIKeyRevoked
+	}
+	if apiKey.IsExpired() {
+		return nil, ErrAPIKeyExpired
+	}
+
+	s.touch(&apiKey)
+
+	return &apiKey, nil
+}
+
+// touch はAPIキーの最終使用日時を更新します
+// 更新に失敗しても認証自体は成功として扱います
+func (s *APIKeyStore) touch(apiKey *models.APIKey) {
+	now := time.Now()
+	if apiKey.LastUsedAt != nil && now.Sub(*apiKey.LastUsedAt) < lastUsedInterval {
+		return
+	}
+
+	if err := s.db.Model(apiKey).UpdateColumn("last_used_at", now).Error; err != nil {
+		log.Printf("APIキーの最終使用日時の更新に失敗しました: api_key_id=%d: %v", apiKey.ID, err)
+		return
+	}
+	apiKey.LastUsedAt = &now
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"log"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogger は監査ログを記録する構造体です
+// なりすまし等、後から追跡できる必要がある操作を記録します
+type AuditLogger struct {
+	db *gorm.DB
+}
+
+// NewAuditLogger は新しいAuditLoggerを作成します
+func NewAuditLogger(db *gorm.DB) *AuditLogger {
+	return &AuditLogger{db: db}
+}
+
+// Record は監査ログを記録します
+// 記録に失敗した場合も、証跡が失われたことが分かるよう内容をログに出力します
+func (a *AuditLogger) Record(entry *models.AuditLog) error {
+	if err := a.db.Create(entry).Error; err != nil {
+		log.Printf("監査ログの記録に失敗しました: action=%s actor_id=%d user_id=%d %s %s: %v",
+			entry.Action, entry.ActorID, entry.UserID, entry.Method, entry.Path, err)
+		return err
+	}
+	return nil
+}
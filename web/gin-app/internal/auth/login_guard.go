@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// failureWindow はログイン失敗回数を保持する期間です
+// 最後の失敗からこの期間が経過すると、失敗回数はリセットされます
+const failureWindow = 24 * time.Hour
+
+// LoginGuard はログインの総当たり攻撃を防ぐための構造体です
+// アカウントごとの失敗回数はデータベースに、IPアドレスごとと
+// 存在しないユーザー名ごとの失敗回数はメモリ上に記録します
+// 失敗回数が上限を超えると、指数関数的に延びる期間だけログインを拒否します
+type LoginGuard struct {
+	db  *gorm.DB
+	cfg config.AuthConfig
+
+	mu       sync.Mutex
+	attempts map[string]*attempt // "ip:<IP>" または "name:<ユーザー名>" ごとの失敗記録
+
+	dummyHash []byte // 存在しないユーザーでもパスワード検証と同じ時間をかけるためのハッシュ
+}
+
+// attempt はメモリ上で管理するログイン失敗の記録です
+type attempt struct {
+	failures    int       // 連続失敗回数
+	lastFailure time.Time // 最後に失敗した時刻
+	lockedUntil time.Time // この時刻までログインを拒否
+}
+
+// NewLoginGuard は新しいLoginGuardを作成します
+func NewLoginGuard(db *gorm.DB, cfg config.AuthConfig) *LoginGuard {
+	// 実在するユーザーと同じコストのハッシュを用意する
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+
+	g := &LoginGuard{
+		db:        db,
+		cfg:       cfg,
+		attempts:  make(map[string]*attempt),
+		dummyHash: dummyHash,
+	}
+
+	// 定期的に古い失敗記録をクリーンアップ
+	go g.cleanup()
+
+	return g
+}
+
+// lockDuration は失敗回数に応じたログイン拒否期間を返します
+// 上限回数までは拒否せず、それ以降は失敗のたびに期間を2倍にします（上限あり）
+func lockDuration(failures, maxAttempts int, base, max time.Duration) time.Duration {
+	if failures < maxAttempts {
+		return 0
+	}
+
+	d := base
+	for i := maxAttempts; i < failures; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	return d
+}
+
+// CheckIP はIPアドレスからのログインが一時的に拒否されているかを判定します
+// 拒否されている場合は、再試行までの待ち時間を返します
+func (g *LoginGuard) CheckIP(ip string) (time.Duration, bool) {
+	return g.checkMemory("ip:" + ip)
+}
+
+// CheckUnknownUser は存在しないユーザー名でのログインが一時的に拒否されているかを判定します
+// 実在するアカウントと同じようにロックされることで、アカウントの有無を推測されにくくします
+func (g *LoginGuard) CheckUnknownUser(identifier string) (time.Duration, bool) {
+	return g.checkMemory("name:" + strings.ToLower(identifier))
+}
+
+// CheckUser はアカウントが一時的にロックされているかを判定します
+func (g *LoginGuard) CheckUser(user *models.User) (time.Duration, bool) {
+	if user.LockedUntil == nil {
+		return 0, false
+	}
+	if wait := time.Until(*user.LockedUntil); wait > 0 {
+		return wait, true
+	}
+	return 0, false
+}
+
+// EqualizeTiming は存在しないユーザーのログイン時にダミーのパスワード検証を行います
+// 応答時間の差からアカウントの有無が推測されるのを防ぎます
+func (g *LoginGuard) EqualizeTiming(password string) {
+	_ = bcrypt.CompareHashAndPassword(g.dummyHash, []byte(password))
+}
+
+// RecordUserFailure はアカウントのログイン失敗を記録します
+// 同時に複数のリクエストが失敗しても回数を取りこぼさないよう、行ロックを取得して更新します
+func (g *LoginGuard) RecordUserFailure(userID uint, ip string) error {
+	g.recordMemory("ip:"+ip, g.cfg.LoginIPMaxAttempts)
+
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "failed_login_attempts", "last_failed_login_at").
+			First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		failures := user.FailedLoginAttempts + 1
+		if user.LastFailedLoginAt != nil && now.Sub(*user.LastFailedLoginAt) > failureWindow {
+			failures = 1
+		}
+
+		updates := map[string]interface{}{
+			"failed_login_attempts": failures,
+			"last_failed_login_at":  now,
+		}
+		if d := lockDuration(failures, g.cfg.LoginMaxAttempts, g.cfg.LoginLockoutBase, g.cfg.LoginLockoutMax); d > 0 {
+			updates["locked_until"] = now.Add(d)
+		}
+
+		return tx.Model(&user).Updates(updates).Error
+	})
+}
+
+// RecordUnknownUserFailure は存在しないユーザー名でのログイン失敗を記録します
+func (g *LoginGuard) RecordUnknownUserFailure(identifier, ip string) {
+	g.recordMemory("ip:"+ip, g.cfg.LoginIPMaxAttempts)
+	g.recordMemory("name:"+strings.ToLower(identifier), g.cfg.LoginMaxAttempts)
+}
+
+// RecordSuccess はログイン成功時にアカウントの失敗回数をリセットします
+// IPアドレスごとの失敗回数は、有効なアカウントを1つ持つ攻撃者が
+// リセットできないよう、成功してもリセットしません
+func (g *LoginGuard) RecordSuccess(userID uint) error {
+	return g.Unlock(userID)
+}
+
+// Unlock はアカウントのロックを解除し、失敗回数をリセットします
+func (g *LoginGuard) Unlock(userID uint) error {
+	return g.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+		"locked_until":          nil,
+	}).Error
+}
+
+// checkMemory はメモリ上の失敗記録からログイン拒否中かを判定します
+func (g *LoginGuard) checkMemory(key string) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a, ok := g.attempts[key]
+	if !ok {
+		return 0, false
+	}
+	if wait := time.Until(a.lockedUntil); wait > 0 {
+		return wait, true
+	}
+	return 0, false
+}
+
+// recordMemory はメモリ上に失敗を記録し、必要に応じてロック期間を設定します
+func (g *LoginGuard) recordMemory(key string, maxAttempts int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	a, ok := g.attempts[key]
+	if !ok || now.Sub(a.lastFailure) > failureWindow {
+		a = &attempt{}
+		g.attempts[key] = a
+	}
+
+	a.failures++
+	a.lastFailure = now
+	if d := lockDuration(a.failures, maxAttempts, g.cfg.LoginLockoutBase, g.cfg.LoginLockoutMax); d > 0 {
+		a.lockedUntil = now.Add(d)
+	}
+}
+
+// cleanup は失敗回数の保持期間を過ぎた記録を削除します
+// メモリリークを防ぐため、バックグラウンドで定期実行されます
+func (g *LoginGuard) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		g.mu.Lock()
+		now := time.Now()
+
+		for key, a := range g.attempts {
+			if now.Sub(a.lastFailure) > failureWindow && now.After(a.lockedUntil) {
+				delete(g.attempts, key)
+			}
+		}
+
+		g.mu.Unlock()
+	}
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// passwordMaxBytes はパスワードの最大バイト数です
+// bcrypt は72バイトを超える部分を扱えないため、それ以上の長さは受け付けません
+const passwordMaxBytes = 72
+
+// PasswordPolicyError はパスワードがポリシーを満たさない場合のエラーです
+// 満たしていない要件を全て Violations に含めます（メッセージはレスポンスの言語で変換します）
+type PasswordPolicyError struct {
+	Violations []i18n.Message
+}
+
+// Error はエラーメッセージを返します（ログ出力用のため、デフォルトの言語を使用します）
+func (e *PasswordPolicyError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, v.Translate(i18n.DefaultLocale))
+	}
+	return i18n.Translate(i18n.DefaultLocale, "validation.strong_password") + ": " + strings.Join(violations, ", ")
+}
+
+// PasswordPolicy はパスワードポリシーを検証する構造体です
+// 文字数・文字種の要件に加えて、よく使われている（漏洩が確認されている）パスワードと
+// 直近に使用したパスワードの再利用を禁止します
+type PasswordPolicy struct {
+	db        *gorm.DB
+	cfg       config.AuthConfig
+	blocklist map[string]struct{} // 使用を禁止するパスワード（小文字に正規化）
+}
+
+// NewPasswordPolicy は新しいPasswordPolicyを作成します
+// AUTH_PASSWORD_BLOCKLIST_FILE が設定されている場合はファイルを読み込み、
+// 読み込めない場合はエラーを返します
+func NewPasswordPolicy(db *gorm.DB, cfg config.AuthConfig) (*PasswordPolicy, error) {
+	p := &PasswordPolicy{
+		db:        db,
+		cfg:       cfg,
+		blocklist: make(map[string]struct{}),
+	}
+
+	if cfg.PasswordBlocklistFile != "" {
+		if err := p.loadBlocklist(cfg.PasswordBlocklistFile); err != nil {
+			return nil, fmt.Errorf("パスワードの禁止リストの読み込みに失敗しました: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// loadBlocklist は1行に1つのパスワードを記載したファイルを読み込みます
+// 空行と "#" で始まる行は無視します
+func (p *PasswordPolicy) loadBlocklist(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Validate は新しいパスワードがポリシーを満たすかを検証します
+// user を指定した場合（パスワードの変更・再設定）は、直近のパスワードの再利用も確認します
+// ポリシーを満たさない場合は *PasswordPolicyError を返します
+func (p *PasswordPolicy) Validate(password string, user *models.User) error {
+	violations := p.CheckRules(password)
+
+	// 文字数等の要件を満たさない場合は、コストの高い履歴の確認を省略する
+	if len(violations) == 0 && user != nil {
+		reused, err := p.isReused(password, user)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violations = append(violations, i18n.NewMessage("password.rule.reused", p.cfg.PasswordHistory))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// CheckRules は文字数・文字種・禁止リストの要件を確認し、満たしていない要件を返します
+// リクエストのバインド時の検証（strong_password）にも使用します
+func (p *PasswordPolicy) CheckRules(password string) []i18n.Message {
+	var violations []i18n.Message
+
+	if utf8.RuneCountInString(password) < p.cfg.PasswordMinLength {
+		violations = append(violations, i18n.NewMessage("password.rule.min_length", p.cfg.PasswordMinLength))
+	}
+	if len(password) > passwordMaxBytes {
+		violations = append(violations, i18n.NewMessage("password.rule.max_bytes", passwordMaxBytes))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.cfg.PasswordRequireUpper && !hasUpper {
+		violations = append(violations, i18n.NewMessage("password.rule.upper"))
+	}
+	if p.cfg.PasswordRequireLower && !hasLower {
+		violations = append(violations, i18n.NewMessage("password.rule.lower"))
+	}
+	if p.cfg.PasswordRequireDigit && !hasDigit {
+		violations = append(violations, i18n.NewMessage("password.rule.digit"))
+	}
+	if p.cfg.PasswordRequireSymbol && !hasSymbol {
+		violations = append(violations, i18n.NewMessage("password.rule.symbol"))
+	}
+
+	if _, blocked := p.blocklist[strings.ToLower(password)]; blocked {
+		violations = append(violations, i18n.NewMessage("password.rule.blocked"))
+	}
+
+	return violations
+}
+
+// isReused はパスワードが現在のパスワードまたは履歴に残る直近のパスワードと一致するかを返します
+func (p *PasswordPolicy) isReused(password string, user *models.User) (bool, error) {
+	if p.cfg.PasswordHistory <= 0 {
+		return false, nil
+	}
+
+	// 履歴の導入前に設定されたパスワードは履歴にないため、現在のパスワードも確認する
+	if user.Password != "" && user.CheckPassword(password) {
+		return true, nil
+	}
+
+	var history []models.PasswordHistory
+	if err := p.db.Where("user_id = ?", user.ID).
+		Order("created_at DESC, id DESC").
+		Limit(p.cfg.PasswordHistory).
+		Find(&history).Error; err != nil {
+		return false, err
+	}
+
+	for _, h := range history {
+		if bcrypt.CompareHashAndPassword([]byte(h.PasswordHash), []byte(password)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Record はユーザーの現在のパスワード（ハッシュ値）を履歴に記録します
+// パスワードを設定した直後に呼び出し、AUTH_PASSWORD_HISTORY を超える古い履歴は削除します
+// トランザクション内で呼び出せるよう、使用するデータベース接続を指定します
+func (p *PasswordPolicy) Record(tx *gorm.DB, user *models.User) error {
+	if p.cfg.PasswordHistory <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&models.PasswordHistory{
+		UserID:       user.ID,
+		PasswordHash: user.Password,
+	}).Error; err != nil {
+		return err
+	}
+
+	// 保持件数を超えた古い履歴を削除
+	keep := tx.Model(&models.PasswordHistory{}).Select("id").
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC, id DESC").
+		Limit(p.cfg.PasswordHistory)
+	return tx.Where("user_id = ? AND id NOT IN (?)", user.ID, keep).
+		Delete(&models.PasswordHistory{}).Error
+}
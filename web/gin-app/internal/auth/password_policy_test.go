@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+)
+
+// testPasswordConfig はデフォルト設定と同じパスワードポリシーです（記号は必須）
+func testPasswordConfig() config.AuthConfig {
+	return config.AuthConfig{
+		PasswordMinLength:     8,
+		PasswordRequireUpper:  true,
+		PasswordRequireLower:  true,
+		PasswordRequireDigit:  true,
+		PasswordRequireSymbol: true,
+	}
+}
+
+// violationKeys は満たしていない要件を、メッセージキーの password.rule. に続く部分で返します
+func violationKeys(p *PasswordPolicy, password string) []string {
+	return ruleNames(p.CheckRules(password))
+}
+
+// ruleNames はメッセージを要件の名前に変換します
+func ruleNames(violations []i18n.Message) []string {
+	var names []string
+	for _, v := range violations {
+		names = append(names, strings.TrimPrefix(v.Key, "password.rule."))
+	}
+	return names
+}
+
+// TestPasswordPolicyCheckRules は文字数・文字種の要件を満たしていない全ての要件を返すことを確認します
+func TestPasswordPolicyCheckRules(t *testing.T) {
+	policy, err := NewPasswordPolicy(nil, testPasswordConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"valid", "Passw0rd!", nil},
+		{"multibyte_symbol", "Passw0rd＃", nil},
+		{"too_short", "Pa0!", []string{"min_length"}},
+		{"multibyte_counts_runes", "Pあいう0!x", []string{"min_length"}},
+		{"too_long", "Aa0!" + strings.Repeat("x", 69), []string{"max_bytes"}},
+		{"no_upper", "passw0rd!", []string{"upper"}},
+		{"no_lower", "PASSW0RD!", []string{"lower"}},
+		{"no_digit", "Password!", []string{"digit"}},
+		{"no_symbol", "Passw0rdd", []string{"symbol"}},
+		{"empty", "", []string{
+			"min_length", "upper", "lower",
+			"digit", "symbol",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := violationKeys(policy, tt.password); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPasswordPolicyOptionalRules は無効にした文字種の要件を確認しないことを確認します
+func TestPasswordPolicyOptionalRules(t *testing.T) {
+	policy, err := NewPasswordPolicy(nil, config.AuthConfig{PasswordMinLength: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := violationKeys(policy, "abcd"); got != nil {
+		t.Errorf("got %v", got)
+	}
+
+	// 最大バイト数は設定に関わらず確認する
+	if got := violationKeys(policy, strings.Repeat("a", 73)); !reflect.DeepEqual(got, []string{"max_bytes"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+// TestPasswordPolicyBlocklist は禁止リストのパスワードを大文字・小文字を区別せずに拒否することを確認します
+func TestPasswordPolicyBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	content := "# よく使われているパスワード\n\nPassw0rd!\n  Welcome1!  \n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testPasswordConfig()
+	cfg.PasswordBlocklistFile = path
+	policy, err := NewPasswordPolicy(nil, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"listed", "Passw0rd!", []string{"blocked"}},
+		{"longer", "Passw0rd!x", nil},
+		{"case_insensitive", "pASSW0RD!", []string{"blocked"}},
+		{"trimmed_line", "Welcome1!", []string{"blocked"}},
+		{"comment_line", "# よく使われているパスワード", []string{
+			"upper", "lower", "digit",
+		}},
+		{"not_listed", "Tr0ub4dor&3", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := violationKeys(policy, tt.password); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewPasswordPolicyBlocklistMissing は禁止リストのファイルを読み込めない場合にエラーを返すことを確認します
+func TestNewPasswordPolicyBlocklistMissing(t *testing.T) {
+	cfg := testPasswordConfig()
+	cfg.PasswordBlocklistFile = filepath.Join(t.TempDir(), "missing.txt")
+	if _, err := NewPasswordPolicy(nil, cfg); err == nil {
+		t.Error("エラーになっていません")
+	}
+}
+
+// TestPasswordPolicyValidate は要件を満たさない場合と現在のパスワードを再利用する場合のエラーを確認します
+// いずれも履歴を確認する前に判定できるため、データベースは使用しません
+func TestPasswordPolicyValidate(t *testing.T) {
+	user := &models.User{}
+	if err := user.SetPassword("Current0!"); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testPasswordConfig()
+	cfg.PasswordHistory = 5
+	withHistory, _ := NewPasswordPolicy(nil, cfg)
+	cfg.PasswordHistory = 0
+	withoutHistory, _ := NewPasswordPolicy(nil, cfg)
+
+	tests := []struct {
+		name     string
+		policy   *PasswordPolicy
+		password string
+		user     *models.User
+		want     []string
+	}{
+		{"valid_without_user", withHistory, "Passw0rd!", nil, nil},
+		{"rules_without_user", withHistory, "password", nil, []string{
+			"upper", "digit", "symbol",
+		}},
+		{"rules_skip_history", withHistory, "Current0", user, []string{"symbol"}},
+		{"reuse_current", withHistory, "Current0!", user, []string{"reused"}},
+		{"history_disabled", withoutHistory, "Current0!", user, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password, tt.user)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+
+			var policyErr *PasswordPolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("got %v, want *PasswordPolicyError", err)
+			}
+			if got := ruleNames(policyErr.Violations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PermissionStore はロールごとの権限をキャッシュする構造体です
+// 権限チェックのたびにデータベースへ問い合わせないよう、全ロールの権限をメモリに保持し、
+// ttl が経過したら再読み込みします（他のインスタンスでの変更もこの間隔で反映されます）
+type PermissionStore struct {
+	db  *gorm.DB
+	ttl time.Duration
+
+	mu       sync.RWMutex
+	roles    map[string]map[string]struct{} // ロール名 -> 権限名の集合
+	loadedAt time.Time                      // 最後にデータベースから読み込んだ時刻
+}
+
+// NewPermissionStore は新しいPermissionStoreを作成します
+// ttl: ロールと権限の対応をキャッシュする期間
+func NewPermissionStore(db *gorm.DB, ttl time.Duration) *PermissionStore {
+	s := &PermissionStore{
+		db:    db,
+		ttl:   ttl,
+		roles: make(map[string]map[string]struct{}),
+	}
+
+	if err := s.reload(); err != nil {
+		log.Printf("ロール権限の読み込みに失敗しました: %v", err)
+	}
+
+	return s
+}
+
+// HasPermission はロールが指定した権限を持つかどうかを返します
+// 存在しないロールは権限を持たないものとして扱います
+func (s *PermissionStore) HasPermission(role, permission string) bool {
+	s.refreshIfStale()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.roles[role][permission]
+	return ok
+}
+
+// Permissions はロールが持つ権限名の一覧を返します
+func (s *PermissionStore) Permissions(role string) []string {
+	s.refreshIfStale()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.roles[role]))
+	for name := range s.roles[role] {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Invalidate はキャッシュを破棄し、データベースから再読み込みします
+// ロールの権限を変更した直後に呼び出し、次のリクエストから即座に反映させます
+func (s *PermissionStore) Invalidate() {
+	if err := s.reload(); err != nil {
+		log.Printf("ロール権限の再読み込みに失敗しました: %v", err)
+	}
+}
+
+// refreshIfStale はキャッシュが古い場合にデータベースから再読み込みします
+// 読み込みに失敗した場合は、古いキャッシュのまま処理を続けます
+func (s *PermissionStore) refreshIfStale() {
+	s.mu.RLock()
+	stale := time.Since(s.loadedAt) >= s.ttl
+	s.mu.RUnlock()
+
+	if stale {
+		if err := s.reload(); err != nil {
+			log.Printf("ロール権限の再読み込みに失敗しました: %v", err)
+		}
+	}
+}
+
+// reload はデータベースから全ロールの権限を読み込みます
+func (s *PermissionStore) reload() error {
+	var roles []models.Role
+	if err := s.db.Preload("Permissions").Find(&roles).Error; err != nil {
+		// 失敗した場合も連続して問い合わせないよう、読み込み時刻は更新する
+		s.mu.Lock()
+		s.loadedAt = time.Now()
+		s.mu.Unlock()
+		return err
+	}
+
+	loaded := make(map[string]map[string]struct{}, len(roles))
+	for _, r := range roles {
+		perms := make(map[string]struct{}, len(r.Permissions))
+		for _, p := range r.Permissions {
+			perms[p.Name] = struct{}{}
+		}
+		loaded[r.Name] = perms
+	}
+
+	s.mu.Lock()
+	s.roles = loaded
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,253 @@
+// Package auth は認証に関するサーバー側の状態を管理します
+// トークンの失効リストやユーザーの有効状態など、JWTだけでは表現できない情報を扱います
+package auth
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore は失効したトークン（jti）の一覧を管理する構造体です
+// 失効情報はデータベースに永続化し、メモリ上のキャッシュで高速に判定します
+// 複数インスタンスで動作する場合に備えて、定期的にデータベースから再読み込みします
+type RevocationStore struct {
+	db *gorm.DB
+	mu sync.RWMutex
+
+	revoked map[string]time.Time // jti -> トークンの有効期限
+	users   map[uint]userStatus  // ユーザーIDごとの有効状態キャッシュ
+	userTTL time.Duration        // ユーザー状態キャッシュの有効期間
+}
+
+// userStatus はキャッシュされたユーザーの有効状態です
+type userStatus struct {
+	active            bool      // ログイン可能な状態か（is_active かつ未削除）
+	sessionsRevokedAt time.Time // この時刻より前に発行されたトークンは無効
+	locale            string    // ユーザーが設定したメッセージの言語（未設定の場合は空）
+	checkedAt         time.Time // データベースで確認した時刻
+}
+
+// 認証時のユーザー状態チェックで返されるエラー
+var (
+	ErrUserInactive    = errors.New("このアカウントは利用できません")
+	ErrSessionsRevoked = errors.New("このトークンは無効化されています。再度ログインしてください")
+)
+
+// syncInterval はデータベースから失効リストを再読み込みする間隔です
+const syncInterval = 1 * time.Minute
+
+// sessionKeyPrefix はセッション単位の失効を失効リストに記録する際のキーの接頭辞です
+// jti と同じテーブルで管理し、セッションで発行された全てのアクセストークンを拒否します
+const sessionKeyPrefix = "sid:"
+
+// NewRevocationStore は新しいRevocationStoreを作成します
+// userTTL: ユーザーの有効状態をキャッシュする期間
+func NewRevocationStore(db *gorm.DB, userTTL time.Duration) *RevocationStore {
+	s := &RevocationStore{
+		db:      db,
+		revoked: make(map[string]time.Time),
+		users:   make(map[uint]userStatus),
+		userTTL: userTTL,
+	}
+
+	if err := s.reload(); err != nil {
+		log.Printf("失効トークンの読み込みに失敗しました: %v", err)
+	}
+
+	// 定期的にデータベースと同期し、期限切れのレコードを削除
+	go s.syncLoop()
+
+	return s
+}
+
+// Revoke はトークンを失効させます
+// expiresAt にはトークン本来の有効期限を指定します（それ以降は記録を保持する必要がない）
+func (s *RevocationStore) Revoke(jti string, userID uint, expiresAt time.Time) error {
+	record := models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+
+	// 同じjtiが既に失効済みの場合は何もしない
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error; err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.revoked[jti] = expiresAt
+	s.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked はトークンが失効しているかどうかを返します
+func (s *RevocationStore) IsRevoked(jti string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.revoked[jti]
+	return ok
+}
+
+// RevokeSession はログインセッション（sid）で発行された全てのアクセストークンを失効させます
+// expiresAt には最後に発行したアクセストークンの有効期限以降の時刻を指定します
+func (s *RevocationStore) RevokeSession(sid string, userID uint, expiresAt time.Time) error {
+	return s.Revoke(sessionKeyPrefix+sid, userID, expiresAt)
+}
+
+// IsSessionRevoked はログインセッション（sid）が失効しているかどうかを返します
+func (s *RevocationStore) IsSessionRevoked(sid string) bool {
+	return s.IsRevoked(sessionKeyPrefix + sid)
+}
+
+// CheckUser はユーザーのトークンが利用可能かどうかを判定します
+// ユーザーが無効化・削除されている場合は ErrUserInactive を、
+// 全セッションの無効化（パスワードリセット等）より前に発行されたトークンの場合は
+// ErrSessionsRevoked を返します。結果は userTTL の間キャッシュされます
+// データベースの問い合わせに失敗した場合は、そのエラーを返します（結果はキャッシュしません）
+func (s *RevocationStore) CheckUser(userID uint, issuedAt time.Time) error {
+	status, err := s.userStatus(userID)
+	if err != nil {
+		return err
+	}
+
+	if !status.active {
+		return ErrUserInactive
+	}
+
+	// JWTの発行時刻は秒単位のため、比較も秒単位で行う
+	if issuedAt.Before(status.sessionsRevokedAt.Truncate(time.Second)) {
+		return ErrSessionsRevoked
+	}
+
+	return nil
+}
+
+// UserLocale はユーザーが設定したメッセージの言語を返します（未設定の場合は空文字）
+// CheckUser と同じキャッシュを使用するため、認証済みのリクエストでは追加の問い合わせは発生しません
+// 問い合わせに失敗した場合も空文字を返します（Accept-Language の言語を使用します）
+func (s *RevocationStore) UserLocale(userID uint) string {
+	status, err := s.userStatus(userID)
+	if err != nil {
+		return ""
+	}
+	return status.locale
+}
+
+// userStatus はキャッシュまたはデータベースからユーザーの状態を取得します
+// 一時的なデータベースのエラーでユーザーを userTTL の間拒否しないよう、問い合わせに失敗した場合はキャッシュしません
+func (s *RevocationStore) userStatus(userID uint) (userStatus, error) {
+	s.mu.RLock()
+	status, ok := s.users[userID]
+	s.mu.RUnlock()
+
+	if ok && time.Since(status.checkedAt) < s.userTTL {
+		return status, nil
+	}
+
+	// キャッシュがない、または古い場合はデータベースで確認
+	// ソフトデリートされたユーザーは見つからないため無効として扱われます
+	var user models.User
+	status = userStatus{checkedAt: time.Now()}
+	err := s.db.Select("id", "is_active", "sessions_revoked_at", "locale").First(&user, userID).Error
+	switch {
+	case err == nil:
+		status.active = user.IsActive
+		status.locale = user.Locale
+		if user.SessionsRevokedAt != nil {
+			status.sessionsRevokedAt = *user.SessionsRevokedAt
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return userStatus{}, err
+	}
+
+	s.mu.Lock()
+	s.users[userID] = status
+	s.mu.Unlock()
+
+	return status, nil
+}
+
+// RevokeUserSessions はユーザーの全てのセッションを無効化します
+// 現在時刻より前に発行されたアクセストークンを全て拒否するよう記録し、
+// リフレッシュトークンとログインセッションも全て失効させます
+func (s *RevocationStore) RevokeUserSessions(userID uint) error {
+	now := time.Now()
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("sessions_revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+
+	s.ForgetUser(userID)
+	return err
+}
+
+// ForgetUser はユーザー状態のキャッシュを破棄します
+// ユーザーの無効化や削除、言語の変更の直後に呼び出し、次のリクエストから即座に反映させます
+func (s *RevocationStore) ForgetUser(userID uint) {
+	s.mu.Lock()
+	delete(s.users, userID)
+	s.mu.Unlock()
+}
+
+// reload はデータベースから有効期限内の失効トークンを読み込みます
+func (s *RevocationStore) reload() error {
+	var records []models.RevokedToken
+	if err := s.db.Where("expires_at > ?", time.Now()).Find(&records).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 他のインスタンスで失効したトークンを取り込む
+	for _, r := range records {
+		s.revoked[r.JTI] = r.ExpiresAt
+	}
+
+	// 有効期限を過ぎたトークンはキャッシュからも削除
+	for jti, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, jti)
+		}
+	}
+
+	return nil
+}
+
+// syncLoop は定期的にデータベースと同期します
+// 期限切れの失効レコードはトークン自体が無効なので削除します
+func (s *RevocationStore) syncLoop() {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.db.Where("expires_at <= ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+			log.Printf("期限切れの失効トークンの削除に失敗しました: %v", err)
+		}
+		if err := s.reload(); err != nil {
+			log.Printf("失効トークンの同期に失敗しました: %v", err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// lastSeenInterval はセッションの最終アクセス日時を更新する最小間隔です
+// リクエストのたびに書き込みが発生しないよう、この間隔より短い更新は省略します
+const lastSeenInterval = 1 * time.Minute
+
+// SessionStore はログインセッション（ログイン中の端末）を管理する構造体です
+// セッションはリフレッシュトークンのファミリーに対応し、アクセストークンには sid クレームとして含まれます
+// セッションを失効させると、そのセッションで発行されたアクセストークンとリフレッシュトークンが全て拒否されます
+type SessionStore struct {
+	db          *gorm.DB
+	revocations *RevocationStore
+	accessTTL   time.Duration // アクセストークンの有効期間
+
+	mu       sync.Mutex
+	touched  map[string]time.Time // sid -> 最終アクセス日時を記録した時刻
+	prunedAt time.Time            // touched から古いエントリを削除した時刻
+}
+
+// NewSessionStore は新しいSessionStoreを作成します
+// accessTTL: アクセストークンの有効期間（セッション失効の記録を保持する期間）
+func NewSessionStore(db *gorm.DB, revocations *RevocationStore, accessTTL time.Duration) *SessionStore {
+	return &SessionStore{
+		db:          db,
+		revocations: revocations,
+		accessTTL:   accessTTL,
+		touched:     make(map[string]time.Time),
+	}
+}
+
+// Touch はセッションの最終アクセス日時を更新します
+// 同じセッションの更新は lastSeenInterval に一度までに抑えます
+// 更新に失敗しても認証自体は成功として扱います
+func (s *SessionStore) Touch(sid string) {
+	now := time.Now()
+
+	s.mu.Lock()
+	if last, ok := s.touched[sid]; ok && now.Sub(last) < lastSeenInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.touched[sid] = now
+
+	// 間隔を過ぎたエントリは保持する必要がないため定期的に削除
+	if now.Sub(s.prunedAt) >= lastSeenInterval {
+		for id, last := range s.touched {
+			if now.Sub(last) >= lastSeenInterval {
+				delete(s.touched, id)
+			}
+		}
+		s.prunedAt = now
+	}
+	s.mu.Unlock()
+
+	if err := s.db.Model(&models.Session{}).Where("family_id = ?", sid).
+		UpdateColumn("last_seen_at", now).Error; err != nil {
+		log.Printf("セッションの最終アクセス日時の更新に失敗しました: %v", err)
+	}
+}
+
+// Revoke はセッションを失効させます
+// セッションのリフレッシュトークンを失効させ、発行済みのアクセストークンも有効期限まで拒否します
+func (s *SessionStore) Revoke(session *models.Session) error {
+	now := time.Now()
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", session.ID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", session.FamilyID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// アクセストークンの有効期限はリフレッシュのたびに延びるため、現在時刻から数える
+	if err := s.revocations.RevokeSession(session.FamilyID, session.UserID, now.Add(s.accessTTL)); err != nil {
+		return err
+	}
+
+	session.RevokedAt = &now
+	return nil
+}
+
+// RevokeFamily はリフレッシュトークンのファミリーIDに対応するセッションを失効させます
+// セッションの記録がないファミリー（セッション管理の導入前に発行されたもの）は
+// リフレッシュトークンのみを失効させます
+func (s *SessionStore) RevokeFamily(familyID string) error {
+	var session models.Session
+	err := s.db.Where("family_id = ?", familyID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.db.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", time.Now()).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.Revoke(&session)
+}
+
+// RevokeOthers は指定したセッション以外のユーザーのセッションを全て失効させます
+// パスワード変更時など、操作中の端末のログインを維持したまま他の端末をログアウトさせる場合に使用します
+// keepSID が空の場合（セッション管理の導入前に発行されたトークン）は全てのセッションを失効させます
+func (s *SessionStore) RevokeOthers(userID uint, keepSID string) error {
+	if keepSID == "" {
+		return s.revocations.RevokeUserSessions(userID)
+	}
+
+	var sessions []models.Session
+	if err := s.db.Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepSID).
+		Find(&sessions).Error; err != nil {
+		return err
+	}
+	for i := range sessions {
+		if err := s.Revoke(&sessions[i]); err != nil {
+			return err
+		}
+	}
+
+	// セッションの記録がないトークンファミリー（セッション管理の導入前に発行されたもの）
+	return s.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepSID).
+		Update("revoked_at", time.Now()).Error
+}
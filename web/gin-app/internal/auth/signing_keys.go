@@ -0,0 +1,385 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// 署名鍵の管理に関するパラメータ
+const (
+	keySyncInterval    = 1 * time.Minute  // データベースとの同期・ローテーション判定の間隔
+	unknownKIDCooldown = 10 * time.Second // 未知の kid による再読み込みの最小間隔
+	rsaKeyBits         = 2048             // RS256 の鍵長
+)
+
+// errUnknownSigningKey はトークンの kid に対応する鍵がない場合のエラーです
+var errUnknownSigningKey = errors.New("署名鍵が見つかりません")
+
+// KeyManager はJWTの署名鍵を管理する構造体です（utils.KeyProvider を実装）
+// HS256 では JWT_SECRET をそのまま使用します
+// RS256/EdDSA では鍵ペアを生成してデータベースに保存し、定期的にローテーションします
+// ローテーション後も古い鍵は発行済みトークンの有効期限まで検証に使用し、
+// 公開鍵は JWKS（/.well-known/jwks.json）として公開します
+type KeyManager struct {
+	db        *gorm.DB
+	cfg       config.JWTConfig
+	retention time.Duration // ローテーション後に古い鍵を検証に使用する期間
+	aead      cipher.AEAD   // 秘密鍵の暗号化に使用
+
+	mu         sync.RWMutex
+	current    *signingKey            // 署名に使用する鍵
+	keys       map[string]*signingKey // kid -> 検証に使用できる鍵
+	lastReload time.Time              // 最後にデータベースから読み込んだ時刻
+}
+
+// signingKey は復号済みの署名鍵です
+type signingKey struct {
+	kid       string
+	algorithm string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// JWK は公開鍵の JSON Web Key 表現です（RFC 7517 / RFC 8037）
+type JWK struct {
+	Kty string `json:"kty"`           // 鍵の種類（RSA, OKP）
+	Kid string `json:"kid"`           // 鍵ID
+	Use string `json:"use"`           // 用途（sig: 署名）
+	Alg string `json:"alg"`           // アルゴリズム
+	N   string `json:"n,omitempty"`   // RSA: モジュラス
+	E   string `json:"e,omitempty"`   // RSA: 公開指数
+	Crv string `json:"crv,omitempty"` // OKP: 曲線名
+	X   string `json:"x,omitempty"`   // OKP: 公開鍵
+}
+
+// NewKeyManager は新しいKeyManagerを作成します
+// RS256/EdDSA の場合は、有効な署名鍵がなければ生成し、定期的なローテーションを開始します
+// maxTokenTTL には署名するトークンの最長の有効期限（config.Config.MaxTokenTTL）を指定します
+func NewKeyManager(db *gorm.DB, cfg config.JWTConfig, maxTokenTTL time.Duration) (*KeyManager, error) {
+	m := &KeyManager{
+		db:        db,
+		cfg:       cfg,
+		retention: maxTokenTTL,
+		keys:      make(map[string]*signingKey),
+	}
+
+	if cfg.Algorithm == "HS256" {
+		return m, nil
+	}
+
+	// 秘密鍵の暗号化に使う鍵を JWT_SECRET から導出
+	sum := sha256.Sum256([]byte("jwt-signing-key:" + cfg.SecretKey))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	if m.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+
+	if err := m.reload(); err != nil {
+		return nil, fmt.Errorf("署名鍵の読み込みエラー: %w", err)
+	}
+	if m.needsRotation() {
+		if err := m.Rotate(); err != nil {
+			return nil, fmt.Errorf("署名鍵の生成エラー: %w", err)
+		}
+	}
+
+	// 定期的にデータベースと同期し、必要に応じてローテーション
+	go m.syncLoop()
+
+	return m, nil
+}
+
+// SigningKey は新しいトークンの署名に使う鍵を返します
+func (m *KeyManager) SigningKey() (string, jwt.SigningMethod, interface{}, error) {
+	if m.cfg.Algorithm == "HS256" {
+		return "", jwt.SigningMethodHS256, []byte(m.cfg.SecretKey), nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.current == nil {
+		return "", nil, nil, errUnknownSigningKey
+	}
+	return m.current.kid, m.current.method, m.current.private, nil
+}
+
+// VerificationKey はトークンの kid と alg に対応する検証用の鍵を返します
+// 鍵のアルゴリズムと alg が一致しない場合は拒否します（アルゴリズム混同攻撃の防止）
+func (m *KeyManager) VerificationKey(kid, alg string) (interface{}, error) {
+	if m.cfg.Algorithm == "HS256" {
+		if alg != "HS256" {
+			return nil, errors.New("無効な署名アルゴリズムです")
+		}
+		return []byte(m.cfg.SecretKey), nil
+	}
+
+	key, ok := m.lookup(kid)
+	if !ok {
+		return nil, errUnknownSigningKey
+	}
+	if key.algorithm != alg {
+		return nil, errors.New("無効な署名アルゴリズムです")
+	}
+	return key.private.Public(), nil
+}
+
+// JWKS は検証に使用できる全ての公開鍵を返します
+// HS256 の場合、共有の秘密鍵は公開できないため空になります
+func (m *KeyManager) JWKS() []JWK {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	jwks := make([]JWK, 0, len(m.keys))
+	for _, key := range m.keys {
+		jwks = append(jwks, key.jwk())
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+// Rotate は新しい署名鍵を生成し、それまでの鍵を署名に使わないようにします
+// 古い鍵は、発行済みトークンの最大有効期間が経過するまで検証に使用されます
+func (m *KeyManager) Rotate() error {
+	record, err := m.generate()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(m.retention)
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.SigningKey{}).Where("retired_at IS NULL").
+			Updates(map[string]interface{}{"retired_at": now, "expires_at": expiresAt}).Error; err != nil {
+			return err
+		}
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("JWT署名鍵をローテーションしました: kid=%s algorithm=%s", record.KID, record.Algorithm)
+	return m.reload()
+}
+
+// lookup は kid に対応する鍵を返します
+// 見つからない場合は他のインスタンスでローテーションされた可能性があるため、
+// 一定間隔を空けてデータベースから再読み込みします
+func (m *KeyManager) lookup(kid string) (*signingKey, bool) {
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	canReload := time.Since(m.lastReload) >= unknownKIDCooldown
+	m.mu.RUnlock()
+
+	if ok || kid == "" || !canReload {
+		return key, ok
+	}
+
+	if err := m.reload(); err != nil {
+		log.Printf("署名鍵の再読み込みに失敗しました: %v", err)
+		return nil, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok = m.keys[kid]
+	return key, ok
+}
+
+// needsRotation は署名鍵の生成またはローテーションが必要かどうかを返します
+func (m *KeyManager) needsRotation() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.current == nil {
+		return true
+	}
+	return m.cfg.RotationInterval > 0 && time.Since(m.current.createdAt) >= m.cfg.RotationInterval
+}
+
+// generate は設定されたアルゴリズムの鍵ペアを生成し、保存用のレコードを返します
+func (m *KeyManager) generate() (*models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+
+	switch m.cfg.Algorithm {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("未対応の署名アルゴリズムです: %s", m.cfg.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := m.encrypt(der)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := utils.GenerateSecureToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SigningKey{
+		KID:        kid,
+		Algorithm:  m.cfg.Algorithm,
+		PrivateKey: encrypted,
+	}, nil
+}
+
+// reload はデータベースから有効期限内の署名鍵を読み込みます
+// 署名には、設定と同じアルゴリズムで最も新しい未退役の鍵を使用します
+func (m *KeyManager) reload() error {
+	var records []models.SigningKey
+	if err := m.db.Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("created_at").Find(&records).Error; err != nil {
+		return err
+	}
+
+	keys := make(map[string]*signingKey, len(records))
+	var current *signingKey
+	for _, r := range records {
+		key, err := m.parse(r)
+		if err != nil {
+			// JWT_SECRET が変更された場合などは復号できないため、その鍵は使用しない
+			log.Printf("署名鍵を読み込めませんでした: kid=%s: %v", r.KID, err)
+			continue
+		}
+		keys[r.KID] = key
+		if r.RetiredAt == nil && r.Algorithm == m.cfg.Algorithm {
+			current = key
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.current = current
+	m.lastReload = time.Now()
+	m.mu.Unlock()
+
+	return nil
+}
+
+// parse はデータベースのレコードから署名鍵を復元します
+func (m *KeyManager) parse(r models.SigningKey) (*signingKey, error) {
+	der, err := m.decrypt(r.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{kid: r.KID, algorithm: r.Algorithm, createdAt: r.CreatedAt}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private = jwt.SigningMethodRS256, private
+	case ed25519.PrivateKey:
+		key.method, key.private = jwt.SigningMethodEdDSA, private
+	default:
+		return nil, errors.New("未対応の鍵の種類です")
+	}
+	if key.method.Alg() != r.Algorithm {
+		return nil, errors.New("鍵の種類とアルゴリズムが一致しません")
+	}
+
+	return key, nil
+}
+
+// encrypt は秘密鍵を AES-GCM で暗号化し、Base64文字列で返します
+func (m *KeyManager) encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := m.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt は encrypt で暗号化した秘密鍵を復号します
+func (m *KeyManager) decrypt(encoded string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < m.aead.NonceSize() {
+		return nil, errors.New("暗号化された鍵が不正です")
+	}
+	nonce, ciphertext := sealed[:m.aead.NonceSize()], sealed[m.aead.NonceSize():]
+	return m.aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// syncLoop は定期的にデータベースと同期し、ローテーションの時期になった鍵を入れ替えます
+// 有効期限を過ぎた鍵で署名されたトークンは存在しないため、レコードも削除します
+func (m *KeyManager) syncLoop() {
+	ticker := time.NewTicker(keySyncInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := m.db.Where("expires_at <= ?", time.Now()).Delete(&models.SigningKey{}).Error; err != nil {
+			log.Printf("期限切れの署名鍵の削除に失敗しました: %v", err)
+		}
+		if err := m.reload(); err != nil {
+			log.Printf("署名鍵の同期に失敗しました: %v", err)
+			continue
+		}
+		if m.needsRotation() {
+			if err := m.Rotate(); err != nil {
+				log.Printf("署名鍵のローテーションに失敗しました: %v", err)
+			}
+		}
+	}
+}
+
+// jwk は署名鍵の公開鍵を JWK 形式に変換します
+func (k *signingKey) jwk() JWK {
+	jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.algorithm}
+
+	switch public := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
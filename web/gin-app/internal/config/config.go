@@ -14,6 +14,9 @@ type Config struct {
 	Server   ServerConfig   // サーバー関連の設定
 	Database DatabaseConfig // データベース関連の設定
 	JWT      JWTConfig      // JWT認証の設定
+	Auth     AuthConfig     // 認証フローの設定
+	Mail     MailConfig     // メール送信の設定
+	Storage  StorageConfig  // アップロードしたファイルの保存先の設定
 	App      AppConfig      // アプリケーション全般の設定
 }
 
@@ -41,10 +44,61 @@ type DatabaseConfig struct {
 
 // JWTConfig はJWT認証の設定を保持します
 type JWTConfig struct {
-	SecretKey       string        // JWT署名用の秘密鍵
-	ExpirationHours int           // トークンの有効期限（時間）
-	Issuer          string        // トークンの発行者
-	Expiration      time.Duration // トークンの有効期限（Duration）
+	SecretKey         string        // JWT署名用の秘密鍵
+	ExpirationHours   int           // トークンの有効期限（時間）
+	Issuer            string        // トークンの発行者
+	Expiration        time.Duration // トークンの有効期限（Duration）
+	RefreshExpiration time.Duration // リフレッシュトークンの有効期限
+	UserCacheTTL      time.Duration // 認証時のユーザー有効状態キャッシュの有効期間
+	Algorithm         string        // 署名アルゴリズム（HS256, RS256, EdDSA）
+	RotationInterval  time.Duration // 署名鍵のローテーション間隔（RS256/EdDSAのみ、0で無効）
+}
+
+// AuthConfig はパスワードリセット等の認証フローの設定を保持します
+type AuthConfig struct {
+	PasswordResetTTL         time.Duration // パスワードリセットトークンの有効期限
+	EmailVerificationTTL     time.Duration // メールアドレス確認トークンの有効期限
+	RequireEmailVerification bool          // trueの場合、メールアドレス未確認のユーザーはログイン不可
+	MFATokenTTL              time.Duration // 二要素認証待ちトークンの有効期限
+	RequireAdmin2FA          bool          // trueの場合、管理者APIの利用に二要素認証でのログインが必要
+	LoginMaxAttempts         int           // アカウントごとのロックまでのログイン失敗回数
+	LoginIPMaxAttempts       int           // IPアドレスごとのロックまでのログイン失敗回数
+	LoginLockoutBase         time.Duration // 最初のロック期間（以降は失敗ごとに2倍）
+	LoginLockoutMax          time.Duration // ロック期間の上限
+	PasswordMinLength        int           // パスワードの最小文字数
+	PasswordRequireUpper     bool          // パスワードに英大文字を必須にするか
+	PasswordRequireLower     bool          // パスワードに英小文字を必須にするか
+	PasswordRequireDigit     bool          // パスワードに数字を必須にするか
+	PasswordRequireSymbol    bool          // パスワードに記号を必須にするか
+	PasswordBlocklistFile    string        // 使用を禁止するパスワードの一覧ファイル（空の場合は無効）
+	PasswordHistory          int           // 再利用を禁止する直近のパスワードの数（0で無効）
+	ImpersonationTTL         time.Duration // なりすまし用トークンの有効期限
+}
+
+// MailConfig はメール送信の設定を保持します
+type MailConfig struct {
+	Driver       string // 送信方式 (log: ファイル/標準出力, smtp: SMTPサーバー)
+	From         string // 送信元アドレス
+	FilePath     string // log ドライバーの出力先ファイル（空の場合は標準出力）
+	SMTPHost     string // SMTPサーバーのホスト名
+	SMTPPort     string // SMTPサーバーのポート番号
+	SMTPUsername string // SMTP認証のユーザー名（空の場合は認証なし）
+	SMTPPassword string // SMTP認証のパスワード
+}
+
+// StorageConfig はアップロードしたファイル（商品画像）の保存先の設定を保持します
+type StorageConfig struct {
+	Driver        string        // 保存先 (local: ローカルのディレクトリ, s3: S3互換のオブジェクトストレージ)
+	LocalPath     string        // local ドライバーの保存先ディレクトリ
+	S3Endpoint    string        // S3互換のエンドポイント（例: MinIO の http://localhost:9000）
+	S3Region      string        // リージョン（署名に使用）
+	S3Bucket      string        // バケット名
+	S3AccessKey   string        // アクセスキー
+	S3SecretKey   string        // シークレットキー
+	S3PathStyle   bool          // trueの場合はパス形式（endpoint/bucket/key）のURLを使用（MinIO）
+	MaxUploadMB   int           // アップロードできる画像1枚の最大サイズ（MB）
+	ThumbnailSize int           // サムネイルの長辺のピクセル数
+	CacheMaxAge   time.Duration // 配信時の Cache-Control の max-age
 }
 
 // AppConfig はアプリケーション全般の設定を保持します
@@ -53,6 +107,7 @@ type AppConfig struct {
 	Version     string // アプリケーションのバージョン
 	Environment string // 実行環境 (development, staging, production)
 	LogLevel    string // ログレベル (debug, info, warn, error)
+	BaseURL     string // メール内のリンクに使用するフロントエンドのURL
 }
 
 // Load は環境変数から設定を読み込み、Config構造体を返します
@@ -78,16 +133,62 @@ func Load() (*Config, error) {
 			ConnMaxLifetime: getDurationEnv("DB_CONN_MAX_LIFETIME", 5*time.Minute),
 		},
 		JWT: JWTConfig{
-			SecretKey:       getEnv("JWT_SECRET", "your-secret-key-change-this-in-production"),
-			ExpirationHours: getIntEnv("JWT_EXPIRATION_HOURS", 24),
-			Issuer:          getEnv("JWT_ISSUER", "gin-app"),
-			Expiration:      time.Duration(getIntEnv("JWT_EXPIRATION_HOURS", 24)) * time.Hour,
+			SecretKey:         getEnv("JWT_SECRET", "your-secret-key-change-this-in-production"),
+			ExpirationHours:   getIntEnv("JWT_EXPIRATION_HOURS", 24),
+			Issuer:            getEnv("JWT_ISSUER", "gin-app"),
+			Expiration:        time.Duration(getIntEnv("JWT_EXPIRATION_HOURS", 24)) * time.Hour,
+			RefreshExpiration: time.Duration(getIntEnv("JWT_REFRESH_EXPIRATION_HOURS", 720)) * time.Hour,
+			UserCacheTTL:      getDurationEnv("JWT_USER_CACHE_TTL", 30*time.Second),
+			Algorithm:         getEnv("JWT_ALGORITHM", "HS256"),
+			RotationInterval:  getDurationEnv("JWT_KEY_ROTATION_INTERVAL", 720*time.Hour),
+		},
+		Auth: AuthConfig{
+			PasswordResetTTL:         getDurationEnv("AUTH_PASSWORD_RESET_TTL", 1*time.Hour),
+			EmailVerificationTTL:     getDurationEnv("AUTH_EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			RequireEmailVerification: getBoolEnv("AUTH_REQUIRE_EMAIL_VERIFICATION", false),
+			MFATokenTTL:              getDurationEnv("AUTH_MFA_TOKEN_TTL", 5*time.Minute),
+			RequireAdmin2FA:          getBoolEnv("AUTH_REQUIRE_ADMIN_2FA", false),
+			LoginMaxAttempts:         getIntEnv("AUTH_LOGIN_MAX_ATTEMPTS", 5),
+			LoginIPMaxAttempts:       getIntEnv("AUTH_LOGIN_IP_MAX_ATTEMPTS", 20),
+			LoginLockoutBase:         getDurationEnv("AUTH_LOGIN_LOCKOUT_BASE", 1*time.Minute),
+			LoginLockoutMax:          getDurationEnv("AUTH_LOGIN_LOCKOUT_MAX", 1*time.Hour),
+			PasswordMinLength:        getIntEnv("AUTH_PASSWORD_MIN_LENGTH", 8),
+			PasswordRequireUpper:     getBoolEnv("AUTH_PASSWORD_REQUIRE_UPPER", true),
+			PasswordRequireLower:     getBoolEnv("AUTH_PASSWORD_REQUIRE_LOWER", true),
+			PasswordRequireDigit:     getBoolEnv("AUTH_PASSWORD_REQUIRE_DIGIT", true),
+			PasswordRequireSymbol:    getBoolEnv("AUTH_PASSWORD_REQUIRE_SYMBOL", false),
+			PasswordBlocklistFile:    getEnv("AUTH_PASSWORD_BLOCKLIST_FILE", ""),
+			PasswordHistory:          getIntEnv("AUTH_PASSWORD_HISTORY", 5),
+			ImpersonationTTL:         getDurationEnv("AUTH_IMPERSONATION_TTL", 15*time.Minute),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "no-reply@example.com"),
+			FilePath:     getEnv("MAIL_FILE_PATH", ""),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnv("SMTP_PORT", "1025"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		},
+		Storage: StorageConfig{
+			Driver:        getEnv("STORAGE_DRIVER", "local"),
+			LocalPath:     getEnv("STORAGE_LOCAL_PATH", "./data/uploads"),
+			S3Endpoint:    getEnv("S3_ENDPOINT", "http://localhost:9000"),
+			S3Region:      getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:      getEnv("S3_BUCKET", "gin-app"),
+			S3AccessKey:   getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:   getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle:   getBoolEnv("S3_PATH_STYLE", true),
+			MaxUploadMB:   getIntEnv("STORAGE_MAX_UPLOAD_MB", 10),
+			ThumbnailSize: getIntEnv("STORAGE_THUMBNAIL_SIZE", 320),
+			CacheMaxAge:   getDurationEnv("STORAGE_CACHE_MAX_AGE", 8760*time.Hour),
 		},
 		App: AppConfig{
 			Name:        getEnv("APP_NAME", "Gin Web Application"),
 			Version:     getEnv("APP_VERSION", "1.0.0"),
 			Environment: getEnv("APP_ENV", "development"),
 			LogLevel:    getEnv("LOG_LEVEL", "debug"),
+			BaseURL:     getEnv("APP_BASE_URL", "http://localhost:3000"),
 		},
 	}
 
@@ -107,6 +208,26 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("production環境ではJWT_SECRETを必ず変更してください")
 	}
 
+	// JWT署名アルゴリズムのチェック
+	switch c.JWT.Algorithm {
+	case "HS256", "RS256", "EdDSA":
+	default:
+		return fmt.Errorf("JWT_ALGORITHMはHS256、RS256またはEdDSAを指定してください: %s", c.JWT.Algorithm)
+	}
+
+	// パスワードポリシーのチェック（bcrypt は72バイトまでしか扱えない）
+	if c.Auth.PasswordMinLength < 1 || c.Auth.PasswordMinLength > 72 {
+		return fmt.Errorf("AUTH_PASSWORD_MIN_LENGTHは1〜72の範囲で指定してください: %d", c.Auth.PasswordMinLength)
+	}
+	if c.Auth.PasswordHistory < 0 {
+		return fmt.Errorf("AUTH_PASSWORD_HISTORYは0以上を指定してください: %d", c.Auth.PasswordHistory)
+	}
+
+	// なりすまし用トークンは0を指定すると通常の有効期限になってしまうため、正の値を必須とする
+	if c.Auth.ImpersonationTTL <= 0 {
+		return fmt.Errorf("AUTH_IMPERSONATION_TTLは正の値を指定してください: %s", c.Auth.ImpersonationTTL)
+	}
+
 	// データベース接続情報の基本チェック
 	if c.Database.Host == "" {
 		return fmt.Errorf("DB_HOSTが設定されていません")
@@ -115,6 +236,31 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("DB_NAMEが設定されていません")
 	}
 
+	// メール送信方式のチェック
+	if c.Mail.Driver != "log" && c.Mail.Driver != "smtp" {
+		return fmt.Errorf("MAIL_DRIVERはlogまたはsmtpを指定してください: %s", c.Mail.Driver)
+	}
+
+	// ファイルの保存先のチェック
+	switch c.Storage.Driver {
+	case "local":
+		if c.Storage.LocalPath == "" {
+			return fmt.Errorf("STORAGE_LOCAL_PATHが設定されていません")
+		}
+	case "s3":
+		if c.Storage.S3Endpoint == "" || c.Storage.S3Bucket == "" {
+			return fmt.Errorf("STORAGE_DRIVER=s3の場合はS3_ENDPOINTとS3_BUCKETを設定してください")
+		}
+	default:
+		return fmt.Errorf("STORAGE_DRIVERはlocalまたはs3を指定してください: %s", c.Storage.Driver)
+	}
+	if c.Storage.MaxUploadMB < 1 {
+		return fmt.Errorf("STORAGE_MAX_UPLOAD_MBは1以上を指定してください: %d", c.Storage.MaxUploadMB)
+	}
+	if c.Storage.ThumbnailSize < 16 {
+		return fmt.Errorf("STORAGE_THUMBNAIL_SIZEは16以上を指定してください: %d", c.Storage.ThumbnailSize)
+	}
+
 	return nil
 }
 
@@ -136,6 +282,16 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getBoolEnv は環境変数を真偽値として取得し、存在しないまたは変換できない場合はデフォルト値を返します
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
 // getDurationEnv は環境変数をDurationとして取得し、存在しないまたは変換できない場合はデフォルト値を返します
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
@@ -146,6 +302,19 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// MaxTokenTTL は署名するトークン（アクセストークン、二要素認証待ちトークン、なりすまし用トークン）の
+// 有効期限のうち最も長いものを返します
+// ローテーションした署名鍵は、この期間が経過するまで検証に使用します
+func (c *Config) MaxTokenTTL() time.Duration {
+	ttl := c.JWT.Expiration
+	for _, t := range []time.Duration{c.Auth.MFATokenTTL, c.Auth.ImpersonationTTL} {
+		if t > ttl {
+			ttl = t
+		}
+	}
+	return ttl
+}
+
 // GetDatabaseDSN はPostgreSQL接続文字列を生成します
 func (c *DatabaseConfig) GetDatabaseDSN() string {
 	return fmt.Sprintf(
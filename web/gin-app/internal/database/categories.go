@@ -0,0 +1,57 @@
+// Package database はデータベース接続とマイグレーション機能を提供します
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateProductCategories は商品のカテゴリー名（文字列）からカテゴリーを作成し、商品に関連付けます
+// カテゴリーのモデルを追加する前に登録された商品を移行するためのものです
+// 関連付けていない商品がない場合は何もしないため、起動のたびに実行しても問題ありません
+func migrateProductCategories(db *gorm.DB) error {
+	// 削除済みの商品も復元される可能性があるため含める
+	var names []string
+	if err := db.Unscoped().Model(&models.Product{}).
+		Where("category_id IS NULL AND category <> ''").
+		Distinct("category").
+		Pluck("category", &names).Error; err != nil {
+		return fmt.Errorf("カテゴリーの移行エラー: %w", err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			// 同じ名前の最上位のカテゴリーがあれば使用し、なければ作成する（スラッグは名前から作成）
+			var category models.Category
+			err := tx.Where("name = ? AND parent_id IS NULL", name).Order("id").First(&category).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				category = models.Category{Name: name}
+				err = tx.Create(&category).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Unscoped().Model(&models.Product{}).
+				Where("category_id IS NULL AND category = ?", name).
+				UpdateColumn("category_id", category.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("カテゴリーの移行エラー: %w", err)
+	}
+
+	log.Printf("商品のカテゴリー %d 件をカテゴリーに移行しました", len(names))
+	return nil
+}
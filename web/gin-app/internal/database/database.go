@@ -3,6 +3,7 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
@@ -74,19 +75,102 @@ func AutoMigrate(db *gorm.DB) error {
 	// GORMが自動的にテーブルを作成・更新します
 	err := db.AutoMigrate(
 		&models.User{},
+		&models.Category{},
 		&models.Product{},
+		&models.OptionType{},
+		&models.OptionValue{},
+		&models.ProductVariant{},
+		&models.ProductImage{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.UserToken{},
+		&models.RecoveryCode{},
+		&models.Permission{},
+		&models.Role{},
+		&models.APIKey{},
+		&models.SigningKey{},
+		&models.Session{},
+		&models.PasswordHistory{},
+		&models.AuditLog{},
+		&models.StockMovement{},
 	)
 
 	if err != nil {
 		return fmt.Errorf("マイグレーションエラー: %w", err)
 	}
 
+	// 商品の全文検索用のカラムとインデックス
+	if err := migrateProductSearch(db); err != nil {
+		return err
+	}
+
+	// 商品のカテゴリー名（文字列）からカテゴリーへの移行
+	if err := migrateProductCategories(db); err != nil {
+		return err
+	}
+
+	// 在庫の移動を記録する前に登録された商品の在庫を、初期在庫として記録
+	if err := migrateStockLedger(db); err != nil {
+		return err
+	}
+
 	log.Println("マイグレーションが完了しました")
 	return nil
 }
 
+// SeedRoles は組み込みのロールと権限を作成します
+// 既に存在するロールの権限は変更しないため、管理者が編集した内容は保持されます
+// ただし admin ロールには、追加された権限も含めて常に全ての権限を割り当てます
+func SeedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 1. 権限の作成（既に存在する場合は何もしない）
+		for _, p := range models.DefaultPermissions {
+			perm := p
+			if err := tx.Where(models.Permission{Name: perm.Name}).FirstOrCreate(&perm).Error; err != nil {
+				return fmt.Errorf("権限の作成エラー: %w", err)
+			}
+		}
+
+		var permissions []models.Permission
+		if err := tx.Find(&permissions).Error; err != nil {
+			return err
+		}
+		byName := make(map[string]models.Permission, len(permissions))
+		for _, p := range permissions {
+			byName[p.Name] = p
+		}
+
+		// 2. ロールの作成（既に存在する場合は何もしない）
+		for _, d := range models.DefaultRoles {
+			var existing models.Role
+			err := tx.Where("name = ?", d.Role.Name).First(&existing).Error
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			role := d.Role
+			for _, name := range d.Permissions {
+				role.Permissions = append(role.Permissions, byName[name])
+			}
+			if err := tx.Create(&role).Error; err != nil {
+				return fmt.Errorf("ロールの作成エラー: %w", err)
+			}
+		}
+
+		// 3. admin ロールに全ての権限を割り当てる
+		var admin models.Role
+		if err := tx.Where("name = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+			return err
+		}
+		return tx.Model(&admin).Association("Permissions").Replace(permissions)
+	})
+}
+
 // Close はデータベース接続をクローズします
 // アプリケーション終了時に呼び出してリソースを解放します
 // 普通の関数に変更 (db を引数として受け取る)
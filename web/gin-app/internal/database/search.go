@@ -0,0 +1,42 @@
+// Package database はデータベース接続とマイグレーション機能を提供します
+package database
+
+import (
+	"fmt"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// productSearchVector は商品の全文検索用の tsvector を生成する式です
+// 商品名とSKUを最も重く（A）、カテゴリー（B）、説明（C）の順に重み付けします
+var productSearchVector = fmt.Sprintf(
+	"setweight(to_tsvector('%[1]s', coalesce(name, '')), 'A') || "+
+		"setweight(to_tsvector('%[1]s', coalesce(sku, '')), 'A') || "+
+		"setweight(to_tsvector('%[1]s', coalesce(category, '')), 'B') || "+
+		"setweight(to_tsvector('%[1]s', coalesce(description, '')), 'C')",
+	models.ProductSearchConfig,
+)
+
+// migrateProductSearch は商品の全文検索に必要なカラムとインデックスを作成します
+// GORM のモデルでは生成列と GIN インデックスを表現できないため、SQL で作成します
+// 何度実行しても同じ結果になります
+func migrateProductSearch(db *gorm.DB) error {
+	statements := []string{
+		// 検索語の候補（もしかして）の類似度計算に使用
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		// 商品の更新時にデータベースが自動で再計算する検索用のカラム
+		"ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector " +
+			"GENERATED ALWAYS AS (" + productSearchVector + ") STORED",
+		"CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)",
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("商品検索のマイグレーションエラー: %w", err)
+		}
+	}
+	return nil
+}
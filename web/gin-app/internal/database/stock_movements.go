@@ -0,0 +1,56 @@
+// Package database はデータベース接続とマイグレーション機能を提供します
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateStockLedger は在庫の移動がない商品・バリエーションについて、現在の在庫を初期在庫として記録します
+// 在庫の移動のモデルを追加する前に登録された商品の在庫を、在庫の移動の合計と一致させるためのものです
+// 在庫の移動が記録済みの商品は対象外のため、起動のたびに実行しても問題ありません
+func migrateStockLedger(db *gorm.DB) error {
+	var created int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 1. バリエーションがない商品の在庫
+		result := tx.Exec(`
+			INSERT INTO stock_movements (created_at, product_id, delta, balance, reason, actor_name)
+			SELECT NOW(), p.id, p.stock, p.stock, ?, 'system'
+			FROM products p
+			WHERE p.deleted_at IS NULL AND p.stock <> 0
+			  AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND v.deleted_at IS NULL)
+			  AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = p.id)`,
+			models.StockReasonInitial)
+		if result.Error != nil {
+			return result.Error
+		}
+		created += result.RowsAffected
+
+		// 2. バリエーションの在庫（商品の在庫はバリエーションの在庫の合計のため、記録しない）
+		result = tx.Exec(`
+			INSERT INTO stock_movements (created_at, product_id, variant_id, delta, balance, reason, actor_name)
+			SELECT NOW(), v.product_id, v.id, v.stock, v.stock, ?, 'system'
+			FROM product_variants v
+			JOIN products p ON p.id = v.product_id AND p.deleted_at IS NULL
+			WHERE v.deleted_at IS NULL AND v.stock <> 0
+			  AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = v.product_id)`,
+			models.StockReasonInitial)
+		if result.Error != nil {
+			return result.Error
+		}
+		created += result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("在庫の移動の移行エラー: %w", err)
+	}
+
+	if created > 0 {
+		log.Printf("商品・バリエーションの在庫 %d 件を初期在庫として記録しました", created)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIKeyHandler はAPIキーの管理に関するハンドラーをまとめる構造体です
+type APIKeyHandler struct {
+	db          *gorm.DB
+	permissions *auth.PermissionStore
+}
+
+// NewAPIKeyHandler は新しいAPIKeyHandlerを作成します
+func NewAPIKeyHandler(db *gorm.DB, permissions *auth.PermissionStore) *APIKeyHandler {
+	return &APIKeyHandler{
+		db:          db,
+		permissions: permissions,
+	}
+}
+
+// ListAPIKeys はAPIキーの一覧を取得します
+// キー本体は返さず、表示用のプレフィックスのみを返します
+// GET /api/v1/api-keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	var keys []models.APIKey
+	if err := h.db.Order("created_at DESC").Find(&keys).Error; err != nil {
+		apperr.Abort(c, apperr.ErrAPIKeyFetchFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": keys,
+	})
+}
+
+// GetAPIKey は特定のAPIキーの情報を取得します
+// GET /api/v1/api-keys/:id
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+	var key models.APIKey
+	if err := h.db.First(&key, c.Param("id")).Error; err != nil {
+		apperr.Abort(c, apperr.ErrAPIKeyNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// CreateAPIKey は新しいAPIキーを発行します
+// キー本体はこのレスポンスでのみ返され、以降は確認できません
+// 権限の昇格を防ぐため、作成者自身が持つ権限のみスコープに指定できます
+// POST /api/v1/api-keys
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 1. スコープの検証
+	scopes := uniqueStrings(req.Scopes)
+	var count int64
+	if err := h.db.Model(&models.Permission{}).Where("name IN ?", scopes).Count(&count).Error; err != nil {
+		apperr.Abort(c, apperr.ErrPermissionFetchFailed.WithCause(err))
+		return
+	}
+	if int(count) != len(scopes) {
+		apperr.Abort(c, apperr.ErrPermissionUnknown)
+		return
+	}
+
+	role := c.GetString("role")
+	for _, scope := range scopes {
+		if !h.permissions.HasPermission(role, scope) {
+			apperr.Abort(c, apperr.ErrAPIKeyScopeForbidden.With("scope", scope))
+			return
+		}
+	}
+
+	// 2. キーの生成
+	rawKey, prefix, err := auth.GenerateKey()
+	if err != nil {
+		apperr.Abort(c, apperr.ErrAPIKeyGenerateFailed.WithCause(err))
+		return
+	}
+
+	key := models.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    scopes,
+		CreatedBy: c.GetUint("user_id"),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	// 3. データベースに保存（キー本体は保存しない）
+	if err := h.db.Create(&key).Error; err != nil {
+		apperr.Abort(c, apperr.ErrAPIKeyCreateFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "api_key.created"),
+		"key":     rawKey,
+		"api_key": key,
+	})
+}
+
+// RevokeAPIKey はAPIキーを失効させます
+// 失効したキーは次のリクエストから拒否されます
+// DELETE /api/v1/api-keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	var key models.APIKey
+	if err := h.db.First(&key, c.Param("id")).Error; err != nil {
+		apperr.Abort(c, apperr.ErrAPIKeyNotFound)
+		return
+	}
+
+	if key.IsRevoked() {
+		c.JSON(http.StatusOK, gin.H{
+			"message": i18n.T(c, "api_key.already_revoked"),
+		})
+		return
+	}
+
+	if err := h.db.Model(&key).Update("revoked_at", time.Now()).Error; err != nil {
+		apperr.Abort(c, apperr.ErrAPIKeyRevokeFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "api_key.revoked"),
+	})
+}
+
+// uniqueStrings は重複を除いた文字列の一覧を返します（順序は維持）
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/pagination"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 監査ログの一覧のページサイズ（確認のため他の一覧より多く表示します）
+const (
+	auditLogDefaultPageSize = 50
+	auditLogMaxPageSize     = 200
+)
+
+// AuditLogHandler は監査ログの閲覧に関するハンドラーをまとめる構造体です
+type AuditLogHandler struct {
+	db *gorm.DB
+}
+
+// NewAuditLogHandler は新しいAuditLogHandlerを作成します
+func NewAuditLogHandler(db *gorm.DB) *AuditLogHandler {
+	return &AuditLogHandler{db: db}
+}
+
+// ListAuditLogs は監査ログの一覧を新しい順に取得します（audit_logs:read 権限が必要）
+// クエリパラメータ action, actor_id, user_id で絞り込めます
+// GET /api/v1/audit-logs
+func (h *AuditLogHandler) ListAuditLogs(c *gin.Context) {
+	// ページネーション
+	pageReq, err := pagination.Parse(c, auditLogDefaultPageSize, auditLogMaxPageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	// 絞り込み条件
+	query := h.db.Model(&models.AuditLog{})
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	for _, column := range []string{"actor_id", "user_id"} {
+		value := c.Query(column)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrInvalidQueryParameter.WithArgs(column))
+			return
+		}
+		query = query.Where(column+" = ?", id)
+	}
+
+	keys := []pagination.Key{
+		{Name: "created_at", Column: "audit_logs.created_at", Desc: true},
+		{Name: "id", Column: "audit_logs.id", Desc: true},
+	}
+	logs, page, err := pagination.Find(query, pageReq, keys, func(entry *models.AuditLog) []interface{} {
+		return []interface{}{entry.CreatedAt, entry.ID}
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrAuditLogFetchFailed.WithCause(err))
+		return
+	}
+
+	response := page.Fields()
+	response["audit_logs"] = logs
+	c.JSON(http.StatusOK, response)
+}
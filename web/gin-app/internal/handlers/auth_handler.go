@@ -0,0 +1,384 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/mailer"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// refreshTokenBytes はリフレッシュトークンの乱数部分のバイト数です
+const refreshTokenBytes = 32
+
+// errRefreshTokenReused はローテーション中にトークンが既に使用されていた場合のエラーです
+var errRefreshTokenReused = errors.New("リフレッシュトークンは既に使用されています")
+
+// errInvalidUserToken は使い捨てトークンが無効・期限切れ・使用済みの場合のエラーです
+var errInvalidUserToken = errors.New("無効または期限切れのトークンです")
+
+// AuthHandler はトークン管理など認証関連のハンドラーをまとめる構造体です
+type AuthHandler struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	keys        *auth.KeyManager
+	revocations *auth.RevocationStore
+	mailer      mailer.Mailer
+	guard       *auth.LoginGuard
+	sessions    *auth.SessionStore
+	policy      *auth.PasswordPolicy
+}
+
+// NewAuthHandler は新しいAuthHandlerを作成します
+func NewAuthHandler(db *gorm.DB, cfg *config.Config, keys *auth.KeyManager, revocations *auth.RevocationStore, mail mailer.Mailer, guard *auth.LoginGuard, sessions *auth.SessionStore, policy *auth.PasswordPolicy) *AuthHandler {
+	return &AuthHandler{
+		db:          db,
+		cfg:         cfg,
+		keys:        keys,
+		revocations: revocations,
+		mailer:      mail,
+		guard:       guard,
+		sessions:    sessions,
+		policy:      policy,
+	}
+}
+
+// userAgentMaxLength はセッションに記録するUser-Agentの最大長です
+const userAgentMaxLength = 255
+
+// tokenPair はログインやトークン更新で返すトークンの組です
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64 // アクセストークンの有効期間（秒）
+}
+
+// issueTokenPair はアクセストークンとリフレッシュトークンを発行します
+// familyID が空の場合は新しいトークンファミリーとログインセッションを開始します（ログイン時）
+// mfa は二要素認証を経たログインかどうかで、トークン更新後も引き継がれます
+// セッションにはリクエストのUser-AgentとIPアドレスを記録します
+func issueTokenPair(c *gin.Context, db *gorm.DB, cfg *config.Config, keys *auth.KeyManager, user *models.User, familyID string, mfa bool) (*tokenPair, error) {
+	var err error
+	if familyID == "" {
+		familyID, err = utils.GenerateSecureToken(16)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// セッションに記録するため、トークンID（jti）はここで生成する
+	jti, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateJWT(user.ID, user.Username, user.Role, cfg.JWT, keys, utils.TokenOptions{
+		MFA:       mfa,
+		SessionID: familyID,
+		TokenID:   jti,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateSecureToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(cfg.JWT.RefreshExpiration),
+		MFA:       mfa,
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	// ログインセッションの記録（トークン更新時は最新のjtiと有効期限に更新）
+	result := db.Model(&models.Session{}).Where("family_id = ?", familyID).Updates(map[string]interface{}{
+		"current_jti":  jti,
+		"last_seen_at": now,
+		"expires_at":   record.ExpiresAt,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 新しいログイン、またはセッション管理の導入前に開始されたファミリー
+		userAgent := c.Request.UserAgent()
+		if len(userAgent) > userAgentMaxLength {
+			userAgent = userAgent[:userAgentMaxLength]
+		}
+		session := models.Session{
+			UserID:     user.ID,
+			FamilyID:   familyID,
+			CurrentJTI: jti,
+			UserAgent:  userAgent,
+			IPAddress:  c.ClientIP(),
+			MFA:        mfa,
+			LastSeenAt: now,
+			ExpiresAt:  record.ExpiresAt,
+		}
+		if err := db.Create(&session).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &tokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(cfg.JWT.Expiration.Seconds()),
+	}, nil
+}
+
+// createUserToken は使い捨てトークンを発行し、平文のトークンを返します
+// 同じ用途の未使用トークンは無効化されるため、最後に発行したものだけが有効です
+func createUserToken(db *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeUserToken は使い捨てトークンを検証して使用済みにします
+// 同時に同じトークンが使われても一度しか成功しないよう、条件付きで更新します
+func consumeUserToken(db *gorm.DB, token, purpose string) (*models.UserToken, error) {
+	var record models.UserToken
+	if err := db.Where("token_hash = ? AND purpose = ?", utils.HashToken(token), purpose).
+		First(&record).Error; err != nil {
+		return nil, errInvalidUserToken
+	}
+
+	if !record.IsUsable() {
+		return nil, errInvalidUserToken
+	}
+
+	result := db.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errInvalidUserToken
+	}
+
+	return &record, nil
+}
+
+// sendMailAsync はメールをバックグラウンドで送信します
+// 送信にかかる時間でアカウントの有無が推測されないよう、レスポンスを待たせません
+func sendMailAsync(m mailer.Mailer, msg mailer.Message) {
+	go func() {
+		if err := m.Send(msg); err != nil {
+			log.Printf("メールの送信に失敗しました: to=%s: %v", msg.To, err)
+		}
+	}()
+}
+
+// Refresh はリフレッシュトークンを使って新しいトークンの組を発行します
+// 使用したリフレッシュトークンは無効化され（ローテーション）、
+// 使用済みのトークンが再度提示された場合はファミリー全体を失効させます
+// POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 1. ハッシュ値でリフレッシュトークンを検索
+	var stored models.RefreshToken
+	if err := h.db.Where("token_hash = ?", utils.HashToken(req.RefreshToken)).First(&stored).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperr.Abort(c, apperr.ErrRefreshTokenInvalid)
+			return
+		}
+		apperr.Abort(c, apperr.ErrTokenRefreshFailed.WithCause(err))
+		return
+	}
+
+	// 2. 再利用の検知（使用済み・失効済みトークンの提示）
+	if stored.IsConsumed() {
+		h.handleReuse(c, &stored)
+		return
+	}
+
+	// 3. 有効期限のチェック
+	if stored.IsExpired() {
+		apperr.Abort(c, apperr.ErrRefreshTokenExpired)
+		return
+	}
+
+	// 4. ユーザーの状態チェック（削除・無効化されたユーザーの場合のみファミリーを失効させる）
+	var user models.User
+	err := h.db.First(&user, stored.UserID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		apperr.Abort(c, apperr.ErrTokenRefreshFailed.WithCause(err))
+		return
+	}
+	if err != nil || !user.IsActive {
+		if err := h.sessions.RevokeFamily(stored.FamilyID); err != nil {
+			apperr.Abort(c, apperr.ErrTokenRefreshFailed.WithCause(err))
+			return
+		}
+		apperr.Abort(c, apperr.ErrAccountUnavailable)
+		return
+	}
+
+	// 5. トランザクション内でローテーション
+	var pair *tokenPair
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// 未使用の場合のみ使用済みにする（同時リクエストによる二重使用を防止）
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", stored.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRefreshTokenReused
+		}
+
+		var err error
+		pair, err = issueTokenPair(c, tx, h.cfg, h.keys, &user, stored.FamilyID, stored.MFA)
+		return err
+	})
+	if err == errRefreshTokenReused {
+		h.handleReuse(c, &stored)
+		return
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.ErrTokenRefreshFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       i18n.T(c, "login.token_refreshed"),
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+	})
+}
+
+// handleReuse はリフレッシュトークンの再利用を検知した際の処理を行います
+// トークンが盗用された可能性があるため、ファミリー全体とそのセッションを失効させます
+func (h *AuthHandler) handleReuse(c *gin.Context, stored *models.RefreshToken) {
+	if err := h.sessions.RevokeFamily(stored.FamilyID); err != nil {
+		log.Printf("トークンファミリーの失効に失敗しました: %v", err)
+	}
+	log.Printf("リフレッシュトークンの再利用を検知しました: user_id=%d family_id=%s", stored.UserID, stored.FamilyID)
+
+	apperr.Abort(c, apperr.ErrRefreshTokenReused)
+}
+
+// Logout はログアウトを処理します
+// 使用中のアクセストークンとログインセッションを失効させます
+// リフレッシュトークンが指定された場合は、そのトークンファミリーも失効させます
+// （セッション管理の導入前に発行されたトークンとの互換性のため）
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	jti := c.GetString("jti")
+	expiresAt := c.GetTime("token_expires_at")
+
+	// リクエストボディは任意
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondValidationError(c, err)
+			return
+		}
+	}
+
+	// 1. アクセストークンの失効
+	if err := h.revocations.Revoke(jti, userID.(uint), expiresAt); err != nil {
+		apperr.Abort(c, apperr.ErrLogoutFailed.WithCause(err))
+		return
+	}
+
+	// 2. ログインセッションの失効（リフレッシュトークンファミリーを含む）
+	if sid := c.GetString("sid"); sid != "" {
+		if err := h.sessions.RevokeFamily(sid); err != nil {
+			apperr.Abort(c, apperr.ErrLogoutFailed.WithCause(err))
+			return
+		}
+	}
+
+	// 3. 指定されたリフレッシュトークンファミリーの失効（本人のトークンのみ）
+	if req.RefreshToken != "" {
+		var stored models.RefreshToken
+		err := h.db.Where("token_hash = ? AND user_id = ?", utils.HashToken(req.RefreshToken), userID).
+			First(&stored).Error
+		if err == nil {
+			if err := h.sessions.RevokeFamily(stored.FamilyID); err != nil {
+				apperr.Abort(c, apperr.ErrLogoutFailed.WithCause(err))
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "login.logged_out"),
+	})
+}
+
+// respondLoginLocked はログイン試行が制限されている場合のレスポンスを返します
+// Retry-After ヘッダーに再試行までの秒数を設定します
+func respondLoginLocked(c *gin.Context, wait time.Duration) {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	apperr.Abort(c, apperr.ErrLoginLocked.With("retry_after", seconds))
+}
+
+// resetLoginFailures はログイン成功時にアカウントの失敗回数をリセットします
+// 失敗記録がない場合はデータベースを更新しません
+func resetLoginFailures(guard *auth.LoginGuard, user *models.User) {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return
+	}
+	if err := guard.RecordSuccess(user.ID); err != nil {
+		log.Printf("ログイン失敗回数のリセットに失敗しました: %v", err)
+		return
+	}
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+}
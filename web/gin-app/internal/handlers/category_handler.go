@@ -0,0 +1,318 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// categorySubtree は条件に一致するカテゴリーと、その子孫のカテゴリーのIDを返すSQLです
+// root には起点となるカテゴリーの条件を指定します（例: "id = ?"）
+// 親子関係が循環していても終了するよう、UNION で重複を除きます
+func categorySubtree(root string) string {
+	return "WITH RECURSIVE category_tree AS (" +
+		"SELECT id FROM categories WHERE " + root +
+		" UNION SELECT categories.id FROM categories JOIN category_tree ON categories.parent_id = category_tree.id" +
+		") SELECT id FROM category_tree"
+}
+
+// categoryAncestorsSQL は指定したカテゴリーと、その祖先のカテゴリーを最上位から順に返すSQLです
+const categoryAncestorsSQL = "WITH RECURSIVE ancestors AS (" +
+	"SELECT id, parent_id, 0 AS depth FROM categories WHERE id = ?" +
+	" UNION SELECT categories.id, categories.parent_id, ancestors.depth + 1 FROM categories JOIN ancestors ON categories.id = ancestors.parent_id" +
+	") SELECT categories.* FROM categories JOIN ancestors ON categories.id = ancestors.id ORDER BY ancestors.depth DESC"
+
+// categoryDisplayOrder はカテゴリーの表示順です
+const categoryDisplayOrder = "sort_order, name, id"
+
+// CategoryHandler は商品カテゴリーに関するハンドラーをまとめる構造体です
+type CategoryHandler struct {
+	db *gorm.DB
+}
+
+// NewCategoryHandler は新しいCategoryHandlerを作成します
+func NewCategoryHandler(db *gorm.DB) *CategoryHandler {
+	return &CategoryHandler{db: db}
+}
+
+// ListCategories はカテゴリーの一覧をツリー構造で取得します（公開API）
+// GET /api/v1/categories
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	var categories []models.Category
+	if err := h.db.Order(categoryDisplayOrder).Find(&categories).Error; err != nil {
+		apperr.Abort(c, apperr.ErrCategoryFetchFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": models.BuildCategoryTree(categories),
+	})
+}
+
+// GetCategory はカテゴリーを、最上位からのパス（パンくずリスト）と子カテゴリーとともに取得します（公開API）
+// :id にはカテゴリーのIDまたはスラッグを指定できます
+// GET /api/v1/categories/:id
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	category, err := findCategory(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	var path []models.Category
+	if err := h.db.Raw(categoryAncestorsSQL, category.ID).Scan(&path).Error; err != nil {
+		apperr.Abort(c, apperr.ErrCategoryFetchFailed.WithCause(err))
+		return
+	}
+
+	children := []models.Category{}
+	if err := h.db.Where("parent_id = ?", category.ID).Order(categoryDisplayOrder).Find(&children).Error; err != nil {
+		apperr.Abort(c, apperr.ErrCategoryFetchFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+		"path":     path,
+		"children": children,
+	})
+}
+
+// CreateCategory は新しいカテゴリーを作成します（products:write 権限が必要）
+// POST /api/v1/categories
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req models.CategoryCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 1. スラッグの重複チェック（省略した場合は作成時に重複しないスラッグを作成）
+	if req.Slug != "" && h.slugTaken(req.Slug, 0) {
+		apperr.Abort(c, apperr.ErrCategorySlugTaken)
+		return
+	}
+
+	// 2. 親カテゴリーの確認（0 の場合は最上位にする）
+	if req.ParentID != nil && *req.ParentID == 0 {
+		req.ParentID = nil
+	}
+	if req.ParentID != nil {
+		var count int64
+		h.db.Model(&models.Category{}).Where("id = ?", *req.ParentID).Count(&count)
+		if count == 0 {
+			apperr.Abort(c, apperr.ErrCategoryParentInvalid)
+			return
+		}
+	}
+
+	category := models.Category{
+		Name:        strings.TrimSpace(req.Name),
+		Slug:        req.Slug,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		SortOrder:   req.SortOrder,
+	}
+	if err := h.db.Create(&category).Error; err != nil {
+		apperr.Abort(c, apperr.ErrCategoryCreateFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  i18n.T(c, "category.created"),
+		"category": category,
+	})
+}
+
+// UpdateCategory はカテゴリーを更新します（products:write 権限が必要）
+// 名前を変更した場合は、カテゴリーに属する商品のカテゴリー名も更新します
+// 親カテゴリーには、自分自身と子孫のカテゴリーは指定できません（循環の防止）
+// PUT /api/v1/categories/:id
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	category, err := findCategory(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	var req models.CategoryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 1. スラッグの重複チェック
+	if req.Slug != "" && req.Slug != category.Slug && h.slugTaken(req.Slug, category.ID) {
+		apperr.Abort(c, apperr.ErrCategorySlugTaken)
+		return
+	}
+
+	// 2. 親カテゴリーの確認（0 の場合は最上位にする）
+	if req.ParentID != nil && *req.ParentID != 0 {
+		valid, err := h.validParent(category.ID, *req.ParentID)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrCategoryUpdateFailed.WithCause(err))
+			return
+		}
+		if !valid {
+			apperr.Abort(c, apperr.ErrCategoryParentInvalid)
+			return
+		}
+	}
+
+	// 3. 更新するフィールドのみ適用
+	renamed := false
+	if name := strings.TrimSpace(req.Name); name != "" && name != category.Name {
+		category.Name = name
+		renamed = true
+	}
+	if req.Slug != "" {
+		category.Slug = req.Slug
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.ParentID != nil {
+		category.ParentID = req.ParentID
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		}
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+
+	// 4. 保存（名前を変更した場合は商品のカテゴリー名も同じトランザクションで更新）
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			return err
+		}
+		if !renamed {
+			return nil
+		}
+		// 削除済みの商品も復元される可能性があるため含めて更新する
+		return tx.Unscoped().Model(&models.Product{}).
+			Where("category_id = ?", category.ID).
+			UpdateColumn("category", category.Name).Error
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrCategoryUpdateFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  i18n.T(c, "category.updated"),
+		"category": category,
+	})
+}
+
+// DeleteCategory はカテゴリーを削除します（products:write 権限が必要）
+// 子カテゴリーまたは商品（削除済みを含む）があるカテゴリーは削除できません
+// DELETE /api/v1/categories/:id
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	category, err := findCategory(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	var children, products int64
+	h.db.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+	h.db.Unscoped().Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&products)
+	if children > 0 || products > 0 {
+		apperr.Abort(c, apperr.ErrCategoryInUse.With("children", children).With("products", products))
+		return
+	}
+
+	if err := h.db.Delete(category).Error; err != nil {
+		apperr.Abort(c, apperr.ErrCategoryDeleteFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "category.deleted"),
+	})
+}
+
+// slugTaken はスラッグが他のカテゴリーで使用されているかを返します
+func (h *CategoryHandler) slugTaken(slug string, exceptID uint) bool {
+	var count int64
+	h.db.Model(&models.Category{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count)
+	return count > 0
+}
+
+// validParent は parentID のカテゴリーを categoryID のカテゴリーの親にできるかを返します
+// 存在しないカテゴリー、自分自身、子孫のカテゴリーは親にできません
+func (h *CategoryHandler) validParent(categoryID, parentID uint) (bool, error) {
+	var exists int64
+	if err := h.db.Model(&models.Category{}).Where("id = ?", parentID).Count(&exists).Error; err != nil {
+		return false, err
+	}
+	if exists == 0 {
+		return false, nil
+	}
+
+	var descendant int64
+	err := h.db.Raw("SELECT count(*) FROM ("+categorySubtree("id = ?")+") AS subtree WHERE id = ?", categoryID, parentID).
+		Scan(&descendant).Error
+	return descendant == 0, err
+}
+
+// findCategory はIDまたはスラッグでカテゴリーを取得します
+func findCategory(db *gorm.DB, idOrSlug string) (*models.Category, error) {
+	var category models.Category
+	query := db.Where("slug = ?", idOrSlug)
+	if id, err := strconv.ParseUint(idOrSlug, 10, 64); err == nil {
+		query = db.Where("id = ?", id)
+	}
+
+	if err := query.First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrCategoryNotFound
+		}
+		return nil, apperr.ErrCategoryFetchFailed.WithCause(err)
+	}
+	return &category, nil
+}
+
+// resolveProductCategory は商品に設定するカテゴリーを、IDまたはスラッグ・名前から取得します
+// id を優先し、どちらも指定されていない場合は nil を返します
+// 同じ名前のカテゴリーが複数ある場合は、スラッグが一致するもの、IDが小さいものを優先します
+func resolveProductCategory(db *gorm.DB, id *uint, ref string) (*models.Category, error) {
+	var category models.Category
+	var err error
+	switch {
+	case id != nil:
+		err = db.First(&category, *id).Error
+	case strings.TrimSpace(ref) != "":
+		ref = strings.TrimSpace(ref)
+		err = db.Where("slug = ? OR name = ?", ref, ref).
+			Clauses(clause.OrderBy{Expression: clause.Expr{
+				SQL:                "slug = ? DESC, id",
+				Vars:               []interface{}{ref},
+				WithoutParentheses: true,
+			}}).
+			Take(&category).Error
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrCategoryUnknown
+		}
+		return nil, apperr.ErrCategoryFetchFailed.WithCause(err)
+	}
+	return &category, nil
+}
@@ -0,0 +1,118 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/mailer"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// sendVerificationEmail はメールアドレス確認用のトークンを発行し、確認メールを送信します
+// 以前に送信した確認リンクは無効になります
+// メールは locale で指定した言語で送信します
+func sendVerificationEmail(db *gorm.DB, cfg *config.Config, m mailer.Mailer, user *models.User, locale string) error {
+	token, err := createUserToken(db, user.ID, models.TokenPurposeEmailVerification, cfg.Auth.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", cfg.App.BaseURL, url.QueryEscape(token))
+	sendMailAsync(m, mailer.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(locale, "email.verify.subject", cfg.App.Name),
+		Body:    i18n.Translate(locale, "email.verify.body", user.Username, link, cfg.Auth.EmailVerificationTTL),
+	})
+
+	return nil
+}
+
+// userLocale はユーザーに送信するメールの言語を返します
+// ユーザーが言語を設定していない場合は、リクエストの言語を使用します
+func userLocale(c *gin.Context, user *models.User) string {
+	if i18n.IsSupported(user.Locale) {
+		return user.Locale
+	}
+	return i18n.Locale(c)
+}
+
+// VerifyEmail は確認トークンを使ってメールアドレスを確認済みにします
+// POST /api/v1/auth/email/verify
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	var user models.User
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// 1. トークンの検証と使用済み化
+		token, err := consumeUserToken(tx, req.Token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		// 2. ユーザーを確認済みに更新
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return errInvalidUserToken
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Model(&user).Update("email_verified_at", now).Error
+	})
+	if err == errInvalidUserToken {
+		apperr.Abort(c, apperr.ErrUserTokenInvalid)
+		return
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.ErrEmailVerifyFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "login.email_verified"),
+		"user":    user.ToResponse(),
+	})
+}
+
+// ResendVerification は確認メールを再送信します
+// 確認が必要な設定ではログインできないため、認証なしでメールアドレスを指定して呼び出します
+// アカウントの有無が推測されないよう、常に同じレスポンスを返します
+// POST /api/v1/auth/email/resend
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"message": i18n.T(c, "login.verification_email_sent"),
+	}
+
+	// 未確認の有効なユーザーにのみ送信
+	var user models.User
+	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil ||
+		!user.IsActive || user.IsEmailVerified() {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if err := sendVerificationEmail(h.db, h.cfg, h.mailer, &user, userLocale(c, &user)); err != nil {
+		log.Printf("確認メールの送信に失敗しました: user_id=%d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
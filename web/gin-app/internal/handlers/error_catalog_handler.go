@@ -0,0 +1,57 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"net/http"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCatalogHandler はエラーコードの一覧を返すハンドラーです
+// エラーレスポンスの type（/api/v1/errors/<code>）の参照先になります
+type ErrorCatalogHandler struct{}
+
+// NewErrorCatalogHandler は新しいErrorCatalogHandlerを作成します
+func NewErrorCatalogHandler() *ErrorCatalogHandler {
+	return &ErrorCatalogHandler{}
+}
+
+// ListErrorCodes はエラーコードの一覧を返します
+// GET /api/v1/errors
+func (h *ErrorCatalogHandler) ListErrorCodes(c *gin.Context) {
+	catalog := apperr.Catalog()
+	codes := make([]gin.H, 0, len(catalog))
+	for _, e := range catalog {
+		codes = append(codes, errorCodeResponse(e, i18n.Locale(c)))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"errors": codes,
+		"total":  len(codes),
+	})
+}
+
+// GetErrorCode は指定したエラーコードの説明を返します
+// GET /api/v1/errors/:code
+func (h *ErrorCatalogHandler) GetErrorCode(c *gin.Context) {
+	e, ok := apperr.Lookup(c.Param("code"))
+	if !ok {
+		apperr.Abort(c, apperr.ErrErrorCodeNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, errorCodeResponse(e, i18n.Locale(c)))
+}
+
+// errorCodeResponse はエラーコードの定義をレスポンス用に変換します
+// メッセージは代表的なもので、実際のエラーでは処理に応じたメッセージになる場合があります
+func errorCodeResponse(e *apperr.AppError, locale string) gin.H {
+	return gin.H{
+		"code":   e.Code,
+		"status": e.Status,
+		"title":  e.Message(locale),
+	}
+}
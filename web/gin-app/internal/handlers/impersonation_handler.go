@@ -0,0 +1,123 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ImpersonationHandler はなりすまし（サポート担当者によるユーザー視点での操作）を扱う構造体です
+type ImpersonationHandler struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	keys        *auth.KeyManager
+	permissions *auth.PermissionStore
+	audit       *auth.AuditLogger
+}
+
+// NewImpersonationHandler は新しいImpersonationHandlerを作成します
+func NewImpersonationHandler(db *gorm.DB, cfg *config.Config, keys *auth.KeyManager, permissions *auth.PermissionStore, audit *auth.AuditLogger) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		db:          db,
+		cfg:         cfg,
+		keys:        keys,
+		permissions: permissions,
+		audit:       audit,
+	}
+}
+
+// ImpersonateUser は指定したユーザーになりすますためのアクセストークンを発行します（users:impersonate 権限が必要）
+// トークンには act クレームでなりすましを行っているユーザーが記録され、
+// 有効期限は AUTH_IMPERSONATION_TTL で、リフレッシュトークンは発行しません
+// 権限の昇格を防ぐため、自分が持たない権限を持つユーザーにはなりすませません
+// POST /api/v1/users/:id/impersonate
+func (h *ImpersonationHandler) ImpersonateUser(c *gin.Context) {
+	var req models.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 1. 対象ユーザーの取得
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidUserID)
+		return
+	}
+
+	actorID := c.GetUint("user_id")
+	if uint(id) == actorID {
+		apperr.Abort(c, apperr.ErrImpersonationSelf)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	if !user.IsActive {
+		apperr.Abort(c, apperr.ErrImpersonationTargetInactive)
+		return
+	}
+
+	// 2. 対象ユーザーの権限が自分の権限の範囲内かを確認
+	role := c.GetString("role")
+	for _, permission := range h.permissions.Permissions(user.Role) {
+		if !h.permissions.HasPermission(role, permission) {
+			apperr.Abort(c, apperr.ErrImpersonationEscalation.With("permission", permission))
+			return
+		}
+	}
+
+	// 3. なりすまし用トークンの生成
+	// セッションには紐付けず、なりすましを行うユーザー自身の二要素認証の状態を引き継ぐ
+	actor := &utils.Actor{
+		UserID:   actorID,
+		Username: c.GetString("username"),
+	}
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role, h.cfg.JWT, h.keys, utils.TokenOptions{
+		MFA:   c.GetBool("mfa"),
+		Actor: actor,
+		TTL:   h.cfg.Auth.ImpersonationTTL,
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrTokenGenerateFailed.WithCause(err))
+		return
+	}
+
+	// 4. 監査ログに記録（記録できない場合はトークンを返さない）
+	if err := h.audit.Record(&models.AuditLog{
+		Action:    models.AuditActionImpersonationStart,
+		ActorID:   actor.UserID,
+		ActorName: actor.Username,
+		UserID:    user.ID,
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		IPAddress: c.ClientIP(),
+		RequestID: c.GetString("request_id"),
+		Details:   req.Reason,
+	}); err != nil {
+		apperr.Abort(c, apperr.ErrAuditLogRecordFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      i18n.T(c, "impersonation.started"),
+		"token":        token,
+		"expires_in":   int64(h.cfg.Auth.ImpersonationTTL.Seconds()),
+		"user":         user.ToResponse(),
+		"impersonator": actor,
+	})
+}
@@ -0,0 +1,83 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MediaHandler はアップロードしたファイル（商品画像）を配信するハンドラーです
+type MediaHandler struct {
+	blobs       storage.BlobStore
+	cacheMaxAge time.Duration
+}
+
+// NewMediaHandler は新しいMediaHandlerを作成します
+func NewMediaHandler(blobs storage.BlobStore, cfg config.StorageConfig) *MediaHandler {
+	return &MediaHandler{blobs: blobs, cacheMaxAge: cfg.CacheMaxAge}
+}
+
+// ServeFile は保存したファイルを返します（公開API）
+// ファイルのキーはアップロードごとに異なり内容は変更されないため、長期間キャッシュできるよう
+// Cache-Control に immutable を付け、ETag が一致する場合は 304 Not Modified を返します
+// GET /media/*key
+func (h *MediaHandler) ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	body, object, err := h.blobs.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			apperr.Abort(c, apperr.ErrFileNotFound)
+			return
+		}
+		apperr.Abort(c, apperr.ErrFileFetchFailed.WithCause(err))
+		return
+	}
+	defer body.Close()
+
+	header := c.Writer.Header()
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int64(h.cacheMaxAge.Seconds())))
+	header.Set("X-Content-Type-Options", "nosniff") // 保存したMIMEタイプ以外として解釈させない
+	if object.ETag != "" {
+		header.Set("ETag", object.ETag)
+	}
+	if !object.ModTime.IsZero() {
+		header.Set("Last-Modified", object.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	// キャッシュが有効な場合は本文を返さない
+	if object.ETag != "" && etagMatches(c.GetHeader("If-None-Match"), object.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	contentType := object.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, object.Size, contentType, body, nil)
+}
+
+// etagMatches は If-None-Match ヘッダーに ETag が含まれるかを返します（弱い比較）
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -2,23 +2,37 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"strconv"
 
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/inventory"
+	"go_learning/web/gin-app/internal/middleware"
 	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/pagination"
+	"go_learning/web/gin-app/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // OrderHandler は注文関連のハンドラーをまとめる構造体です
 type OrderHandler struct {
-	db *gorm.DB
+	db          *gorm.DB
+	permissions *auth.PermissionStore
 }
 
 // NewOrderHandler は新しいOrderHandlerを作成します
-func NewOrderHandler(db *gorm.DB) *OrderHandler {
-	return &OrderHandler{db: db}
+func NewOrderHandler(db *gorm.DB, permissions *auth.PermissionStore) *OrderHandler {
+	return &OrderHandler{db: db, permissions: permissions}
+}
+
+// hasPermission はリクエストしたユーザー（またはAPIキー）が指定した権限を持つかどうかを返します
+func (h *OrderHandler) hasPermission(c *gin.Context, permission string) bool {
+	return middleware.HasPermission(c, h.permissions, permission)
 }
 
 // CreateOrder は新しい注文を作成します
@@ -28,9 +42,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	var req models.OrderCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "入力値が無効です: " + err.Error(),
-		})
+		utils.RespondValidationError(c, err)
 		return
 	}
 
@@ -52,9 +64,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	if err := tx.Create(&order).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "注文の作成に失敗しました",
-		})
+		apperr.Abort(c, apperr.ErrOrderCreateFailed.WithCause(err))
 		return
 	}
 
@@ -65,18 +75,28 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		var product models.Product
 		if err := tx.First(&product, item.ProductID).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "商品が見つかりません: " + strconv.Itoa(int(item.ProductID)),
-			})
+			apperr.Abort(c, apperr.ErrOrderProductNotFound.WithArgs(item.ProductID).With("product_id", item.ProductID))
+			return
+		}
+
+		// バリエーションの取得（バリエーションがある商品はバリエーションの指定が必須）
+		variant, err := orderItemVariant(tx, &product, item.VariantID)
+		if err != nil {
+			tx.Rollback()
+			apperr.Abort(c, err)
 			return
 		}
 
-		// 在庫チェック
-		if product.Stock < item.Quantity {
+		// 在庫チェック（バリエーションを指定した場合はバリエーションの在庫）
+		if variant != nil && variant.Stock < item.Quantity {
+			tx.Rollback()
+			apperr.Abort(c, apperr.ErrInsufficientStock.WithArgs(product.Name+" ("+variant.Title+")").
+				With("product_id", product.ID).With("variant_id", variant.ID))
+			return
+		}
+		if variant == nil && product.Stock < item.Quantity {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "在庫が不足しています: " + product.Name,
-			})
+			apperr.Abort(c, apperr.ErrInsufficientStock.WithArgs(product.Name).With("product_id", product.ID))
 			return
 		}
 
@@ -84,26 +104,43 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		orderItem := models.OrderItem{
 			OrderID:   order.ID,
 			ProductID: product.ID,
+			VariantID: item.VariantID,
 			Quantity:  item.Quantity,
 			Price:     product.Price, // 注文時の価格を記録
 		}
+		if variant != nil {
+			orderItem.Price = variant.UnitPrice(product.Price)
+		}
 		orderItem.Subtotal = float64(orderItem.Quantity) * orderItem.Price
 
 		if err := tx.Create(&orderItem).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "注文明細の作成に失敗しました",
-			})
+			apperr.Abort(c, apperr.ErrOrderItemCreateFailed.WithCause(err))
 			return
 		}
 
-		// 在庫を減らす
-		product.Stock -= item.Quantity
-		if err := tx.Save(&product).Error; err != nil {
+		// 在庫を減らし、出庫として在庫の移動に記録する
+		// （バリエーションの場合は商品の在庫をバリエーションの在庫の合計に更新）
+		if _, err := inventory.Apply(tx, inventory.Change{
+			ProductID: product.ID,
+			VariantID: item.VariantID,
+			Delta:     -item.Quantity,
+			Reason:    models.StockReasonSale,
+			OrderID:   &order.ID,
+			Actor:     stockActor(c),
+		}); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "在庫の更新に失敗しました",
-			})
+			// 在庫チェックの後に他の注文で在庫が減った場合
+			if errors.Is(err, inventory.ErrNegativeStock) && variant != nil {
+				apperr.Abort(c, apperr.ErrInsufficientStock.WithArgs(product.Name+" ("+variant.Title+")").
+					With("product_id", product.ID).With("variant_id", variant.ID))
+				return
+			}
+			if errors.Is(err, inventory.ErrNegativeStock) {
+				apperr.Abort(c, apperr.ErrInsufficientStock.WithArgs(product.Name).With("product_id", product.ID))
+				return
+			}
+			apperr.Abort(c, apperr.ErrStockUpdateFailed.WithCause(err))
 			return
 		}
 
@@ -115,25 +152,21 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	order.TotalAmount = totalAmount
 	if err := tx.Save(&order).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "注文の更新に失敗しました",
-		})
+		apperr.Abort(c, apperr.ErrOrderUpdateFailed.WithCause(err))
 		return
 	}
 
 	// トランザクションのコミット
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "注文の確定に失敗しました",
-		})
+		apperr.Abort(c, apperr.ErrOrderCommitFailed.WithCause(err))
 		return
 	}
 
 	// 注文明細を含めて取得
-	h.db.Preload("OrderItems.Product").First(&order, order.ID)
+	h.db.Preload("OrderItems.Product").Preload("OrderItems.Variant").First(&order, order.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "注文を作成しました",
+		"message": i18n.T(c, "order.created"),
 		"order":   order,
 	})
 }
@@ -142,45 +175,39 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 // GET /api/v1/orders
 func (h *OrderHandler) ListOrders(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	role, _ := c.Get("role")
 
 	// ページネーション
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	offset := (page - 1) * pageSize
+	pageReq, err := pagination.Parse(c, pagination.DefaultPageSize, pagination.MaxPageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
 
 	query := h.db.Model(&models.Order{})
 
-	// 管理者以外は自分の注文のみ表示
-	if role != "admin" {
+	// 全注文の閲覧権限がない場合は自分の注文のみ表示
+	if !h.hasPermission(c, models.PermissionOrdersReadAll) {
 		query = query.Where("user_id = ?", userID)
 	}
 
-	// 総数を取得
-	var total int64
-	query.Count(&total)
-
-	// 注文を取得（注文明細と商品情報も含む）
-	var orders []models.Order
-	if err := query.
-		Preload("OrderItems.Product").
-		Limit(pageSize).
-		Offset(offset).
-		Order("created_at DESC").
-		Find(&orders).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "注文の取得に失敗しました",
-		})
+	// 注文を新しい順に取得（注文明細と商品情報も含む）
+	keys := []pagination.Key{
+		{Name: "created_at", Column: "orders.created_at", Desc: true},
+		{Name: "id", Column: "orders.id", Desc: true},
+	}
+	orders, page, err := pagination.Find(query, pageReq, keys, func(order *models.Order) []interface{} {
+		return []interface{}{order.CreatedAt, order.ID}
+	}, func(tx *gorm.DB) *gorm.DB {
+		return tx.Preload("OrderItems.Product").Preload("OrderItems.Variant")
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrOrderFetchFailed.WithCause(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"orders":      orders,
-		"total":       total,
-		"page":        page,
-		"page_size":   pageSize,
-		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-	})
+	response := page.Fields()
+	response["orders"] = orders
+	c.JSON(http.StatusOK, response)
 }
 
 // GetOrder は特定の注文情報を取得します
@@ -188,58 +215,54 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	id := c.Param("id")
 	userID, _ := c.Get("user_id")
-	role, _ := c.Get("role")
 
 	var order models.Order
-	query := h.db.Preload("OrderItems.Product").Preload("User")
+	query := h.db.Preload("OrderItems.Product").Preload("OrderItems.Variant").Preload("User")
 
-	// 管理者以外は自分の注文のみ表示
-	if role != "admin" {
+	// 全注文の閲覧権限がない場合は自分の注文のみ表示
+	if !h.hasPermission(c, models.PermissionOrdersReadAll) {
 		query = query.Where("user_id = ?", userID)
 	}
 
 	if err := query.First(&order, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "注文が見つかりません",
-		})
+		apperr.Abort(c, apperr.ErrOrderNotFound)
 		return
 	}
 
 	c.JSON(http.StatusOK, order)
 }
 
-// UpdateOrderStatus は注文ステータスを更新します（管理者のみ）
+// UpdateOrderStatus は注文ステータスを更新します（orders:update_status 権限が必要）
+// キャンセルは在庫を戻す必要があるため、CancelOrder（POST /orders/:id/cancel）を使用します
 // PATCH /api/v1/orders/:id/status
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	id := c.Param("id")
 
 	var req models.OrderUpdateStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "入力値が無効です: " + err.Error(),
-		})
+		utils.RespondValidationError(c, err)
+		return
+	}
+	if req.Status == models.OrderStatusCancelled {
+		apperr.Abort(c, apperr.ErrOrderCancelRequired)
 		return
 	}
 
 	var order models.Order
 	if err := h.db.First(&order, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "注文が見つかりません",
-		})
+		apperr.Abort(c, apperr.ErrOrderNotFound)
 		return
 	}
 
 	// ステータスの更新
 	order.Status = req.Status
 	if err := h.db.Save(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "ステータスの更新に失敗しました",
-		})
+		apperr.Abort(c, apperr.ErrOrderStatusUpdateFailed.WithCause(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "注文ステータスを更新しました",
+		"message": i18n.T(c, "order.status_updated"),
 		"order":   order,
 	})
 }
@@ -249,57 +272,104 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	id := c.Param("id")
 	userID, _ := c.Get("user_id")
-	role, _ := c.Get("role")
 
 	var order models.Order
 	query := h.db.Preload("OrderItems")
 
-	// 管理者以外は自分の注文のみキャンセル可能
-	if role != "admin" {
+	// 全注文のキャンセル権限がない場合は自分の注文のみキャンセル可能
+	if !h.hasPermission(c, models.PermissionOrdersCancelAny) {
 		query = query.Where("user_id = ?", userID)
 	}
 
 	if err := query.First(&order, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "注文が見つかりません",
-		})
+		apperr.Abort(c, apperr.ErrOrderNotFound)
 		return
 	}
 
-	// キャンセル可能なステータスチェック
-	if order.Status == models.OrderStatusShipped || order.Status == models.OrderStatusDelivered {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "発送済みまたは配達完了の注文はキャンセルできません",
-		})
+	// キャンセル可能なステータスチェック（キャンセル済みの注文の在庫を再度戻さない）
+	if order.Status == models.OrderStatusShipped || order.Status == models.OrderStatusDelivered ||
+		order.Status == models.OrderStatusCancelled {
+		apperr.Abort(c, apperr.ErrOrderNotCancellable)
 		return
 	}
 
 	// トランザクション開始
 	tx := h.db.Begin()
 
-	// 在庫を戻す
+	// 同時にキャンセルされた場合に在庫を二重に戻さないよう、注文の行をロックしてステータスを再確認する
+	var current models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, order.ID).Error; err != nil {
+		tx.Rollback()
+		apperr.Abort(c, apperr.ErrOrderCancelFailed.WithCause(err))
+		return
+	}
+	if current.Status == models.OrderStatusShipped || current.Status == models.OrderStatusDelivered ||
+		current.Status == models.OrderStatusCancelled {
+		tx.Rollback()
+		apperr.Abort(c, apperr.ErrOrderNotCancellable)
+		return
+	}
+
+	// 在庫を戻し、キャンセルとして在庫の移動に記録する
+	// （バリエーションの場合はバリエーションの在庫を戻し、商品の在庫を合計に更新）
 	for _, item := range order.OrderItems {
-		var product models.Product
-		if err := tx.First(&product, item.ProductID).Error; err == nil {
-			product.Stock += item.Quantity
-			tx.Save(&product)
+		_, err := inventory.Apply(tx, inventory.Change{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Delta:     item.Quantity,
+			Reason:    models.StockReasonCancel,
+			OrderID:   &order.ID,
+			Actor:     stockActor(c),
+		})
+		// 削除された商品・バリエーションの在庫は戻さない
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			apperr.Abort(c, apperr.ErrStockUpdateFailed.WithCause(err))
+			return
 		}
 	}
 
-	// ステータスをキャンセルに変更
-	order.Status = models.OrderStatusCancelled
-	if err := tx.Save(&order).Error; err != nil {
+	// ステータスをキャンセルに変更（ロックした注文のステータスのみ更新する）
+	if err := tx.Model(&current).Update("status", models.OrderStatusCancelled).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "注文のキャンセルに失敗しました",
-		})
+		apperr.Abort(c, apperr.ErrOrderCancelFailed.WithCause(err))
 		return
 	}
 
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		apperr.Abort(c, apperr.ErrOrderCancelFailed.WithCause(err))
+		return
+	}
 
+	current.OrderItems = order.OrderItems
 	c.JSON(http.StatusOK, gin.H{
-		"message": "注文をキャンセルしました",
-		"order":   order,
+		"message": i18n.T(c, "order.cancelled"),
+		"order":   current,
 	})
 }
+
+// orderItemVariant は注文明細に指定されたバリエーションを取得します
+// バリエーションを指定していない場合は nil を返しますが、商品にバリエーションがある場合はエラーにします
+// 他の商品のバリエーションと、販売中でないバリエーションは指定できません
+func orderItemVariant(tx *gorm.DB, product *models.Product, variantID *uint) (*models.ProductVariant, error) {
+	if variantID == nil {
+		variants, err := hasVariants(tx, product.ID)
+		if err != nil {
+			return nil, apperr.ErrVariantFetchFailed.WithCause(err)
+		}
+		if variants {
+			return nil, apperr.ErrVariantRequired.WithArgs(product.Name).With("product_id", product.ID)
+		}
+		return nil, nil
+	}
+
+	var variant models.ProductVariant
+	if err := tx.Where("product_id = ? AND is_active = ?", product.ID, true).First(&variant, *variantID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrVariantFetchFailed.WithCause(err)
+		}
+		return nil, apperr.ErrOrderVariantNotFound.WithArgs(*variantID).
+			With("product_id", product.ID).With("variant_id", *variantID)
+	}
+	return &variant, nil
+}
@@ -0,0 +1,219 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/mailer"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ForgotPassword はパスワードリセットを申請し、リセット用のメールを送信します
+// アカウントの有無が推測されないよう、メールアドレスが登録されていなくても同じレスポンスを返します
+// POST /api/v1/auth/password/forgot
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"message": i18n.T(c, "password.reset_email_sent"),
+	}
+
+	// 1. 有効なユーザーの検索（見つからなくても同じレスポンスを返す）
+	var user models.User
+	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil || !user.IsActive {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// 2. リセットトークンの発行
+	token, err := createUserToken(h.db, user.ID, models.TokenPurposePasswordReset, h.cfg.Auth.PasswordResetTTL)
+	if err != nil {
+		log.Printf("パスワードリセットトークンの発行に失敗しました: user_id=%d: %v", user.ID, err)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// 3. リセット用メールの送信（ユーザーが設定した言語、未設定の場合はリクエストの言語）
+	link := fmt.Sprintf("%s/reset-password?token=%s", h.cfg.App.BaseURL, url.QueryEscape(token))
+	locale := userLocale(c, &user)
+	sendMailAsync(h.mailer, mailer.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(locale, "email.password_reset.subject", h.cfg.App.Name),
+		Body:    i18n.Translate(locale, "email.password_reset.body", user.Username, link, h.cfg.Auth.PasswordResetTTL),
+	})
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword はリセットトークンを使ってパスワードを再設定します
+// トークンは一度しか使用できず、再設定後はそのユーザーの全セッションを無効化します
+// 新しいパスワードがポリシーを満たさない場合、トークンは使用済みにならず再試行できます
+// POST /api/v1/auth/password/reset
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	var user models.User
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// 1. トークンの検証と使用済み化
+		token, err := consumeUserToken(tx, req.Token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		// 2. ユーザーの取得（無効化されたユーザーはリセット不可）
+		if err := tx.First(&user, token.UserID).Error; err != nil || !user.IsActive {
+			return errInvalidUserToken
+		}
+
+		// 3. パスワードポリシーのチェック（直近のパスワードの再利用を含む）
+		if err := h.policy.Validate(req.Password, &user); err != nil {
+			return err
+		}
+
+		// 4. 新しいパスワードの設定と履歴への記録
+		if err := user.SetPassword(req.Password); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("password", user.Password).Error; err != nil {
+			return err
+		}
+		return h.policy.Record(tx, &user)
+	})
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		respondPasswordPolicyError(c, "password", policyErr)
+		return
+	}
+	if err == errInvalidUserToken {
+		apperr.Abort(c, apperr.ErrUserTokenInvalid)
+		return
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.ErrPasswordResetFailed.WithCause(err))
+		return
+	}
+
+	// 5. 既存のセッションを全て無効化（盗まれたトークンを使えなくする）
+	if err := h.revocations.RevokeUserSessions(user.ID); err != nil {
+		log.Printf("セッションの無効化に失敗しました: user_id=%d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "password.reset_done"),
+	})
+}
+
+// ChangePassword はログイン中のユーザーのパスワードを変更します
+// 現在のパスワードの入力が必要で、変更後は使用中のセッション以外を全て終了させます
+// PUT /api/v1/users/profile/password
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req models.PasswordChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, c.GetUint("user_id")).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	// 1. 現在のパスワードの確認（盗まれたトークンでの総当たりを防ぐため、ログイン失敗として記録）
+	if wait, locked := h.guard.CheckUser(&user); locked {
+		respondLoginLocked(c, wait)
+		return
+	}
+	if !user.CheckPassword(req.CurrentPassword) {
+		if err := h.guard.RecordUserFailure(user.ID, c.ClientIP()); err != nil {
+			log.Printf("ログイン失敗の記録に失敗しました: %v", err)
+		}
+		apperr.Abort(c, apperr.ErrCurrentPasswordIncorrect)
+		return
+	}
+
+	// 2. パスワードポリシーのチェック（直近のパスワードの再利用を含む）
+	if !checkPasswordPolicy(c, h.policy, "new_password", req.NewPassword, &user) {
+		return
+	}
+
+	// 3. 新しいパスワードの設定と履歴への記録
+	if err := user.SetPassword(req.NewPassword); err != nil {
+		apperr.Abort(c, apperr.ErrPasswordChangeFailed.WithCause(err))
+		return
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", user.Password).Error; err != nil {
+			return err
+		}
+		return h.policy.Record(tx, &user)
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrPasswordChangeFailed.WithCause(err))
+		return
+	}
+
+	resetLoginFailures(h.guard, &user)
+
+	// 4. 他の端末のセッションを終了（使用中のセッションは維持）
+	if err := h.sessions.RevokeOthers(user.ID, c.GetString("sid")); err != nil {
+		log.Printf("セッションの無効化に失敗しました: user_id=%d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "password.changed"),
+	})
+}
+
+// checkPasswordPolicy はパスワードポリシーを検証し、満たさない場合はエラーレスポンスを返します
+// 文字数等の要件はバインド時（strong_password）にも検証されるため、主に直近のパスワードの再利用を確認します
+// field にはエラーの details に含めるフィールド名を指定します
+func checkPasswordPolicy(c *gin.Context, policy *auth.PasswordPolicy, field, password string, user *models.User) bool {
+	err := policy.Validate(password, user)
+	if err == nil {
+		return true
+	}
+
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		respondPasswordPolicyError(c, field, policyErr)
+		return false
+	}
+
+	apperr.Abort(c, apperr.ErrPasswordCheckFailed.WithCause(err))
+	return false
+}
+
+// respondPasswordPolicyError はパスワードポリシー違反のレスポンスを返します（エラーコード PASSWORD_POLICY_VIOLATION）
+// 満たしていない要件ごとに、入力エラーと同じ形式でリクエストの言語のメッセージを details に含めます
+func respondPasswordPolicyError(c *gin.Context, field string, err *auth.PasswordPolicyError) {
+	locale := i18n.Locale(c)
+	details := make([]utils.FieldError, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		details = append(details, utils.FieldError{
+			Field:   field,
+			Rule:    utils.ValidateStrongPassword,
+			Message: violation.Translate(locale),
+		})
+	}
+	apperr.Abort(c, apperr.ErrPasswordPolicy.WithDetails(details))
+}
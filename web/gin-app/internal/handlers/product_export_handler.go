@@ -0,0 +1,178 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// productExportBatchSize は商品のエクスポートで一度に取得する商品の数です
+	productExportBatchSize = 500
+	// productExportBatchTimeout は商品を1回取得して書き込むまでのタイムアウトです
+	// 商品が多い場合もサーバー全体のタイムアウトで途中で終了しないよう、取得するごとに延長します
+	productExportBatchTimeout = 30 * time.Second
+)
+
+// productCSVColumns はエクスポートするCSVの列です
+// インポートでは id, is_active, created_at, updated_at の列を無視するため、在庫切れ（stock が 0）の商品を含めてそのままインポートできます
+var productCSVColumns = []string{
+	"id", "sku", "name", "description", "price", "stock", "category_id", "category",
+	"image_url", "is_active", "created_at", "updated_at",
+}
+
+// productExportWriter はエクスポートするファイルに商品を書き込みます
+type productExportWriter interface {
+	writeHeader() error
+	write(product *models.Product) error
+	flush() error
+}
+
+// ExportProducts は絞り込んだ商品をCSVまたはNDJSON（1行に1つのJSON）のファイルで返します（products:write 権限が必要）
+// 絞り込み条件は商品一覧と同じです。ただし active_only のデフォルトは false（販売中でない商品を含む）です
+// 全ての商品をメモリに読み込まないよう、ID順に productExportBatchSize 件ずつ取得してレスポンスに書き込みます
+// GET /api/v1/products/export
+func (h *ProductHandler) ExportProducts(c *gin.Context) {
+	// 1. 形式と絞り込み条件の読み取り
+	format := c.DefaultQuery("format", productFormatCSV)
+	if format != productFormatCSV && format != productFormatNDJSON {
+		apperr.Abort(c, apperr.ErrInvalidQueryParameter.WithArgs("format"))
+		return
+	}
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if c.Query("active_only") == "" {
+		filter.activeOnly = false
+	}
+
+	var writer productExportWriter
+	contentType := "text/csv; charset=utf-8"
+	if format == productFormatCSV {
+		writer = &csvProductWriter{w: csv.NewWriter(c.Writer)}
+	} else {
+		writer = &ndjsonProductWriter{enc: json.NewEncoder(c.Writer)}
+		contentType = "application/x-ndjson"
+	}
+
+	// 2. 最初の商品を取得できた時点でレスポンスを開始する（取得に失敗した場合はエラーを返せるようにする）
+	started := false
+	start := func() error {
+		started = true
+		filename := "products-" + time.Now().Format("20060102") + "." + format
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+		return writer.writeHeader()
+	}
+
+	// 3. 商品を少しずつ取得して書き込む
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.SetWriteDeadline(time.Now().Add(productExportBatchTimeout)) // 設定できない場合はサーバー全体のタイムアウトのまま処理する
+
+	var products []models.Product
+	err = filter.apply(h.db.Model(&models.Product{})).
+		FindInBatches(&products, productExportBatchSize, func(tx *gorm.DB, batch int) error {
+			_ = rc.SetWriteDeadline(time.Now().Add(productExportBatchTimeout))
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			for i := range products {
+				if err := writer.write(&products[i]); err != nil {
+					return err
+				}
+			}
+			if err := writer.flush(); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}).Error
+	if err == nil && !started {
+		err = start()
+		if err == nil {
+			err = writer.flush()
+		}
+	}
+
+	if err != nil {
+		if !started {
+			apperr.Abort(c, apperr.ErrProductExportFailed.WithCause(err))
+			return
+		}
+		// レスポンスを開始した後はステータスを変更できないため、ログに記録して途中で終了する
+		log.Printf("商品のエクスポートに失敗しました: %v", err)
+	}
+}
+
+// csvProductWriter は商品をCSVで書き込みます
+type csvProductWriter struct {
+	w *csv.Writer
+}
+
+// writeHeader はヘッダー（列名）を書き込みます
+func (cw *csvProductWriter) writeHeader() error {
+	return cw.w.Write(productCSVColumns)
+}
+
+// write は商品を1行書き込みます
+func (cw *csvProductWriter) write(p *models.Product) error {
+	categoryID := ""
+	if p.CategoryID != nil {
+		categoryID = strconv.FormatUint(uint64(*p.CategoryID), 10)
+	}
+	return cw.w.Write([]string{
+		strconv.FormatUint(uint64(p.ID), 10),
+		p.SKU,
+		p.Name,
+		p.Description,
+		strconv.FormatFloat(p.Price, 'f', 2, 64),
+		strconv.Itoa(p.Stock),
+		categoryID,
+		p.Category,
+		p.ImageURL,
+		strconv.FormatBool(p.IsActive),
+		p.CreatedAt.Format(time.RFC3339),
+		p.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+// flush はバッファの内容をレスポンスに書き込みます
+func (cw *csvProductWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// ndjsonProductWriter は商品を1行に1つのJSONで書き込みます（商品一覧の商品と同じ形式）
+type ndjsonProductWriter struct {
+	enc *json.Encoder
+}
+
+// writeHeader は何も書き込みません（NDJSONにはヘッダーがありません）
+func (nw *ndjsonProductWriter) writeHeader() error {
+	return nil
+}
+
+// write は商品を1行書き込みます
+func (nw *ndjsonProductWriter) write(p *models.Product) error {
+	return nw.enc.Encode(p)
+}
+
+// flush は何もしません（Encode はすぐにレスポンスに書き込みます）
+func (nw *ndjsonProductWriter) flush() error {
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"go_learning/web/gin-app/internal/models"
+)
+
+// productPriceBoundaries は価格帯のファセットの区切りです（円）
+// 0〜1000未満、1000〜5000未満、…、50000以上 の5つの価格帯で集計します
+var productPriceBoundaries = []float64{1000, 5000, 10000, 50000}
+
+// priceBucketCount は価格帯（width_bucket の番号）ごとの商品数です
+type priceBucketCount struct {
+	Bucket int
+	Count  int64
+}
+
+// productFacets は絞り込み条件に一致する商品のファセット（条件ごとの商品数）を集計します
+// 各項目はその項目自体の絞り込みを除いて集計するため、選択中の条件以外の件数も表示できます
+func (h *ProductHandler) productFacets(f *productFilter) (*models.ProductFacets, error) {
+	facets := &models.ProductFacets{Categories: []models.CategoryFacet{}}
+
+	// 1. カテゴリーごとの商品数（多い順）
+	if err := f.apply(h.db.Model(&models.Product{}), productFilterCategory).
+		Where("products.category <> ''").
+		Select("products.category AS value, count(*) AS count").
+		Group("products.category").
+		Order("count DESC, value").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	// 2. 価格帯ごとの商品数（width_bucket は区切りより安い場合に0、以降は1, 2, … を返す）
+	var buckets []priceBucketCount
+	if err := f.apply(h.db.Model(&models.Product{}), productFilterPrice).
+		Select("width_bucket(products.price, " + priceBoundariesSQL() + ") AS bucket, count(*) AS count").
+		Group("bucket").
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+	facets.PriceRanges = priceRangeFacets(buckets)
+
+	// 3. 在庫の有無ごとの商品数
+	if err := f.apply(h.db.Model(&models.Product{}), productFilterStock).
+		Select("count(*) FILTER (WHERE products.stock > 0) AS in_stock, " +
+			"count(*) FILTER (WHERE products.stock <= 0) AS out_of_stock").
+		Scan(&facets.Availability).Error; err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// priceBoundariesSQL は価格帯の区切りを width_bucket に渡す配列のSQLに変換します
+func priceBoundariesSQL() string {
+	values := make([]string, 0, len(productPriceBoundaries))
+	for _, b := range productPriceBoundaries {
+		values = append(values, strconv.FormatFloat(b, 'f', -1, 64))
+	}
+	return "ARRAY[" + strings.Join(values, ", ") + "]::numeric[]"
+}
+
+// priceRangeFacets は価格帯ごとの商品数を、商品がない価格帯も含めて安い順に並べます
+func priceRangeFacets(buckets []priceBucketCount) []models.PriceRangeFacet {
+	ranges := make([]models.PriceRangeFacet, len(productPriceBoundaries)+1)
+	for i := range ranges {
+		if i > 0 {
+			ranges[i].Min = productPriceBoundaries[i-1]
+		}
+		if i < len(productPriceBoundaries) {
+			max := productPriceBoundaries[i]
+			ranges[i].Max = &max
+		}
+	}
+
+	for _, b := range buckets {
+		if b.Bucket >= 0 && b.Bucket < len(ranges) {
+			ranges[b.Bucket].Count = b.Count
+		}
+	}
+	return ranges
+}
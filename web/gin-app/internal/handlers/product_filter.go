@@ -0,0 +1,301 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/pagination"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 商品一覧の絞り込み条件の種類
+// ファセットの集計で、集計する項目自体の条件を除外する場合に指定します
+const (
+	productFilterSearch   = "search"
+	productFilterCategory = "category"
+	productFilterPrice    = "price"
+	productFilterStock    = "stock"
+)
+
+// dateOnlyLayout は日付のみで指定する場合の形式です
+const dateOnlyLayout = "2006-01-02"
+
+// productSortColumns は並び替えに指定できる項目と対応するカラムです
+// relevance は全文検索（search）を指定した場合のみ使用できます
+var productSortColumns = map[string]string{
+	"price":      "products.price",
+	"name":       "products.name",
+	"stock":      "products.stock",
+	"created_at": "products.created_at",
+	"updated_at": "products.updated_at",
+	"relevance":  productRankExpr,
+}
+
+// skuPatternEscaper は LIKE のパターンで特別な意味を持つ文字をエスケープします
+var skuPatternEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// timeRange は日時の範囲の絞り込み条件です
+type timeRange struct {
+	from        *time.Time // この日時以降
+	to          *time.Time // この日時以前（toExclusive の場合は未満）
+	toExclusive bool       // 日付のみで指定した場合、翌日の0時未満とする
+}
+
+// productFilter は商品一覧の絞り込み条件です
+type productFilter struct {
+	activeOnly  bool
+	search      string
+	categories  []string // カテゴリーのスラッグまたは名前
+	categoryIDs []uint
+	minPrice    *float64
+	maxPrice    *float64
+	inStock     *bool
+	skuPrefix   string
+	created     timeRange
+	updated     timeRange
+}
+
+// parseProductFilter はクエリパラメータから絞り込み条件を読み取ります
+// 値が正しくない場合は INVALID_QUERY_PARAMETER のエラーを返します
+func parseProductFilter(c *gin.Context) (*productFilter, error) {
+	f := &productFilter{
+		activeOnly: c.DefaultQuery("active_only", "true") == "true",
+		search:     strings.TrimSpace(c.Query("search")),
+		skuPrefix:  strings.TrimSpace(c.Query("sku_prefix")),
+	}
+
+	// カテゴリー（category=A&category=B または category=A,B。category_id も同様）
+	for _, value := range c.QueryArray("category") {
+		for _, category := range strings.Split(value, ",") {
+			if category = strings.TrimSpace(category); category != "" {
+				f.categories = append(f.categories, category)
+			}
+		}
+	}
+	for _, value := range c.QueryArray("category_id") {
+		for _, idValue := range strings.Split(value, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(idValue), 10, 64)
+			if err != nil {
+				return nil, apperr.ErrInvalidQueryParameter.WithArgs("category_id")
+			}
+			f.categoryIDs = append(f.categoryIDs, uint(id))
+		}
+	}
+
+	// 価格帯
+	var err error
+	if f.minPrice, err = parsePriceParam(c, "min_price"); err != nil {
+		return nil, err
+	}
+	if f.maxPrice, err = parsePriceParam(c, "max_price"); err != nil {
+		return nil, err
+	}
+	if f.minPrice != nil && f.maxPrice != nil && *f.minPrice > *f.maxPrice {
+		return nil, apperr.ErrInvalidQueryParameter.WithArgs("max_price")
+	}
+
+	// 在庫の有無
+	if value := c.Query("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, apperr.ErrInvalidQueryParameter.WithArgs("in_stock")
+		}
+		f.inStock = &inStock
+	}
+
+	// 作成日時・更新日時の範囲
+	if f.created, err = parseTimeRange(c, "created"); err != nil {
+		return nil, err
+	}
+	if f.updated, err = parseTimeRange(c, "updated"); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// parsePriceParam は価格のクエリパラメータを読み取ります（指定されていない場合は nil）
+func parsePriceParam(c *gin.Context, name string) (*float64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || price < 0 {
+		return nil, apperr.ErrInvalidQueryParameter.WithArgs(name)
+	}
+	return &price, nil
+}
+
+// parseTimeRange は <prefix>_from と <prefix>_to のクエリパラメータを読み取ります
+// RFC 3339 形式の日時、または日付のみ（2024-01-31）で指定できます
+// 日付のみで指定した _to は、その日の終わりまでを含みます
+func parseTimeRange(c *gin.Context, prefix string) (timeRange, error) {
+	var r timeRange
+	for _, bound := range []string{"from", "to"} {
+		name := prefix + "_" + bound
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		dateOnly := false
+		if err != nil {
+			t, err = time.ParseInLocation(dateOnlyLayout, value, time.Local)
+			dateOnly = true
+		}
+		if err != nil {
+			return r, apperr.ErrInvalidQueryParameter.WithArgs(name)
+		}
+
+		if bound == "from" {
+			r.from = &t
+			continue
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+			r.toExclusive = true
+		}
+		r.to = &t
+	}
+
+	// 開始が終了より後の場合（日付のみの場合は同日を含む）は該当する商品がないため、指定の誤りとして扱う
+	if r.from != nil && r.to != nil && (r.from.After(*r.to) || (r.toExclusive && r.from.Equal(*r.to))) {
+		return r, apperr.ErrInvalidQueryParameter.WithArgs(prefix + "_to")
+	}
+	return r, nil
+}
+
+// apply は絞り込み条件をクエリに追加します
+// except に指定した種類の条件は追加しません（ファセットの集計と検索語の候補の取得で使用）
+func (f *productFilter) apply(query *gorm.DB, except ...string) *gorm.DB {
+	skip := make(map[string]bool, len(except))
+	for _, e := range except {
+		skip[e] = true
+	}
+
+	// アクティブな商品のみ表示（管理者以外）
+	if f.activeOnly {
+		query = query.Where("products.is_active = ?", true)
+	}
+
+	if f.search != "" && !skip[productFilterSearch] {
+		query = filterProductSearch(query, f.search)
+	}
+
+	// 指定したカテゴリーと、その子孫のカテゴリーの商品
+	if (len(f.categories) > 0 || len(f.categoryIDs) > 0) && !skip[productFilterCategory] {
+		query = query.Where(
+			"products.category_id IN ("+categorySubtree("slug IN ? OR name IN ? OR id IN ?")+")",
+			f.categories, f.categories, f.categoryIDs,
+		)
+	}
+
+	if !skip[productFilterPrice] {
+		if f.minPrice != nil {
+			query = query.Where("products.price >= ?", *f.minPrice)
+		}
+		if f.maxPrice != nil {
+			query = query.Where("products.price <= ?", *f.maxPrice)
+		}
+	}
+
+	if f.inStock != nil && !skip[productFilterStock] {
+		if *f.inStock {
+			query = query.Where("products.stock > 0")
+		} else {
+			query = query.Where("products.stock <= 0")
+		}
+	}
+
+	if f.skuPrefix != "" {
+		query = query.Where("products.sku LIKE ?", skuPatternEscaper.Replace(f.skuPrefix)+"%")
+	}
+
+	query = f.created.apply(query, "products.created_at")
+	return f.updated.apply(query, "products.updated_at")
+}
+
+// apply は日時の範囲の条件をクエリに追加します
+func (r timeRange) apply(query *gorm.DB, column string) *gorm.DB {
+	if r.from != nil {
+		query = query.Where(column+" >= ?", *r.from)
+	}
+	if r.to != nil {
+		if r.toExclusive {
+			query = query.Where(column+" < ?", *r.to)
+		} else {
+			query = query.Where(column+" <= ?", *r.to)
+		}
+	}
+	return query
+}
+
+// parseProductSort は並び替えの指定（例: sort=price,-name）を並び替えの項目に変換します
+// 先頭に - を付けた項目は降順になります。指定できる項目は productSortColumns のみです
+// 指定がない場合は、全文検索時は関連度順、それ以外は新しい順にします
+// 同じ値の商品の順序が変わらず、カーソルの位置が一意に決まるよう、最後に商品IDの降順を追加します
+func parseProductSort(value, search string) ([]pagination.Key, error) {
+	if value == "" {
+		value = "-created_at"
+		if search != "" {
+			value = "-relevance,-created_at"
+		}
+	}
+
+	var keys []pagination.Key
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		column, ok := productSortColumns[field]
+		if !ok || seen[field] || (field == "relevance" && search == "") {
+			return nil, apperr.ErrInvalidQueryParameter.WithArgs("sort")
+		}
+		seen[field] = true
+
+		key := pagination.Key{Name: field, Column: column, Desc: desc}
+		if field == "relevance" {
+			// 関連度は別名（rank）ではなく式で並び替える（カーソルの条件の WHERE で使用するため）
+			key.Vars = []interface{}{search}
+		}
+		keys = append(keys, key)
+	}
+
+	keys = append(keys, pagination.Key{Name: "id", Column: "products.id", Desc: true})
+	return keys, nil
+}
+
+// productSortValues は商品から並び替えの項目の値を取り出します（カーソルの生成に使用）
+// rank には全文検索の関連度を指定します（検索していない場合は使用しません）
+func productSortValues(keys []pagination.Key, product *models.Product, rank float64) []interface{} {
+	values := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		switch key.Name {
+		case "price":
+			values = append(values, product.Price)
+		case "name":
+			values = append(values, product.Name)
+		case "stock":
+			values = append(values, product.Stock)
+		case "created_at":
+			values = append(values, product.CreatedAt)
+		case "updated_at":
+			values = append(values, product.UpdatedAt)
+		case "relevance":
+			values = append(values, rank)
+		case "id":
+			values = append(values, product.ID)
+		}
+	}
+	return values
+}
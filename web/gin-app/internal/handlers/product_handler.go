@@ -2,13 +2,20 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
-	"strconv"
 
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/inventory"
 	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/pagination"
+	"go_learning/web/gin-app/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductHandler は商品関連のハンドラーをまとめる構造体です
@@ -21,24 +28,26 @@ func NewProductHandler(db *gorm.DB) *ProductHandler {
 	return &ProductHandler{db: db}
 }
 
-// CreateProduct は新しい商品を作成します（管理者のみ）
+// CreateProduct は新しい商品を作成します（products:write 権限が必要）
 // POST /api/v1/products
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var req models.ProductCreateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "入力値が無効です: " + err.Error(),
-		})
+		utils.RespondValidationError(c, err)
 		return
 	}
 
-	// SKUの重複チェック
-	var existingProduct models.Product
-	if err := h.db.Where("sku = ?", req.SKU).First(&existingProduct).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "このSKUは既に使用されています",
-		})
+	// SKUの重複チェック（商品とバリエーションで共通）
+	if skuTaken(h.db, req.SKU) {
+		apperr.Abort(c, apperr.ErrSKUTaken)
+		return
+	}
+
+	// カテゴリーの確認（存在するカテゴリーのみ指定できる）
+	category, err := resolveProductCategory(h.db, req.CategoryID, req.Category)
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -49,180 +58,238 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		Price:       req.Price,
 		Stock:       req.Stock,
 		SKU:         req.SKU,
-		Category:    req.Category,
 		ImageURL:    req.ImageURL,
 		IsActive:    true,
 	}
-
-	if err := h.db.Create(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "商品の作成に失敗しました",
-		})
+	product.SetCategory(category)
+
+	// 商品の作成と、初期在庫の在庫の移動の記録
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		_, err := inventory.Record(tx, inventory.Change{
+			ProductID: product.ID,
+			Delta:     product.Stock,
+			Reason:    models.StockReasonInitial,
+			Actor:     stockActor(c),
+		}, product.Stock)
+		return err
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrProductCreateFailed.WithCause(err))
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "商品を作成しました",
+		"message": i18n.T(c, "product.created"),
 		"product": product,
 	})
 }
 
 // ListProducts は商品リストを取得します（公開API）
+// カテゴリー・価格帯・在庫・SKU・日時で絞り込み、sort で並び替えられます
+// search を指定した場合は全文検索を行い、関連度と検索語を強調したテキストとともに返します
+// 検索結果がない場合は、検索語に近い商品名を suggestion として返します
+// facets=true の場合は、絞り込み条件ごとの商品数（ファセット）も返します
+// ページはカーソル（cursor）またはページ番号（page）で指定します
 // GET /api/v1/products
 func (h *ProductHandler) ListProducts(c *gin.Context) {
-	// クエリパラメータ
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	category := c.Query("category")
-	searchQuery := c.Query("search")
-	activeOnly := c.DefaultQuery("active_only", "true") == "true"
-
-	offset := (page - 1) * pageSize
-
-	// クエリの構築
-	query := h.db.Model(&models.Product{})
-
-	// アクティブな商品のみ表示（管理者以外）
-	if activeOnly {
-		query = query.Where("is_active = ?", true)
+	// 1. ページ・絞り込み条件・並び替えの読み取り
+	pageReq, err := pagination.Parse(c, pagination.DefaultPageSize, pagination.MaxPageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
 	}
-
-	// カテゴリーフィルター
-	if category != "" {
-		query = query.Where("category = ?", category)
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
 	}
-
-	// 検索フィルター（商品名と説明で検索）
-	if searchQuery != "" {
-		query = query.Where("name ILIKE ? OR description ILIKE ?",
-			"%"+searchQuery+"%", "%"+searchQuery+"%")
+	keys, err := parseProductSort(c.Query("sort"), filter.search)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
 	}
 
-	// 総数を取得
-	var total int64
-	query.Count(&total)
-
-	// 商品を取得
-	var products []models.Product
-	if err := query.Limit(pageSize).Offset(offset).Order("created_at DESC").Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "商品の取得に失敗しました",
+	// 2. 商品を取得（全文検索時は関連度と強調したテキストを含める）
+	query := filter.apply(h.db.Model(&models.Product{}))
+
+	var response gin.H
+	if filter.search != "" {
+		rows, page, err := pagination.Find(query, pageReq, keys, func(row *productSearchRow) []interface{} {
+			return productSortValues(keys, &row.Product, row.Rank)
+		}, selectProductSearch(filter.search))
+		if err != nil {
+			apperr.Abort(c, apperr.ErrProductFetchFailed.WithCause(err))
+			return
+		}
+		response = page.Fields()
+		response["products"] = productSearchResults(rows)
+
+		// 3. 検索結果がない場合は検索語の候補を返す（取得できなくても検索結果は返す）
+		if len(rows) == 0 && pageReq.FirstPage() {
+			suggestion, err := suggestProductSearch(filter.apply(h.db.Model(&models.Product{}), productFilterSearch), filter.search)
+			if err != nil {
+				log.Printf("検索語の候補の取得に失敗しました: %v", err)
+			}
+			if suggestion != "" {
+				response["suggestion"] = suggestion
+			}
+		}
+	} else {
+		products, page, err := pagination.Find(query, pageReq, keys, func(product *models.Product) []interface{} {
+			return productSortValues(keys, product, 0)
 		})
-		return
+		if err != nil {
+			apperr.Abort(c, apperr.ErrProductFetchFailed.WithCause(err))
+			return
+		}
+		response = page.Fields()
+		response["products"] = products
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"products":    products,
-		"total":       total,
-		"page":        page,
-		"page_size":   pageSize,
-		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-	})
+	// 4. ファセットの集計（指定された場合のみ）
+	if c.Query("facets") == "true" {
+		facets, err := h.productFacets(filter)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrProductFetchFailed.WithCause(err))
+			return
+		}
+		response["facets"] = facets
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
-// GetProduct は特定の商品情報を取得します
+// GetProduct は特定の商品情報を、オプションの種類・バリエーション・画像とともに取得します
 // GET /api/v1/products/:id
 func (h *ProductHandler) GetProduct(c *gin.Context) {
 	id := c.Param("id")
 
 	var product models.Product
-	if err := h.db.First(&product, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "商品が見つかりません",
-		})
+	if err := h.db.
+		Preload("OptionTypes", orderByPosition).
+		Preload("OptionTypes.Values", orderByPosition).
+		Preload("Variants", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		Preload("Variants.OptionValues", orderByOptionType).
+		Preload("Images", orderByPosition).
+		First(&product, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrProductNotFound)
 		return
 	}
 
 	c.JSON(http.StatusOK, product)
 }
 
-// UpdateProduct は商品情報を更新します（管理者のみ）
+// UpdateProduct は商品情報を更新します（products:write 権限が必要）
 // PUT /api/v1/products/:id
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
 
 	var req models.ProductUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "入力値が無効です: " + err.Error(),
-		})
+		utils.RespondValidationError(c, err)
 		return
 	}
 
+	// 同時に注文された在庫を上書きしないよう、商品の行をロックして取得し、同じトランザクションで更新する
 	var product models.Product
-	if err := h.db.First(&product, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "商品が見つかりません",
-		})
-		return
-	}
-
-	// 更新するフィールドのみ適用
-	if req.Name != "" {
-		product.Name = req.Name
-	}
-	if req.Description != "" {
-		product.Description = req.Description
-	}
-	if req.Price != nil {
-		product.Price = *req.Price
-	}
-	if req.Stock != nil {
-		product.Stock = *req.Stock
-	}
-	if req.Category != "" {
-		product.Category = req.Category
-	}
-	if req.ImageURL != "" {
-		product.ImageURL = req.ImageURL
-	}
-	if req.IsActive != nil {
-		product.IsActive = *req.IsActive
-	}
-
-	if err := h.db.Save(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "商品の更新に失敗しました",
-		})
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.ErrProductNotFound
+			}
+			return apperr.ErrProductFetchFailed.WithCause(err)
+		}
+
+		// 更新するフィールドのみ適用
+		if req.Name != "" {
+			product.Name = req.Name
+		}
+		if req.Description != "" {
+			product.Description = req.Description
+		}
+		if req.Price != nil {
+			product.Price = *req.Price
+		}
+		if req.CategoryID != nil && *req.CategoryID == 0 {
+			product.SetCategory(nil)
+		} else if req.CategoryID != nil || req.Category != "" {
+			category, err := resolveProductCategory(tx, req.CategoryID, req.Category)
+			if err != nil {
+				return err
+			}
+			product.SetCategory(category)
+		}
+		if req.ImageURL != "" {
+			product.ImageURL = req.ImageURL
+		}
+		if req.IsActive != nil {
+			product.IsActive = *req.IsActive
+		}
+
+		// 在庫を変更した場合は、差を管理者による調整として在庫の移動に記録する
+		if req.Stock != nil {
+			// バリエーションがある商品の在庫はバリエーションの在庫の合計のため、直接は更新できない
+			variants, err := hasVariants(tx, product.ID)
+			if err != nil {
+				return apperr.ErrVariantFetchFailed.WithCause(err)
+			}
+			if variants {
+				return apperr.ErrVariantStockManaged
+			}
+			if _, err := inventory.Set(tx, inventory.Change{
+				ProductID: product.ID,
+				Reason:    models.StockReasonAdjustment,
+				Actor:     stockActor(c),
+			}, *req.Stock); err != nil {
+				return err
+			}
+			product.Stock = *req.Stock
+		}
+
+		// 在庫は inventory でのみ更新する（在庫の移動に記録されない変更を防ぐ）
+		return tx.Omit("stock").Save(&product).Error
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrProductUpdateFailed)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "商品を更新しました",
+		"message": i18n.T(c, "product.updated"),
 		"product": product,
 	})
 }
 
-// DeleteProduct は商品を削除します（ソフトデリート、管理者のみ）
+// DeleteProduct は商品を削除します（ソフトデリート、products:write 権限が必要）
 // DELETE /api/v1/products/:id
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := h.db.Delete(&models.Product{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "商品の削除に失敗しました",
-		})
+		apperr.Abort(c, apperr.ErrProductDeleteFailed.WithCause(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "商品を削除しました",
+		"message": i18n.T(c, "product.deleted"),
 	})
 }
 
-// GetCategories は商品カテゴリーのリストを取得します
+// GetCategories は商品カテゴリーの名前のリストを取得します
+// 階層構造を含むカテゴリーの一覧は GET /api/v1/categories を使用してください
 // GET /api/v1/products/categories
 func (h *ProductHandler) GetCategories(c *gin.Context) {
 	var categories []string
 
-	// DISTINCT でカテゴリーを取得
-	if err := h.db.Model(&models.Product{}).
-		Distinct("category").
-		Where("category != ''").
-		Pluck("category", &categories).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "カテゴリーの取得に失敗しました",
-		})
+	// 同じ名前のカテゴリー（親が異なる場合）は1つにまとめる
+	if err := h.db.Model(&models.Category{}).
+		Distinct("name").
+		Order("name").
+		Pluck("name", &categories).Error; err != nil {
+		apperr.Abort(c, apperr.ErrCategoryFetchFailed.WithCause(err))
 		return
 	}
 
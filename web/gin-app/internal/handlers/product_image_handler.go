@@ -0,0 +1,433 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // GIF のデコードに使用
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/storage"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxImagesPerProduct は1つの商品に登録できる画像の数の上限です
+	maxImagesPerProduct = 20
+	// maxImagesPerUpload は一度にアップロードできる画像の数の上限です
+	maxImagesPerUpload = 10
+	// maxImagePixels は画像の画素数の上限です（展開後のサイズが大きすぎる画像によるメモリの枯渇を防ぐ）
+	maxImagePixels = 40_000_000
+	// multipartOverhead はリクエストのサイズの上限に加える、ファイル以外のフォームの部分のサイズです
+	multipartOverhead = 1 << 20
+	// thumbnailJPEGQuality はJPEGのサムネイルの画質です
+	thumbnailJPEGQuality = 85
+)
+
+// imageFormats はアップロードできる画像の形式（内容から判定したMIMEタイプ）と保存時の拡張子です
+var imageFormats = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ProductImageHandler は商品画像のアップロードと管理のハンドラーをまとめる構造体です
+type ProductImageHandler struct {
+	db    *gorm.DB
+	blobs storage.BlobStore
+	cfg   config.StorageConfig
+}
+
+// NewProductImageHandler は新しいProductImageHandlerを作成します
+func NewProductImageHandler(db *gorm.DB, blobs storage.BlobStore, cfg config.StorageConfig) *ProductImageHandler {
+	return &ProductImageHandler{db: db, blobs: blobs, cfg: cfg}
+}
+
+// preparedImage は検証とサムネイルの作成が完了したアップロード画像です
+type preparedImage struct {
+	data          []byte
+	contentType   string
+	ext           string
+	width         int
+	height        int
+	thumbnail     []byte
+	thumbnailType string
+	thumbnailExt  string
+}
+
+// ListImages は商品画像の一覧を表示順に取得します（公開API）
+// GET /api/v1/products/:id/images
+func (h *ProductImageHandler) ListImages(c *gin.Context) {
+	product, err := findProduct(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	images := []models.ProductImage{}
+	if err := h.db.Where("product_id = ?", product.ID).Order("position, id").Find(&images).Error; err != nil {
+		apperr.Abort(c, apperr.ErrImageFetchFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"images": images,
+	})
+}
+
+// UploadImages は商品画像をアップロードします（products:write 権限が必要）
+// multipart/form-data の image フィールドで1つ以上のファイルを送信します
+// ファイルの形式は内容から判定し、サムネイルを作成して元の画像とともに保存します
+// 最初の画像（表示順が0の画像）のURLを商品の image_url に設定します
+// POST /api/v1/products/:id/images
+func (h *ProductImageHandler) UploadImages(c *gin.Context) {
+	product, err := findProduct(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	// 1. リクエスト全体のサイズを制限してフォームを読み込む
+	maxBytes := int64(h.cfg.MaxUploadMB) << 20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes*maxImagesPerUpload+multipartOverhead)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apperr.Abort(c, apperr.ErrImageTooLarge.WithArgs(h.cfg.MaxUploadMB))
+			return
+		}
+		apperr.Abort(c, apperr.ErrImageRequired)
+		return
+	}
+
+	files := form.File["image"]
+	if len(files) == 0 {
+		apperr.Abort(c, apperr.ErrImageRequired)
+		return
+	}
+	if len(files) > maxImagesPerUpload {
+		apperr.Abort(c, apperr.ErrImageUploadLimitExceeded.WithArgs(maxImagesPerUpload))
+		return
+	}
+
+	// 2. 商品の画像の数の上限チェック（画像を処理する前に確認し、保存時にも再度確認します）
+	var count int64
+	if err := h.db.Model(&models.ProductImage{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+		apperr.Abort(c, apperr.ErrImageFetchFailed.WithCause(err))
+		return
+	}
+	if int(count)+len(files) > maxImagesPerProduct {
+		apperr.Abort(c, apperr.ErrImageLimitExceeded.WithArgs(maxImagesPerProduct))
+		return
+	}
+
+	// 3. 全てのファイルを検証してサムネイルを作成（1つでも不正な場合は何も保存しない）
+	prepared := make([]*preparedImage, 0, len(files))
+	for _, file := range files {
+		img, err := h.prepareImage(file)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		prepared = append(prepared, img)
+	}
+
+	// 4. 元の画像とサムネイルを保存
+	ctx := c.Request.Context()
+	images := make([]models.ProductImage, 0, len(prepared))
+	var keys []string
+	for _, img := range prepared {
+		name, err := utils.GenerateSecureToken(16)
+		if err != nil {
+			h.deleteBlobs(ctx, keys)
+			apperr.Abort(c, apperr.ErrImageUploadFailed.WithCause(err))
+			return
+		}
+
+		record := models.ProductImage{
+			ProductID:    product.ID,
+			Key:          fmt.Sprintf("products/%d/%s%s", product.ID, name, img.ext),
+			ThumbnailKey: fmt.Sprintf("products/%d/%s_thumb%s", product.ID, name, img.thumbnailExt),
+			ContentType:  img.contentType,
+			Size:         int64(len(img.data)),
+			Width:        img.width,
+			Height:       img.height,
+		}
+		if err := h.blobs.Put(ctx, record.Key, img.data, img.contentType); err != nil {
+			h.deleteBlobs(ctx, keys)
+			apperr.Abort(c, apperr.ErrImageUploadFailed.WithCause(err))
+			return
+		}
+		keys = append(keys, record.Key)
+		if err := h.blobs.Put(ctx, record.ThumbnailKey, img.thumbnail, img.thumbnailType); err != nil {
+			h.deleteBlobs(ctx, keys)
+			apperr.Abort(c, apperr.ErrImageUploadFailed.WithCause(err))
+			return
+		}
+		keys = append(keys, record.ThumbnailKey)
+		images = append(images, record)
+	}
+
+	// 5. 画像の情報を保存し、代表画像のURLを商品に設定（失敗した場合は保存したファイルを削除）
+	// 同時にアップロードされた画像と上限・表示順が重複しないよう、商品をロックしてから数え直す
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProductImages(tx, product.ID); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count)+len(images) > maxImagesPerProduct {
+			return apperr.ErrImageLimitExceeded.WithArgs(maxImagesPerProduct)
+		}
+		for i := range images {
+			images[i].Position = int(count) + i
+		}
+
+		if err := tx.Create(&images).Error; err != nil {
+			return err
+		}
+		return syncProductImageURL(tx, product.ID)
+	})
+	if err != nil {
+		h.deleteBlobs(ctx, keys)
+		apperr.AbortOr(c, err, apperr.ErrImageUploadFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "image.uploaded"),
+		"images":  images,
+	})
+}
+
+// ReorderImages は商品画像を並び替えます（products:write 権限が必要）
+// PUT /api/v1/products/:id/images/order
+func (h *ProductImageHandler) ReorderImages(c *gin.Context) {
+	product, err := findProduct(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	var req models.ProductImageOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 同時に追加・削除された画像と表示順が重複しないよう、商品をロックしてから画像を取得する
+	var images []models.ProductImage
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProductImages(tx, product.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Find(&images).Error; err != nil {
+			return apperr.ErrImageFetchFailed.WithCause(err)
+		}
+
+		// 1. 商品の全ての画像のIDが1回ずつ指定されているか確認
+		positions := make(map[uint]int, len(req.ImageIDs))
+		for i, id := range req.ImageIDs {
+			positions[id] = i
+		}
+		valid := len(positions) == len(req.ImageIDs) && len(positions) == len(images)
+		for _, img := range images {
+			if _, ok := positions[img.ID]; !ok {
+				valid = false
+			}
+		}
+		if !valid {
+			return apperr.ErrImageOrderInvalid
+		}
+
+		// 2. 表示順を更新し、代表画像のURLを商品に設定
+		for i := range images {
+			images[i].Position = positions[images[i].ID]
+			if err := tx.Model(&images[i]).UpdateColumn("position", images[i].Position).Error; err != nil {
+				return err
+			}
+		}
+		return syncProductImageURL(tx, product.ID)
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrImageUpdateFailed)
+		return
+	}
+
+	h.db.Where("product_id = ?", product.ID).Order("position, id").Find(&images)
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "image.reordered"),
+		"images":  images,
+	})
+}
+
+// DeleteImage は商品画像を削除します（products:write 権限が必要）
+// 後ろの画像の表示順を詰め、保存したファイル（元の画像とサムネイル）も削除します
+// DELETE /api/v1/products/:id/images/:image_id
+func (h *ProductImageHandler) DeleteImage(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrImageNotFound)
+		return
+	}
+	imageID, err := strconv.ParseUint(c.Param("image_id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrImageNotFound)
+		return
+	}
+
+	var record models.ProductImage
+	if err := h.db.Where("product_id = ?", productID).First(&record, imageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperr.Abort(c, apperr.ErrImageNotFound)
+			return
+		}
+		apperr.Abort(c, apperr.ErrImageFetchFailed.WithCause(err))
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// 同時に並び替えられた場合に備えて、商品をロックしてから表示順を取得し直す
+		if err := lockProductImages(tx, record.ProductID); err != nil {
+			return err
+		}
+		if err := tx.First(&record, record.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.ErrImageNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&record).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ProductImage{}).
+			Where("product_id = ? AND position > ?", record.ProductID, record.Position).
+			UpdateColumn("position", gorm.Expr("position - 1")).Error; err != nil {
+			return err
+		}
+		return syncProductImageURL(tx, record.ProductID)
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrImageDeleteFailed)
+		return
+	}
+
+	// ファイルの削除に失敗しても画像は表示されないため、ログに記録して処理を続ける
+	h.deleteBlobs(c.Request.Context(), []string{record.Key, record.ThumbnailKey})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "image.deleted"),
+	})
+}
+
+// prepareImage はアップロードされたファイルのサイズと形式を確認し、サムネイルを作成します
+// 形式はファイル名や Content-Type ヘッダーではなく、ファイルの内容から判定します
+func (h *ProductImageHandler) prepareImage(file *multipart.FileHeader) (*preparedImage, error) {
+	// 1. サイズの確認
+	maxBytes := int64(h.cfg.MaxUploadMB) << 20
+	if file.Size > maxBytes {
+		return nil, apperr.ErrImageTooLarge.WithArgs(h.cfg.MaxUploadMB).With("file", file.Filename)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, apperr.ErrImageUploadFailed.WithCause(err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes))
+	if err != nil {
+		return nil, apperr.ErrImageUploadFailed.WithCause(err)
+	}
+
+	// 2. 内容から形式を判定
+	contentType := http.DetectContentType(data)
+	ext, ok := imageFormats[contentType]
+	if !ok {
+		return nil, apperr.ErrImageTypeUnsupported.WithArgs(contentType).With("file", file.Filename)
+	}
+
+	// 3. 画素数の確認（デコードする前にヘッダーのみ読み込む）
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || imgConfig.Width*imgConfig.Height > maxImagePixels {
+		return nil, apperr.ErrImageInvalid.WithArgs(file.Filename).With("file", file.Filename)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperr.ErrImageInvalid.WithArgs(file.Filename).With("file", file.Filename)
+	}
+
+	// 4. サムネイルの作成（JPEG は JPEG、透過を含む可能性がある PNG・GIF は PNG で保存）
+	prepared := &preparedImage{
+		data:        data,
+		contentType: contentType,
+		ext:         ext,
+		width:       imgConfig.Width,
+		height:      imgConfig.Height,
+	}
+	var buf bytes.Buffer
+	thumbnail := utils.Thumbnail(img, h.cfg.ThumbnailSize)
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: thumbnailJPEGQuality})
+		prepared.thumbnailType, prepared.thumbnailExt = "image/jpeg", ".jpg"
+	} else {
+		err = png.Encode(&buf, thumbnail)
+		prepared.thumbnailType, prepared.thumbnailExt = "image/png", ".png"
+	}
+	if err != nil {
+		return nil, apperr.ErrImageUploadFailed.WithCause(err)
+	}
+	prepared.thumbnail = buf.Bytes()
+	return prepared, nil
+}
+
+// deleteBlobs は保存したファイルを削除します（失敗した場合はログに記録します）
+func (h *ProductImageHandler) deleteBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := h.blobs.Delete(ctx, key); err != nil {
+			log.Printf("商品画像のファイルの削除に失敗しました (key=%s): %v", key, err)
+		}
+	}
+}
+
+// lockProductImages は商品の行をロックし、商品画像の追加・並び替え・削除が同時に行われないようにします
+// 画像の数の上限と表示順は、ロックした後に取得した画像から決めてください
+func lockProductImages(tx *gorm.DB, productID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Product{}, productID).Error
+}
+
+// syncProductImageURL は商品の image_url を代表画像（表示順が最初の画像）のURLにします
+// 画像がなくなった場合は、アップロードした画像のURLが設定されている場合のみ image_url を空にします
+func syncProductImageURL(tx *gorm.DB, productID uint) error {
+	var first models.ProductImage
+	err := tx.Where("product_id = ?", productID).Order("position, id").Take(&first).Error
+	switch {
+	case err == nil:
+		return tx.Model(&models.Product{}).Where("id = ?", productID).UpdateColumn("image_url", first.URL).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return tx.Model(&models.Product{}).
+			Where("id = ? AND image_url LIKE ?", productID, models.MediaPathPrefix+"%").
+			UpdateColumn("image_url", "").Error
+	default:
+		return err
+	}
+}
@@ -0,0 +1,554 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/inventory"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// インポート・エクスポートのファイルの形式
+const (
+	productFormatCSV    = "csv"
+	productFormatNDJSON = "ndjson"
+)
+
+const (
+	// productImportMaxMB はインポートできるファイルの最大サイズ（MB）です
+	productImportMaxMB = 32
+	// productImportMaxLineBytes は NDJSON の1行の最大サイズです
+	productImportMaxLineBytes = 1 << 20
+	// productImportMaxErrors はレスポンスに含めるエラーの行の最大数です（件数の集計は全ての行で行います）
+	productImportMaxErrors = 1000
+	// productImportTimeout はインポートのリクエストの読み込みとレスポンスの書き込みのタイムアウトです
+	// 大きなファイルの読み込みと登録に時間がかかるため、サーバー全体のタイムアウトより長くします
+	productImportTimeout = 5 * time.Minute
+)
+
+// productFormatTypes は Content-Type に対応するファイルの形式です
+var productFormatTypes = map[string]string{
+	"text/csv":             productFormatCSV,
+	"application/csv":      productFormatCSV,
+	"application/x-ndjson": productFormatNDJSON,
+	"application/ndjson":   productFormatNDJSON,
+	"application/jsonl":    productFormatNDJSON,
+}
+
+// productFormatExtensions はファイルの拡張子に対応するファイルの形式です
+var productFormatExtensions = map[string]string{
+	".csv":    productFormatCSV,
+	".ndjson": productFormatNDJSON,
+	".jsonl":  productFormatNDJSON,
+}
+
+// utf8BOM は表計算ソフトがCSVの先頭に付けることがあるバイト順マークです
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// errImportDryRun は dry_run の場合にトランザクションをロールバックするためのエラーです
+var errImportDryRun = errors.New("dry run")
+
+// productImportRecord はインポートするファイルの1行です
+type productImportRecord struct {
+	line     int
+	req      models.ProductCreateRequest
+	stockSet bool             // stock の値がある（在庫切れの 0 を含む）
+	err      *apperr.AppError // 行の読み取りに失敗した場合のエラー（ファイルの読み込みは続ける）
+}
+
+// productImportReader はインポートするファイルを1行ずつ読み取ります
+// ファイル自体を読み込めない場合はエラーを返し、ファイルの終わりでは io.EOF を返します
+type productImportReader interface {
+	next() (*productImportRecord, error)
+}
+
+// ImportProducts はCSVまたはNDJSON（1行に1つのJSON）のファイルから商品を一括で登録します（products:write 権限が必要）
+// ファイルはリクエストボディ、または multipart/form-data の file フィールドで送信します
+// 各行は商品作成と同じルールで検証し、SKUが一致する商品は更新、それ以外は作成します
+// エラーの行はスキップし、行番号とエラーの内容を返します
+// dry_run=true の場合は、データベースを変更せずに結果のみを返します
+// POST /api/v1/products/import
+func (h *ProductHandler) ImportProducts(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+	locale := i18n.Locale(c)
+
+	// 1. ファイルと形式の決定
+	deadline := time.Now().Add(productImportTimeout)
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.SetReadDeadline(deadline) // 設定できない場合はサーバー全体のタイムアウトのまま処理する
+	_ = rc.SetWriteDeadline(deadline)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, productImportMaxMB<<20)
+	body, format, err := productImportFile(c)
+	if err != nil {
+		apperr.Abort(c, productImportError(err))
+		return
+	}
+
+	var reader productImportReader
+	if format == productFormatCSV {
+		reader, err = newCSVProductReader(body, locale)
+	} else {
+		reader = newNDJSONProductReader(body, locale)
+	}
+	if err != nil {
+		apperr.Abort(c, productImportError(err))
+		return
+	}
+
+	// 2. 1行ずつ検証して登録（全ての行を1つのトランザクションで処理し、dry_run の場合はロールバック）
+	result := models.ProductImportResult{DryRun: dryRun, Errors: []models.ProductImportRowError{}}
+	seen := make(map[string]int) // SKUと最初に出現した行番号
+	actor := stockActor(c)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		for {
+			record, err := reader.next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			result.Rows++
+
+			rowErr := record.err
+			if rowErr == nil {
+				if first, ok := seen[record.req.SKU]; ok {
+					rowErr = apperr.ErrImportDuplicateSKU.WithArgs(first)
+				} else {
+					seen[record.req.SKU] = record.line
+				}
+			}
+			if rowErr == nil {
+				created, err := importProduct(tx, &record.req, actor)
+				if err != nil {
+					// 行の内容によるエラー以外（データベースのエラー等）はインポート全体を中止する
+					if appErr := apperr.From(err); appErr.Status < http.StatusInternalServerError {
+						rowErr = appErr
+					} else {
+						return err
+					}
+				} else if created {
+					result.Created++
+				} else {
+					result.Updated++
+				}
+			}
+
+			if rowErr != nil {
+				addImportError(&result, record, rowErr, locale)
+			}
+		}
+
+		if dryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		apperr.Abort(c, productImportError(err))
+		return
+	}
+
+	message := i18n.T(c, "import.completed", result.Rows, result.Created, result.Updated, result.Failed)
+	if dryRun {
+		message = i18n.T(c, "import.validated", result.Rows, result.Created, result.Updated, result.Failed)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"result":  result,
+	})
+}
+
+// addImportError はエラーの行を結果に追加します
+// エラーの行が productImportMaxErrors を超えた場合は、件数のみを数えます
+func addImportError(result *models.ProductImportResult, record *productImportRecord, err *apperr.AppError, locale string) {
+	result.Failed++
+	if len(result.Errors) >= productImportMaxErrors {
+		result.ErrorsTruncated = true
+		return
+	}
+
+	rowErr := models.ProductImportRowError{
+		Line:    record.line,
+		SKU:     record.req.SKU,
+		Code:    err.Code,
+		Message: err.Message(locale),
+	}
+	if fields, ok := err.Details.([]utils.FieldError); ok {
+		rowErr.Errors = fields
+	}
+	result.Errors = append(result.Errors, rowErr)
+}
+
+// importProduct は1行の商品を登録します。作成した場合は true を返します
+// SKUが一致する商品がある場合は、商品更新と同様に、空でない項目のみを更新します
+// 在庫は初期在庫または管理者による調整として在庫の移動に記録します
+func importProduct(tx *gorm.DB, req *models.ProductCreateRequest, actor inventory.Actor) (bool, error) {
+	// 同時に注文された在庫を上書きしないよう、既存の商品の行をロックして取得する
+	var product models.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("sku = ?", req.SKU).First(&product).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, apperr.ErrProductFetchFailed.WithCause(err)
+	}
+	exists := err == nil
+
+	// 1. 新しい商品のSKUの重複チェック（削除済みの商品とバリエーションを含む）
+	if !exists && skuTaken(tx, req.SKU) {
+		return false, apperr.ErrSKUTaken
+	}
+
+	// 2. カテゴリーの確認（存在するカテゴリーのみ指定できる）
+	category, err := resolveProductCategory(tx, req.CategoryID, req.Category)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		product = models.Product{
+			Name:        req.Name,
+			Description: req.Description,
+			Price:       req.Price,
+			Stock:       req.Stock,
+			SKU:         req.SKU,
+			ImageURL:    req.ImageURL,
+			IsActive:    true,
+		}
+		product.SetCategory(category)
+
+		if err := tx.Create(&product).Error; err != nil {
+			return false, apperr.ErrProductCreateFailed.WithCause(err)
+		}
+		if _, err := inventory.Record(tx, inventory.Change{
+			ProductID: product.ID,
+			Delta:     product.Stock,
+			Reason:    models.StockReasonInitial,
+			Actor:     actor,
+		}, product.Stock); err != nil {
+			return false, apperr.ErrProductCreateFailed.WithCause(err)
+		}
+		return true, nil
+	}
+
+	// 3. 既存の商品の更新
+	product.Name = req.Name
+	product.Price = req.Price
+	if req.Description != "" {
+		product.Description = req.Description
+	}
+	if req.ImageURL != "" {
+		product.ImageURL = req.ImageURL
+	}
+	if category != nil {
+		product.SetCategory(category)
+	}
+	if req.Stock != product.Stock {
+		// バリエーションがある商品の在庫はバリエーションの在庫の合計のため、直接は更新できない
+		variants, err := hasVariants(tx, product.ID)
+		if err != nil {
+			return false, apperr.ErrVariantFetchFailed.WithCause(err)
+		}
+		if variants {
+			return false, apperr.ErrVariantStockManaged
+		}
+		if _, err := inventory.Set(tx, inventory.Change{
+			ProductID: product.ID,
+			Reason:    models.StockReasonAdjustment,
+			Actor:     actor,
+			Note:      "商品のインポート",
+		}, req.Stock); err != nil {
+			return false, apperr.ErrProductUpdateFailed.WithCause(err)
+		}
+		product.Stock = req.Stock
+	}
+
+	// 在庫は inventory でのみ更新する（在庫の移動に記録されない変更を防ぐ）
+	if err := tx.Omit("stock").Save(&product).Error; err != nil {
+		return false, apperr.ErrProductUpdateFailed.WithCause(err)
+	}
+	return false, nil
+}
+
+// productImportFile はインポートするファイルとその形式を返します
+// 形式は format クエリパラメータ、Content-Type、ファイル名の拡張子の順に決定します
+// multipart/form-data の場合は、ファイル全体を読み込まずに file フィールドを読み取ります
+func productImportFile(c *gin.Context) (io.Reader, string, error) {
+	var body io.Reader = c.Request.Body
+	contentType := c.ContentType()
+	filename := ""
+
+	if contentType == binding.MIMEMultipartPOSTForm {
+		mr, err := c.Request.MultipartReader()
+		if err != nil {
+			return nil, "", apperr.ErrImportFileRequired
+		}
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				return nil, "", apperr.ErrImportFileRequired.WithCause(err)
+			}
+			if part.FormName() == "file" {
+				body = part
+				contentType = strings.TrimSpace(strings.SplitN(part.Header.Get("Content-Type"), ";", 2)[0])
+				filename = part.FileName()
+				break
+			}
+		}
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = productFormatTypes[contentType]
+	}
+	if format == "" {
+		format = productFormatExtensions[strings.ToLower(path.Ext(filename))]
+	}
+	if format != productFormatCSV && format != productFormatNDJSON {
+		return nil, "", apperr.ErrImportFormatUnsupported
+	}
+	return body, format, nil
+}
+
+// productImportError はインポートのエラーをクライアントに返すエラーに変換します
+func productImportError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperr.ErrImportTooLarge.WithArgs(productImportMaxMB)
+	}
+	var appErr *apperr.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperr.ErrImportFailed.WithCause(err)
+}
+
+// productValidationError は行の読み取りと検証のエラーを、フィールド単位のエラーを含む行のエラーに変換します
+func productValidationError(err error, locale string) *apperr.AppError {
+	return apperr.ErrValidation.WithCause(err).WithDetails(utils.ValidationErrors(err, locale))
+}
+
+// validateProductRecord は行の内容を商品作成と同じルールで検証します
+// ただし stock は値があれば 0 も有効とします（エクスポートした在庫切れの商品をそのままインポートできるようにする）
+func validateProductRecord(record *productImportRecord, locale string) {
+	if record.err != nil {
+		return
+	}
+	err := binding.Validator.ValidateStruct(&record.req)
+	var validationErrs validator.ValidationErrors
+	if record.stockSet && errors.As(err, &validationErrs) {
+		remaining := make(validator.ValidationErrors, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			if fe.StructField() == "Stock" && fe.Tag() == "required" {
+				continue
+			}
+			remaining = append(remaining, fe)
+		}
+		if len(remaining) == 0 {
+			return
+		}
+		err = remaining
+	}
+	if err != nil {
+		record.err = productValidationError(err, locale)
+	}
+}
+
+// csvProductReader はCSVのファイルを読み取ります
+// 1行目はヘッダー（列名）で、列名は商品作成のリクエストボディのキー（sku, name, price 等）です
+// それ以外の列（エクスポートした id, is_active 等）は無視します
+type csvProductReader struct {
+	r       *csv.Reader
+	columns []string // 列ごとのキー（無視する列は空）
+	locale  string
+}
+
+// newCSVProductReader はヘッダーを読み込み、新しいcsvProductReaderを作成します
+func newCSVProductReader(body io.Reader, locale string) (*csvProductReader, error) {
+	r := csv.NewReader(body)
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, apperr.ErrImportFileRequired
+	}
+	if err != nil {
+		return nil, csvReadError(err)
+	}
+
+	columns := make([]string, len(header))
+	hasSKU := false
+	for i, name := range header {
+		if i == 0 {
+			name = string(bytes.TrimPrefix([]byte(name), utf8BOM))
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "sku", "name", "description", "price", "stock", "category_id", "category", "image_url":
+			columns[i] = name
+		}
+		hasSKU = hasSKU || name == "sku"
+	}
+	if !hasSKU {
+		return nil, apperr.ErrImportHeaderInvalid.WithArgs("sku")
+	}
+
+	return &csvProductReader{r: r, columns: columns, locale: locale}, nil
+}
+
+// next は次の行を読み取ります
+// 列の数がヘッダーと異なる行は、行のエラーとして扱います
+func (cr *csvProductReader) next() (*productImportRecord, error) {
+	fields, err := cr.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+
+	record := &productImportRecord{}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+		record.line = parseErr.StartLine
+		record.err = apperr.ErrImportColumnCount.WithArgs(len(fields), len(cr.columns))
+		return record, nil
+	}
+	if err != nil {
+		return nil, csvReadError(err)
+	}
+	record.line, _ = cr.r.FieldPos(0)
+
+	// 列の値をリクエストボディの項目に変換（数値に変換できない列はフィールド単位のエラーにする）
+	var fieldErrs []utils.FieldError
+	for i, value := range fields {
+		value = strings.TrimSpace(value)
+		var typeName string
+		switch cr.columns[i] {
+		case "sku":
+			record.req.SKU = value
+		case "name":
+			record.req.Name = value
+		case "description":
+			record.req.Description = value
+		case "category":
+			record.req.Category = value
+		case "image_url":
+			record.req.ImageURL = value
+		case "price":
+			if value != "" {
+				price, err := strconv.ParseFloat(value, 64)
+				record.req.Price = price
+				if err != nil {
+					typeName = "validation.type_name.number"
+				}
+			}
+		case "stock":
+			if value != "" {
+				stock, err := strconv.Atoi(value)
+				record.req.Stock = stock
+				record.stockSet = err == nil
+				if err != nil {
+					typeName = "validation.type_name.integer"
+				}
+			}
+		case "category_id":
+			if value != "" {
+				id, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					typeName = "validation.type_name.integer"
+				}
+				categoryID := uint(id)
+				record.req.CategoryID = &categoryID
+			}
+		}
+		if typeName != "" {
+			fieldErrs = append(fieldErrs, utils.FieldError{
+				Field:   cr.columns[i],
+				Rule:    "type",
+				Message: i18n.Translate(cr.locale, "validation.type", i18n.Translate(cr.locale, typeName)),
+			})
+		}
+	}
+	if len(fieldErrs) > 0 {
+		record.err = apperr.ErrValidation.WithDetails(fieldErrs)
+	}
+
+	validateProductRecord(record, cr.locale)
+	return record, nil
+}
+
+// csvReadError はCSVの読み込みのエラーを、行番号を含むエラーに変換します
+func csvReadError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return apperr.ErrImportFileInvalid.WithArgs(parseErr.Line).WithCause(err)
+	}
+	return err
+}
+
+// ndjsonProductReader はNDJSON（1行に1つのJSONオブジェクト）のファイルを読み取ります
+// 各行のキーは商品作成のリクエストボディと同じです。空行は無視します
+type ndjsonProductReader struct {
+	scanner *bufio.Scanner
+	line    int
+	locale  string
+}
+
+// newNDJSONProductReader は新しいndjsonProductReaderを作成します
+func newNDJSONProductReader(body io.Reader, locale string) *ndjsonProductReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), productImportMaxLineBytes)
+	return &ndjsonProductReader{scanner: scanner, locale: locale}
+}
+
+// next は次の行を読み取ります
+// JSONとして読み取れない行は、行のエラーとして扱います
+func (nr *ndjsonProductReader) next() (*productImportRecord, error) {
+	for nr.scanner.Scan() {
+		nr.line++
+		data := nr.scanner.Bytes()
+		if nr.line == 1 {
+			data = bytes.TrimPrefix(data, utf8BOM)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		record := &productImportRecord{line: nr.line}
+		if err := json.Unmarshal(data, &record.req); err != nil {
+			record.err = productValidationError(err, nr.locale)
+		} else {
+			var present struct {
+				Stock *int `json:"stock"`
+			}
+			_ = json.Unmarshal(data, &present) // 同じ行を読み取れたため失敗しない
+			record.stockSet = present.Stock != nil
+		}
+		validateProductRecord(record, nr.locale)
+		return record, nil
+	}
+
+	if err := nr.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, apperr.ErrImportFileInvalid.WithArgs(nr.line + 1).WithCause(err)
+		}
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+)
+
+func TestMain(m *testing.M) {
+	if err := utils.RegisterValidators(func(string) []i18n.Message { return nil }); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// TestProductExportImportRoundTrip はエクスポートしたファイルをそのままインポートできることを確認します
+func TestProductExportImportRoundTrip(t *testing.T) {
+	categoryID := uint(3)
+	products := []models.Product{
+		{ID: 1, SKU: "TS-BLK-M", Name: "Tシャツ", Description: "綿100%", Price: 1980, Stock: 12,
+			CategoryID: &categoryID, Category: "衣類", IsActive: true},
+		{ID: 2, SKU: "MUG-01", Name: "マグカップ", Price: 800, Stock: 0, IsActive: false}, // 在庫切れ
+	}
+	for i := range products {
+		products[i].CreatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		products[i].UpdatedAt = products[i].CreatedAt
+	}
+
+	tests := []struct {
+		name   string
+		writer func(w io.Writer) productExportWriter
+		reader func(r io.Reader) (productImportReader, error)
+	}{
+		{
+			name:   productFormatCSV,
+			writer: func(w io.Writer) productExportWriter { return &csvProductWriter{w: csv.NewWriter(w)} },
+			reader: func(r io.Reader) (productImportReader, error) { return newCSVProductReader(r, "ja") },
+		},
+		{
+			name:   productFormatNDJSON,
+			writer: func(w io.Writer) productExportWriter { return &ndjsonProductWriter{enc: json.NewEncoder(w)} },
+			reader: func(r io.Reader) (productImportReader, error) { return newNDJSONProductReader(r, "ja"), nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := tt.writer(&buf)
+			if err := w.writeHeader(); err != nil {
+				t.Fatal(err)
+			}
+			for i := range products {
+				if err := w.write(&products[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := tt.reader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range products {
+				record, err := r.next()
+				if err != nil {
+					t.Fatalf("%d行目: %v", i+1, err)
+				}
+				if record.err != nil {
+					t.Fatalf("%s: 行のエラー %v %v", want.SKU, record.err, record.err.Details)
+				}
+				got := record.req
+				if got.SKU != want.SKU || got.Name != want.Name || got.Description != want.Description ||
+					got.Price != want.Price || got.Stock != want.Stock || got.Category != want.Category {
+					t.Errorf("%s: got %+v", want.SKU, got)
+				}
+				if (got.CategoryID == nil) != (want.CategoryID == nil) ||
+					(got.CategoryID != nil && *got.CategoryID != *want.CategoryID) {
+					t.Errorf("%s: category_id got %v, want %v", want.SKU, got.CategoryID, want.CategoryID)
+				}
+			}
+			if _, err := r.next(); !errors.Is(err, io.EOF) {
+				t.Errorf("最後の行の後: got %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+// TestProductImportStockRequired は stock の列・キーがない行をエラーにすることを確認します
+func TestProductImportStockRequired(t *testing.T) {
+	tests := []struct {
+		name   string
+		reader func() (productImportReader, error)
+	}{
+		{
+			name: "csv_empty",
+			reader: func() (productImportReader, error) {
+				return newCSVProductReader(bytes.NewBufferString("sku,name,price,stock\nA-1,商品,100,\n"), "ja")
+			},
+		},
+		{
+			name: "ndjson_missing",
+			reader: func() (productImportReader, error) {
+				return newNDJSONProductReader(bytes.NewBufferString(`{"sku":"A-1","name":"商品","price":100}`+"\n"), "ja"), nil
+			},
+		},
+		{
+			name: "ndjson_null",
+			reader: func() (productImportReader, error) {
+				return newNDJSONProductReader(bytes.NewBufferString(`{"sku":"A-1","name":"商品","price":100,"stock":null}`+"\n"), "ja"), nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tt.reader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			record, err := r.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.err == nil {
+				t.Fatal("stock がない行がエラーになっていません")
+			}
+			fields, _ := record.err.Details.([]utils.FieldError)
+			if len(fields) != 1 || fields[0].Field != "stock" || fields[0].Rule != "required" {
+				t.Errorf("details: got %+v", record.err.Details)
+			}
+		})
+	}
+}
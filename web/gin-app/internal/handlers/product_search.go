@@ -0,0 +1,109 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"html"
+	"strings"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 検索語を強調する範囲の目印
+// ts_headline の結果をエスケープしてから <mark> タグに置き換えるため、
+// 商品名や説明に含まれない私用領域の文字を使用します
+const (
+	highlightStart = "\ue000"
+	highlightStop  = "\ue001"
+)
+
+// 検索語の強調の設定（ts_headline のオプション）
+var (
+	// 商品名は全体を返す
+	nameHeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	// 説明は検索語を含む部分を最大2か所抜粋する
+	descriptionHeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop +
+		", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+)
+
+// tsQuery は検索語を tsquery に変換するSQLです
+// websearch_to_tsquery は "完全一致"、-除外、OR を解釈し、構文エラーになりません
+const tsQuery = "websearch_to_tsquery('" + models.ProductSearchConfig + "', ?)"
+
+// productRankExpr は検索語に対する商品の関連度を計算するSQLです
+const productRankExpr = "ts_rank(products.search_vector, " + tsQuery + ")"
+
+// productSearchRow は検索結果の1行です（関連度と強調したテキストを含む）
+type productSearchRow struct {
+	models.Product
+	Rank                 float64
+	NameHighlight        string
+	DescriptionHighlight string
+}
+
+// filterProductSearch は全文検索の条件を追加します
+// 検索用のカラム（search_vector）の GIN インデックスを使用します
+func filterProductSearch(query *gorm.DB, search string) *gorm.DB {
+	return query.Where("products.search_vector @@ "+tsQuery, search)
+}
+
+// selectProductSearch は商品とともに関連度と強調したテキストを取得するスコープです
+// ページネーションで総数を集計する場合は適用されません
+func selectProductSearch(search string) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		return query.Select(
+			"products.*, "+
+				productRankExpr+" AS rank, "+
+				"ts_headline('"+models.ProductSearchConfig+"', products.name, "+tsQuery+", ?) AS name_highlight, "+
+				"ts_headline('"+models.ProductSearchConfig+"', coalesce(products.description, ''), "+tsQuery+", ?) AS description_highlight",
+			search, search, nameHeadlineOptions, search, descriptionHeadlineOptions,
+		)
+	}
+}
+
+// productSearchResults は検索結果の行をレスポンスの形式に変換します
+func productSearchResults(rows []productSearchRow) []models.ProductSearchResult {
+	results := make([]models.ProductSearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, models.ProductSearchResult{
+			Product: row.Product,
+			Rank:    row.Rank,
+			Highlight: &models.ProductHighlight{
+				Name:        highlightHTML(row.NameHighlight),
+				Description: highlightHTML(row.DescriptionHighlight),
+			},
+		})
+	}
+	return results
+}
+
+// suggestProductSearch は検索結果がない場合に、検索語に近い商品名を返します（もしかして）
+// 商品名に対する単語単位の類似度（pg_trgm）を使用するため、入力ミスや表記の揺れに対応できます
+// 類似度が pg_trgm.word_similarity_threshold（デフォルト 0.6）以上の商品名のみを候補とし、
+// 商品名のトライグラムの GIN インデックスを使用します。候補がない場合は空文字を返します
+func suggestProductSearch(query *gorm.DB, search string) (string, error) {
+	var names []string
+	err := query.
+		Where("? <% products.name", search).
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "word_similarity(?, products.name) DESC",
+			Vars:               []interface{}{search},
+			WithoutParentheses: true,
+		}}).
+		Limit(1).
+		Pluck("products.name", &names).Error
+	if err != nil || len(names) == 0 {
+		return "", err
+	}
+	return names[0], nil
+}
+
+// highlightHTML は ts_headline の結果をHTMLとして安全に表示できる形式に変換します
+// 商品名や説明に含まれるHTMLはエスケープし、検索語の目印のみ <mark> タグにします
+func highlightHTML(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
@@ -0,0 +1,368 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/inventory"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// variantTitleSeparator はバリエーション名でオプションの値を区切る文字列です（例: Red / M）
+const variantTitleSeparator = " / "
+
+// ListVariants は商品のオプションの種類とバリエーションの一覧を取得します（公開API）
+// GET /api/v1/products/:id/variants
+func (h *ProductHandler) ListVariants(c *gin.Context) {
+	product, err := findProduct(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	optionTypes := []models.OptionType{}
+	if err := preloadOptionTypes(h.db).Where("product_id = ?", product.ID).Find(&optionTypes).Error; err != nil {
+		apperr.Abort(c, apperr.ErrVariantFetchFailed.WithCause(err))
+		return
+	}
+
+	variants := []models.ProductVariant{}
+	if err := preloadVariants(h.db).Where("product_id = ?", product.ID).Find(&variants).Error; err != nil {
+		apperr.Abort(c, apperr.ErrVariantFetchFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"option_types": optionTypes,
+		"variants":     variants,
+	})
+}
+
+// CreateVariant は商品のバリエーションを作成します（products:write 権限が必要）
+// 商品の最初のバリエーションで指定したオプションが、その商品のオプションの種類になります
+// 以降のバリエーションでは、全てのオプションの種類に1つずつ値を指定してください
+// POST /api/v1/products/:id/variants
+func (h *ProductHandler) CreateVariant(c *gin.Context) {
+	product, err := findProduct(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	var req models.ProductVariantCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 1. SKUの重複チェック（商品とバリエーションで共通）
+	if skuTaken(h.db, req.SKU) {
+		apperr.Abort(c, apperr.ErrSKUTaken)
+		return
+	}
+
+	variant := models.ProductVariant{
+		ProductID: product.ID,
+		SKU:       req.SKU,
+		Price:     req.Price,
+		Stock:     req.Stock,
+		IsActive:  true,
+	}
+
+	// 2. オプションの値を取得・作成し、バリエーションを作成（商品の在庫も更新）
+	actor := stockActor(c)
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// 最初のバリエーションの場合は、それまでの商品の在庫を在庫の移動で取り消す
+		if err := inventory.DetachProductStock(tx, product.ID, actor); err != nil {
+			return err
+		}
+
+		values, err := variantOptionValues(tx, product.ID, req.Options)
+		if err != nil {
+			return err
+		}
+		variant.OptionValues = values
+		variant.Title, variant.OptionKey = variantTitle(values)
+
+		// 同じオプションの組み合わせのバリエーションは作成できない
+		var count int64
+		if err := tx.Model(&models.ProductVariant{}).
+			Where("product_id = ? AND option_key = ?", product.ID, variant.OptionKey).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return apperr.ErrVariantOptionsTaken
+		}
+
+		if err := tx.Create(&variant).Error; err != nil {
+			return err
+		}
+		if _, err := inventory.Record(tx, inventory.Change{
+			ProductID: product.ID,
+			VariantID: &variant.ID,
+			Delta:     variant.Stock,
+			Reason:    models.StockReasonInitial,
+			Actor:     actor,
+		}, variant.Stock); err != nil {
+			return err
+		}
+		return inventory.SyncProductStock(tx, product.ID)
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrVariantCreateFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "variant.created"),
+		"variant": variant,
+	})
+}
+
+// UpdateVariant はバリエーションの価格・在庫・販売状態を更新します（products:write 権限が必要）
+// price に 0 を指定した場合は商品の価格に戻します
+// PUT /api/v1/products/:id/variants/:variant_id
+func (h *ProductHandler) UpdateVariant(c *gin.Context) {
+	variant, err := h.findVariant(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	var req models.ProductVariantUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 更新するフィールドのみ適用
+	if req.Price != nil {
+		variant.Price = req.Price
+		if *req.Price == 0 {
+			variant.Price = nil
+		}
+	}
+	if req.Stock != nil {
+		variant.Stock = *req.Stock
+	}
+	if req.IsActive != nil {
+		variant.IsActive = *req.IsActive
+	}
+
+	// 在庫を変更した場合は、差を管理者による調整として在庫の移動に記録する（商品の在庫も更新）
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if req.Stock != nil {
+			if _, err := inventory.Set(tx, inventory.Change{
+				ProductID: variant.ProductID,
+				VariantID: &variant.ID,
+				Reason:    models.StockReasonAdjustment,
+				Actor:     stockActor(c),
+			}, *req.Stock); err != nil {
+				return err
+			}
+		}
+		return tx.Save(variant).Error
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrVariantUpdateFailed.WithCause(err))
+		return
+	}
+
+	preloadVariants(h.db).First(variant, variant.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "variant.updated"),
+		"variant": variant,
+	})
+}
+
+// DeleteVariant はバリエーションを削除します（ソフトデリート、products:write 権限が必要）
+// バリエーションの在庫は調整として0にし、商品の在庫は残りのバリエーションの在庫の合計になります
+// DELETE /api/v1/products/:id/variants/:variant_id
+func (h *ProductHandler) DeleteVariant(c *gin.Context) {
+	variant, err := h.findVariant(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := inventory.Set(tx, inventory.Change{
+			ProductID: variant.ProductID,
+			VariantID: &variant.ID,
+			Reason:    models.StockReasonAdjustment,
+			Actor:     stockActor(c),
+			Note:      "バリエーションの削除",
+		}, 0); err != nil {
+			return err
+		}
+		if err := tx.Delete(variant).Error; err != nil {
+			return err
+		}
+		return inventory.SyncProductStock(tx, variant.ProductID)
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrVariantDeleteFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "variant.deleted"),
+	})
+}
+
+// findProduct はIDで商品を取得します
+func findProduct(db *gorm.DB, id string) (*models.Product, error) {
+	productID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, apperr.ErrProductNotFound
+	}
+
+	var product models.Product
+	if err := db.First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrProductNotFound
+		}
+		return nil, apperr.ErrProductFetchFailed.WithCause(err)
+	}
+	return &product, nil
+}
+
+// findVariant はパスの :id の商品の :variant_id のバリエーションを取得します
+func (h *ProductHandler) findVariant(c *gin.Context) (*models.ProductVariant, error) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, apperr.ErrVariantNotFound
+	}
+	variantID, err := strconv.ParseUint(c.Param("variant_id"), 10, 64)
+	if err != nil {
+		return nil, apperr.ErrVariantNotFound
+	}
+
+	var variant models.ProductVariant
+	if err := h.db.Where("product_id = ?", productID).First(&variant, variantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperr.ErrVariantNotFound
+		}
+		return nil, apperr.ErrVariantFetchFailed.WithCause(err)
+	}
+	return &variant, nil
+}
+
+// orderByPosition はオプションの種類・値や商品画像を表示順（position）に並べます
+func orderByPosition(tx *gorm.DB) *gorm.DB {
+	return tx.Order("position, id")
+}
+
+// orderByOptionType はバリエーションのオプションの値を、オプションの種類の作成順（表示順）に並べます
+func orderByOptionType(tx *gorm.DB) *gorm.DB {
+	return tx.Order("option_values.option_type_id")
+}
+
+// preloadOptionTypes はオプションの種類を値とともに表示順で取得するクエリを返します
+func preloadOptionTypes(db *gorm.DB) *gorm.DB {
+	return orderByPosition(db).Preload("Values", orderByPosition)
+}
+
+// preloadVariants はバリエーションをオプションの値とともに作成順で取得するクエリを返します
+func preloadVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("id").Preload("OptionValues", orderByOptionType)
+}
+
+// skuTaken はSKUが商品またはバリエーション（削除済みを含む）で使用されているかを返します
+func skuTaken(db *gorm.DB, sku string) bool {
+	var products, variants int64
+	db.Unscoped().Model(&models.Product{}).Where("sku = ?", sku).Count(&products)
+	db.Unscoped().Model(&models.ProductVariant{}).Where("sku = ?", sku).Count(&variants)
+	return products > 0 || variants > 0
+}
+
+// hasVariants は商品にバリエーションがあるかを返します
+func hasVariants(db *gorm.DB, productID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.ProductVariant{}).Where("product_id = ?", productID).Count(&count).Error
+	return count > 0, err
+}
+
+// variantOptionValues はバリエーションに指定されたオプションの値を、オプションの種類の表示順に返します
+// 商品にオプションの種類がまだない場合は指定された順に作成し、存在しない値は作成します
+func variantOptionValues(tx *gorm.DB, productID uint, options []models.VariantOptionRequest) ([]models.OptionValue, error) {
+	// 1. 指定されたオプションを名前ごとにまとめる（同じ名前は1回のみ指定できる）
+	requested := make(map[string]string, len(options))
+	names := make([]string, 0, len(options))
+	for _, option := range options {
+		name, value := strings.TrimSpace(option.Name), strings.TrimSpace(option.Value)
+		if _, duplicated := requested[name]; duplicated || name == "" || value == "" {
+			return nil, apperr.ErrVariantOptionsInvalid.WithArgs(strings.Join(names, ", "))
+		}
+		requested[name] = value
+		names = append(names, name)
+	}
+
+	// 2. 商品のオプションの種類を取得（最初のバリエーションの場合は作成）
+	var optionTypes []models.OptionType
+	if err := preloadOptionTypes(tx).Where("product_id = ?", productID).Find(&optionTypes).Error; err != nil {
+		return nil, err
+	}
+	if len(optionTypes) == 0 {
+		for i, name := range names {
+			optionTypes = append(optionTypes, models.OptionType{ProductID: productID, Name: name, Position: i})
+		}
+		if err := tx.Create(&optionTypes).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. 全てのオプションの種類に1つずつ値が指定されているか確認
+	typeNames := make([]string, 0, len(optionTypes))
+	for _, optionType := range optionTypes {
+		typeNames = append(typeNames, optionType.Name)
+	}
+	if len(requested) != len(optionTypes) {
+		return nil, apperr.ErrVariantOptionsInvalid.WithArgs(strings.Join(typeNames, ", "))
+	}
+
+	// 4. オプションの値を取得（存在しない値は作成）
+	values := make([]models.OptionValue, 0, len(optionTypes))
+	for _, optionType := range optionTypes {
+		value, ok := requested[optionType.Name]
+		if !ok {
+			return nil, apperr.ErrVariantOptionsInvalid.WithArgs(strings.Join(typeNames, ", "))
+		}
+
+		optionValue := models.OptionValue{OptionTypeID: optionType.ID, Value: value, Position: len(optionType.Values)}
+		for _, existing := range optionType.Values {
+			if existing.Value == value {
+				optionValue = existing
+				break
+			}
+		}
+		if optionValue.ID == 0 {
+			if err := tx.Create(&optionValue).Error; err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, optionValue)
+	}
+	return values, nil
+}
+
+// variantTitle はオプションの値（表示順）からバリエーション名と、組み合わせの重複確認に使うキーを作成します
+func variantTitle(values []models.OptionValue) (title, key string) {
+	labels := make([]string, 0, len(values))
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		labels = append(labels, value.Value)
+		ids = append(ids, strconv.FormatUint(uint64(value.ID), 10))
+	}
+	return strings.Join(labels, variantTitleSeparator), strings.Join(ids, ",")
+}
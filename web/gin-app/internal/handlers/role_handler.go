@@ -0,0 +1,313 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// roleNamePattern はロール名として使用できる形式です（小文字英数字とアンダースコア）
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// errUnknownPermission は存在しない権限名が指定された場合のエラーです
+var errUnknownPermission = errors.New("存在しない権限が指定されています")
+
+// RoleHandler はロールと権限の管理に関するハンドラーをまとめる構造体です
+type RoleHandler struct {
+	db          *gorm.DB
+	permissions *auth.PermissionStore
+	revocations *auth.RevocationStore
+}
+
+// NewRoleHandler は新しいRoleHandlerを作成します
+func NewRoleHandler(db *gorm.DB, permissions *auth.PermissionStore, revocations *auth.RevocationStore) *RoleHandler {
+	return &RoleHandler{
+		db:          db,
+		permissions: permissions,
+		revocations: revocations,
+	}
+}
+
+// ListPermissions は権限の一覧を取得します
+// GET /api/v1/permissions
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	var permissions []models.Permission
+	if err := h.db.Order("name").Find(&permissions).Error; err != nil {
+		apperr.Abort(c, apperr.ErrPermissionFetchFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"permissions": permissions,
+	})
+}
+
+// ListRoles はロールの一覧を権限と共に取得します
+// GET /api/v1/roles
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	var roles []models.Role
+	if err := h.db.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		apperr.Abort(c, apperr.ErrRoleFetchFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles": roles,
+	})
+}
+
+// CreateRole は新しいロールを作成します
+// POST /api/v1/roles
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req models.RoleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	if !roleNamePattern.MatchString(req.Name) {
+		apperr.Abort(c, apperr.ErrRoleNameInvalid)
+		return
+	}
+
+	// ロール名の重複チェック
+	var count int64
+	h.db.Model(&models.Role{}).Where("name = ?", req.Name).Count(&count)
+	if count > 0 {
+		apperr.Abort(c, apperr.ErrRoleNameTaken)
+		return
+	}
+
+	permissions, err := h.findPermissions(req.Permissions)
+	if err != nil {
+		respondPermissionLookupError(c, err)
+		return
+	}
+
+	role := models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+	if err := h.db.Create(&role).Error; err != nil {
+		apperr.Abort(c, apperr.ErrRoleCreateFailed.WithCause(err))
+		return
+	}
+
+	h.permissions.Invalidate()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.T(c, "role.created"),
+		"role":    role,
+	})
+}
+
+// UpdateRole はロールの説明と権限を更新します
+// admin ロールは常に全ての権限を持つため、権限の変更はできません
+// PUT /api/v1/roles/:id
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	var role models.Role
+	if err := h.db.Preload("Permissions").First(&role, c.Param("id")).Error; err != nil {
+		apperr.Abort(c, apperr.ErrRoleNotFound)
+		return
+	}
+
+	var req models.RoleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	if req.Permissions != nil && role.Name == models.RoleAdmin {
+		apperr.Abort(c, apperr.ErrAdminRoleImmutable)
+		return
+	}
+
+	permissions, err := h.findPermissions(req.Permissions)
+	if err != nil {
+		respondPermissionLookupError(c, err)
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if req.Description != nil {
+			if err := tx.Model(&role).Update("description", *req.Description).Error; err != nil {
+				return err
+			}
+		}
+		if req.Permissions != nil {
+			if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrRoleUpdateFailed.WithCause(err))
+		return
+	}
+
+	h.permissions.Invalidate()
+
+	h.db.Preload("Permissions").First(&role, role.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "role.updated"),
+		"role":    role,
+	})
+}
+
+// DeleteRole はロールを削除します
+// 組み込みロールと、ユーザーに割り当てられているロールは削除できません
+// DELETE /api/v1/roles/:id
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	var role models.Role
+	if err := h.db.First(&role, c.Param("id")).Error; err != nil {
+		apperr.Abort(c, apperr.ErrRoleNotFound)
+		return
+	}
+
+	if role.IsSystem {
+		apperr.Abort(c, apperr.ErrRoleBuiltin)
+		return
+	}
+
+	// 削除済みユーザーも復元される可能性があるため含めて確認する
+	var count int64
+	h.db.Unscoped().Model(&models.User{}).Where("role = ?", role.Name).Count(&count)
+	if count > 0 {
+		apperr.Abort(c, apperr.ErrRoleInUse.With("users", count))
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrRoleDeleteFailed.WithCause(err))
+		return
+	}
+
+	h.permissions.Invalidate()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "role.deleted"),
+	})
+}
+
+// AssignUserRole はユーザーにロールを割り当てます
+// ロールはトークンに含まれるため、変更後はユーザーの全セッションを無効化して再ログインさせます
+// PUT /api/v1/users/:id/role
+func (h *RoleHandler) AssignUserRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidUserID)
+		return
+	}
+
+	var req models.UserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 自分自身のロール変更による管理者不在を防ぐ
+	if uint(id) == c.GetUint("user_id") {
+		apperr.Abort(c, apperr.ErrRoleSelfAssign)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	var role models.Role
+	if err := h.db.Where("name = ?", req.Role).First(&role).Error; err != nil {
+		apperr.Abort(c, apperr.ErrRoleUnknown)
+		return
+	}
+
+	if user.Role == role.Name {
+		c.JSON(http.StatusOK, gin.H{
+			"message": i18n.T(c, "role.unchanged"),
+			"user":    user.ToResponse(),
+		})
+		return
+	}
+
+	// 最後の管理者のロール変更による管理者不在を防ぐ（確認とロールの変更は同じトランザクションで行う）
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		last, err := isLastAdmin(tx, &user)
+		if err != nil {
+			return apperr.ErrUserFetchFailed.WithCause(err)
+		}
+		if last {
+			return apperr.ErrLastAdminRole
+		}
+		return tx.Model(&user).Update("role", role.Name).Error
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrRoleAssignFailed)
+		return
+	}
+
+	// 古いロールを含むトークンを使えなくする
+	if err := h.revocations.RevokeUserSessions(user.ID); err != nil {
+		log.Printf("セッションの無効化に失敗しました: user_id=%d: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "role.assigned"),
+		"user":    user.ToResponse(),
+	})
+}
+
+// findPermissions は権限名の一覧に対応する権限を取得します
+// 存在しない権限名が含まれる場合は errUnknownPermission を返します
+func (h *RoleHandler) findPermissions(names []string) ([]models.Permission, error) {
+	permissions := []models.Permission{}
+	if len(names) == 0 {
+		return permissions, nil
+	}
+
+	if err := h.db.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, errUnknownPermission
+		}
+	}
+
+	return permissions, nil
+}
+
+// respondPermissionLookupError は findPermissions のエラーに応じたレスポンスを返します
+func respondPermissionLookupError(c *gin.Context, err error) {
+	if errors.Is(err, errUnknownPermission) {
+		apperr.Abort(c, apperr.ErrPermissionUnknown)
+		return
+	}
+	apperr.Abort(c, apperr.ErrPermissionFetchFailed.WithCause(err))
+}
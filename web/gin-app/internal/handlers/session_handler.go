@@ -0,0 +1,165 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SessionHandler はログインセッション（ログイン中の端末）の管理に関するハンドラーをまとめる構造体です
+type SessionHandler struct {
+	db          *gorm.DB
+	revocations *auth.RevocationStore
+	sessions    *auth.SessionStore
+}
+
+// NewSessionHandler は新しいSessionHandlerを作成します
+func NewSessionHandler(db *gorm.DB, revocations *auth.RevocationStore, sessions *auth.SessionStore) *SessionHandler {
+	return &SessionHandler{
+		db:          db,
+		revocations: revocations,
+		sessions:    sessions,
+	}
+}
+
+// ListMySessions は自分のログイン中のセッション一覧を取得します
+// リクエストに使用しているセッションには current: true が設定されます
+// GET /api/v1/users/profile/sessions
+func (h *SessionHandler) ListMySessions(c *gin.Context) {
+	h.listSessions(c, c.GetUint("user_id"))
+}
+
+// RevokeMySession は自分のセッションを指定してログアウトさせます
+// 使用中のセッションを指定した場合は、このリクエストのトークンも使えなくなります
+// DELETE /api/v1/users/profile/sessions/:id
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	h.revokeSession(c, c.GetUint("user_id"), c.Param("id"))
+}
+
+// RevokeAllMySessions は全ての端末からログアウトします
+// 使用中のセッションを含め、発行済みの全てのトークンが使えなくなります
+// DELETE /api/v1/users/profile/sessions
+func (h *SessionHandler) RevokeAllMySessions(c *gin.Context) {
+	h.revokeAllSessions(c, c.GetUint("user_id"))
+}
+
+// ListUserSessions は指定したユーザーのログイン中のセッション一覧を取得します（users:read 権限が必要）
+// GET /api/v1/users/:id/sessions
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	userID, ok := h.findUserID(c)
+	if !ok {
+		return
+	}
+	h.listSessions(c, userID)
+}
+
+// RevokeUserSession は指定したユーザーのセッションを強制的にログアウトさせます（users:write 権限が必要）
+// DELETE /api/v1/users/:id/sessions/:session_id
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, ok := h.findUserID(c)
+	if !ok {
+		return
+	}
+	h.revokeSession(c, userID, c.Param("session_id"))
+}
+
+// RevokeAllUserSessions は指定したユーザーを全ての端末からログアウトさせます（users:write 権限が必要）
+// DELETE /api/v1/users/:id/sessions
+func (h *SessionHandler) RevokeAllUserSessions(c *gin.Context) {
+	userID, ok := h.findUserID(c)
+	if !ok {
+		return
+	}
+	h.revokeAllSessions(c, userID)
+}
+
+// listSessions はユーザーの有効なセッションを最終アクセス日時の新しい順に返します
+func (h *SessionHandler) listSessions(c *gin.Context, userID uint) {
+	var sessions []models.Session
+	if err := h.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		apperr.Abort(c, apperr.ErrSessionFetchFailed.WithCause(err))
+		return
+	}
+
+	sid := c.GetString("sid")
+	for i := range sessions {
+		sessions[i].Current = sid != "" && sessions[i].FamilyID == sid
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// revokeSession はユーザーのセッションを1件失効させます
+// 他のユーザーのセッションIDを指定した場合は存在しないものとして扱います
+func (h *SessionHandler) revokeSession(c *gin.Context, userID uint, sessionID string) {
+	id, err := strconv.ParseUint(sessionID, 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidSessionID)
+		return
+	}
+
+	var session models.Session
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&session).Error; err != nil {
+		apperr.Abort(c, apperr.ErrSessionNotFound)
+		return
+	}
+
+	if !session.IsActive() {
+		c.JSON(http.StatusOK, gin.H{
+			"message": i18n.T(c, "session.already_revoked"),
+		})
+		return
+	}
+
+	if err := h.sessions.Revoke(&session); err != nil {
+		apperr.Abort(c, apperr.ErrSessionRevokeFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "session.revoked"),
+	})
+}
+
+// revokeAllSessions はユーザーの全てのセッションを失効させます
+func (h *SessionHandler) revokeAllSessions(c *gin.Context, userID uint) {
+	if err := h.revocations.RevokeUserSessions(userID); err != nil {
+		apperr.Abort(c, apperr.ErrSessionRevokeFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "session.all_revoked"),
+	})
+}
+
+// findUserID はパスパラメータのユーザーIDを検証し、ユーザーが存在する場合に返します
+func (h *SessionHandler) findUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidUserID)
+		return 0, false
+	}
+
+	var user models.User
+	if err := h.db.Select("id").First(&user, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return 0, false
+	}
+
+	return user.ID, true
+}
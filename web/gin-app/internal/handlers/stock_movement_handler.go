@@ -0,0 +1,225 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/inventory"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/pagination"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stockReasons は在庫の移動の一覧で絞り込める理由です
+var stockReasons = map[string]bool{
+	models.StockReasonInitial:    true,
+	models.StockReasonSale:       true,
+	models.StockReasonCancel:     true,
+	models.StockReasonReturn:     true,
+	models.StockReasonAdjustment: true,
+}
+
+// AdjustStock は商品の在庫を増減し、在庫の移動に記録します（products:write 権限が必要）
+// 棚卸しの差異等は adjustment、返品の入庫は return（order_id が必要）として記録します
+// バリエーションがある商品は variant_id でバリエーションを指定してください
+// POST /api/v1/products/:id/stock/adjustments
+func (h *ProductHandler) AdjustStock(c *gin.Context) {
+	product, err := findProduct(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	var req models.StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = models.StockReasonAdjustment
+	}
+
+	// 1. バリエーションがある商品の在庫はバリエーションごとに調整する
+	if req.VariantID == nil {
+		variants, err := hasVariants(h.db, product.ID)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrVariantFetchFailed.WithCause(err))
+			return
+		}
+		if variants {
+			apperr.Abort(c, apperr.ErrStockVariantRequired)
+			return
+		}
+	}
+
+	// 2. 返品は入庫のみ
+	if req.Reason == models.StockReasonReturn && req.Delta < 0 {
+		apperr.Abort(c, apperr.ErrStockReturnDelta)
+		return
+	}
+
+	// 3. 在庫の変更と在庫の移動の記録（返品の場合は返品できる数量を確認）
+	var movement *models.StockMovement
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if req.Reason == models.StockReasonReturn {
+			if err := checkStockReturn(tx, product.ID, &req); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		movement, err = inventory.Apply(tx, inventory.Change{
+			ProductID: product.ID,
+			VariantID: req.VariantID,
+			Delta:     req.Delta,
+			Reason:    req.Reason,
+			OrderID:   req.OrderID,
+			Actor:     stockActor(c),
+			Note:      req.Note,
+		})
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, inventory.ErrNegativeStock):
+			apperr.Abort(c, apperr.ErrStockNegative)
+		case errors.Is(err, gorm.ErrRecordNotFound) && req.VariantID != nil:
+			apperr.Abort(c, apperr.ErrOrderVariantNotFound.WithArgs(*req.VariantID).
+				With("product_id", product.ID).With("variant_id", *req.VariantID))
+		default:
+			apperr.AbortOr(c, err, apperr.ErrStockAdjustFailed)
+		}
+		return
+	}
+
+	// 調整後の商品の在庫（バリエーションの場合はバリエーションの在庫の合計）
+	h.db.First(product, product.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  i18n.T(c, "stock.adjusted"),
+		"movement": movement,
+		"stock":    product.Stock,
+	})
+}
+
+// ListStockMovements は商品の在庫の移動を新しい順に取得します（products:write 権限が必要）
+// クエリパラメータ variant_id, reason, order_id で絞り込めます
+// GET /api/v1/products/:id/stock/movements
+func (h *ProductHandler) ListStockMovements(c *gin.Context) {
+	product, err := findProduct(h.db, c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	// ページネーション
+	pageReq, err := pagination.Parse(c, pagination.DefaultPageSize, pagination.MaxPageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	// 絞り込み条件
+	query := h.db.Model(&models.StockMovement{}).Where("product_id = ?", product.ID)
+	if reason := c.Query("reason"); reason != "" {
+		if !stockReasons[reason] {
+			apperr.Abort(c, apperr.ErrInvalidQueryParameter.WithArgs("reason"))
+			return
+		}
+		query = query.Where("reason = ?", reason)
+	}
+	for _, column := range []string{"variant_id", "order_id"} {
+		value := c.Query(column)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrInvalidQueryParameter.WithArgs(column))
+			return
+		}
+		query = query.Where(column+" = ?", id)
+	}
+
+	keys := []pagination.Key{
+		{Name: "created_at", Column: "stock_movements.created_at", Desc: true},
+		{Name: "id", Column: "stock_movements.id", Desc: true},
+	}
+	movements, page, err := pagination.Find(query, pageReq, keys, func(movement *models.StockMovement) []interface{} {
+		return []interface{}{movement.CreatedAt, movement.ID}
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrStockFetchFailed.WithCause(err))
+		return
+	}
+
+	response := page.Fields()
+	response["movements"] = movements
+	response["stock"] = product.Stock
+	c.JSON(http.StatusOK, response)
+}
+
+// checkStockReturn は返品する数量が、注文された数量から返品済みの数量を引いた数量以下かを確認します
+// 同じ注文の返品が同時に記録されないよう、注文の行をロックします
+func checkStockReturn(tx *gorm.DB, productID uint, req *models.StockAdjustmentRequest) error {
+	var order models.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status <> ?", models.OrderStatusCancelled).
+		First(&order, *req.OrderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperr.ErrStockReturnOrder
+	}
+	if err != nil {
+		return err
+	}
+
+	// 1. 注文された数量（注文明細の商品・バリエーションが一致するもの）
+	items := tx.Model(&models.OrderItem{}).Where("order_id = ? AND product_id = ?", order.ID, productID)
+	movements := tx.Model(&models.StockMovement{}).
+		Where("order_id = ? AND product_id = ? AND reason = ?", order.ID, productID, models.StockReasonReturn)
+	if req.VariantID != nil {
+		items = items.Where("variant_id = ?", *req.VariantID)
+		movements = movements.Where("variant_id = ?", *req.VariantID)
+	} else {
+		items = items.Where("variant_id IS NULL")
+		movements = movements.Where("variant_id IS NULL")
+	}
+
+	var ordered, returned int64
+	if err := items.Select("COALESCE(SUM(quantity), 0)").Scan(&ordered).Error; err != nil {
+		return err
+	}
+	if ordered == 0 {
+		return apperr.ErrStockReturnOrder
+	}
+
+	// 2. 返品済みの数量
+	if err := movements.Select("COALESCE(SUM(delta), 0)").Scan(&returned).Error; err != nil {
+		return err
+	}
+	if returnable := int(ordered - returned); req.Delta > returnable {
+		return apperr.ErrStockReturnExceeded.WithArgs(returnable)
+	}
+	return nil
+}
+
+// stockActor は在庫を変更したユーザーを返します
+// なりすまし中の場合は、なりすましている管理者を記録します
+func stockActor(c *gin.Context) inventory.Actor {
+	if actorID, impersonating := c.Get("actor_id"); impersonating {
+		id := actorID.(uint)
+		return inventory.Actor{ID: &id, Name: c.GetString("actor_username")}
+	}
+	if userID := c.GetUint("user_id"); userID > 0 {
+		return inventory.Actor{ID: &userID, Name: c.GetString("username")}
+	}
+	return inventory.Actor{Name: c.GetString("username")}
+}
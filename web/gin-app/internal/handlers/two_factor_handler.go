@@ -0,0 +1,376 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recoveryCodeCount は一度に発行するリカバリーコードの数です
+const recoveryCodeCount = 10
+
+// errInvalidSecondFactor は二要素認証コードが正しくない場合のエラーです
+var errInvalidSecondFactor = errors.New("認証コードが正しくありません")
+
+// verifyTOTP はTOTPコードを検証し、使用したタイムステップを記録します
+// 同じコードを二度使えないよう、記録済みのタイムステップ以前のコードは拒否します
+func verifyTOTP(db *gorm.DB, user *models.User, code string) (bool, error) {
+	counter, ok := utils.ValidateTOTPCode(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	result := db.Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", user.ID, counter).
+		Update("totp_last_counter", counter)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// verifySecondFactor はTOTPコードまたはリカバリーコードを検証します
+// リカバリーコードは一度使用すると無効になります
+func verifySecondFactor(db *gorm.DB, user *models.User, code string) (usedRecoveryCode bool, err error) {
+	ok, err := verifyTOTP(db, user, code)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return false, nil
+	}
+
+	// TOTPコードでなければリカバリーコードとして検証
+	result := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL",
+			user.ID, utils.HashToken(utils.NormalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, errInvalidSecondFactor
+	}
+	return true, nil
+}
+
+// regenerateRecoveryCodes は既存のリカバリーコードを破棄して新しいコードを発行します
+// 平文のコードはこの時点でしか取得できないため、呼び出し側でユーザーに表示します
+func regenerateRecoveryCodes(db *gorm.DB, userID uint) ([]string, error) {
+	if err := db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(utils.NormalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := db.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// SetupTwoFactor は二要素認証の登録を開始し、TOTPシークレットを発行します
+// 返された otpauth URI を認証アプリに登録し、EnableTwoFactor で有効化を完了します
+// POST /api/v1/users/profile/2fa/setup
+func (h *UserHandler) SetupTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	if user.IsTwoFactorEnabled() {
+		apperr.Abort(c, apperr.ErrTwoFactorAlreadyEnabled)
+		return
+	}
+
+	// 未確定のシークレットとして保存（有効化までは認証に使用されない）
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		apperr.Abort(c, apperr.ErrTwoFactorSecretFailed.WithCause(err))
+		return
+	}
+	if err := h.db.Model(&user).Updates(map[string]interface{}{
+		"totp_secret":       secret,
+		"totp_last_counter": 0,
+	}).Error; err != nil {
+		apperr.Abort(c, apperr.ErrTwoFactorSetupFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     i18n.T(c, "two_factor.setup_started"),
+		"secret":      secret,
+		"otpauth_uri": utils.TOTPAuthURI(h.cfg.App.Name, user.Username, secret),
+	})
+}
+
+// EnableTwoFactor は認証アプリのコードを確認して二要素認証を有効化します
+// 有効化と同時にリカバリーコードを発行します
+// POST /api/v1/users/profile/2fa/enable
+func (h *UserHandler) EnableTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	if user.IsTwoFactorEnabled() {
+		apperr.Abort(c, apperr.ErrTwoFactorAlreadyEnabled)
+		return
+	}
+	if user.TOTPSecret == "" {
+		apperr.Abort(c, apperr.ErrTwoFactorSetupRequired)
+		return
+	}
+
+	var codes []string
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		ok, err := verifyTOTP(tx, &user, req.Code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errInvalidSecondFactor
+		}
+
+		if err := tx.Model(&user).Update("two_factor_enabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		codes, err = regenerateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err == errInvalidSecondFactor {
+		apperr.Abort(c, apperr.ErrTwoFactorSetupCodeInvalid)
+		return
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.ErrTwoFactorEnableFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        i18n.T(c, "two_factor.enabled"),
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor は二要素認証を無効化します
+// パスワードと現在のコード（またはリカバリーコード）の両方で本人確認を行います
+// POST /api/v1/users/profile/2fa/disable
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		apperr.Abort(c, apperr.ErrTwoFactorNotEnabled)
+		return
+	}
+
+	if !user.CheckPassword(req.Password) {
+		apperr.Abort(c, apperr.ErrPasswordIncorrect)
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := verifySecondFactor(tx, &user, req.Code); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_secret":           "",
+			"totp_last_counter":     0,
+			"two_factor_enabled_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err == errInvalidSecondFactor {
+		apperr.Abort(c, apperr.ErrTwoFactorCodeInvalid)
+		return
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.ErrTwoFactorDisableFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "two_factor.disabled"),
+	})
+}
+
+// RegenerateRecoveryCodes はリカバリーコードを再発行します
+// 以前のリカバリーコードは全て無効になります
+// POST /api/v1/users/profile/2fa/recovery-codes
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		apperr.Abort(c, apperr.ErrTwoFactorNotEnabled)
+		return
+	}
+
+	var codes []string
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		ok, err := verifyTOTP(tx, &user, req.Code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errInvalidSecondFactor
+		}
+
+		codes, err = regenerateRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err == errInvalidSecondFactor {
+		apperr.Abort(c, apperr.ErrTwoFactorCodeInvalid)
+		return
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.ErrRecoveryCodesFailed.WithCause(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        i18n.T(c, "two_factor.recovery_codes_regenerated"),
+		"recovery_codes": codes,
+	})
+}
+
+// VerifyTwoFactorLogin は二段階ログインの2段階目として認証コードを検証します
+// ログイン時に返された mfa_token と、TOTPコードまたはリカバリーコードを受け取り、
+// 検証に成功した場合にアクセストークンとリフレッシュトークンを発行します
+// POST /api/v1/auth/login/2fa
+func (h *AuthHandler) VerifyTwoFactorLogin(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	// 1. 二要素認証待ちトークンの検証
+	claims, err := utils.ValidateJWT(req.MFAToken, h.keys)
+	if err != nil || claims.TokenType != utils.TokenTypeMFAPending || h.revocations.IsRevoked(claims.ID) {
+		apperr.Abort(c, apperr.ErrMFATokenInvalid)
+		return
+	}
+
+	// 2. ユーザーの状態チェック
+	var user models.User
+	if err := h.db.First(&user, claims.UserID).Error; err != nil || !user.IsActive || !user.IsTwoFactorEnabled() {
+		apperr.Abort(c, apperr.ErrAccountUnavailable)
+		return
+	}
+
+	// 3. アカウントロックのチェック
+	// 認証コードの総当たりを防ぐため、コードの誤りもログイン失敗として数えます
+	if wait, locked := h.guard.CheckUser(&user); locked {
+		respondLoginLocked(c, wait)
+		return
+	}
+
+	// 4. 認証コードの検証
+	usedRecoveryCode, err := verifySecondFactor(h.db, &user, req.Code)
+	if err == errInvalidSecondFactor {
+		if err := h.guard.RecordUserFailure(user.ID, c.ClientIP()); err != nil {
+			log.Printf("ログイン失敗の記録に失敗しました: %v", err)
+		}
+		apperr.Abort(c, apperr.ErrTwoFactorCodeInvalid)
+		return
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.ErrTwoFactorVerifyFailed.WithCause(err))
+		return
+	}
+
+	// 5. 二要素認証待ちトークンは一度しか使えないよう失効させる
+	if err := h.revocations.Revoke(claims.ID, user.ID, claims.ExpiresAt.Time); err != nil {
+		apperr.Abort(c, apperr.ErrTokenGenerateFailed.WithCause(err))
+		return
+	}
+
+	// 6. ログイン失敗回数をリセットし、二要素認証済みとしてトークンを発行
+	resetLoginFailures(h.guard, &user)
+
+	pair, err := issueTokenPair(c, h.db, h.cfg, h.keys, &user, "", true)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrTokenGenerateFailed.WithCause(err))
+		return
+	}
+
+	response := gin.H{
+		"message":       i18n.T(c, "login.succeeded"),
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"user":          user.ToResponse(),
+	}
+
+	// リカバリーコードを使用した場合は残り数を通知
+	if usedRecoveryCode {
+		var remaining int64
+		h.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&remaining)
+		response["recovery_codes_remaining"] = remaining
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,282 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/pagination"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errUserHasOrders は注文履歴のあるユーザーを完全削除しようとした場合のエラーです
+// 注文履歴のあるユーザーは、代わりに個人情報の削除（POST /users/:id/erase）を使用します
+var errUserHasOrders = errors.New("注文履歴があるユーザーは完全削除できません。個人情報の削除を使用してください")
+
+// DeactivateUser はユーザーを無効化します（users:write 権限が必要）
+// 無効化したユーザーはログインできず、発行済みのトークンも使えなくなります
+// POST /api/v1/users/:id/deactivate
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	user, ok := h.findTargetUser(c)
+	if !ok {
+		return
+	}
+
+	if !user.IsActive {
+		c.JSON(http.StatusOK, gin.H{
+			"message": i18n.T(c, "user.already_deactivated"),
+			"user":    user.ToResponse(),
+		})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkLastAdmin(tx, user); err != nil {
+			return err
+		}
+		return tx.Model(user).Update("is_active", false).Error
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrUserDeactivateFailed)
+		return
+	}
+
+	h.revokeUserSessions(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "user.deactivated"),
+		"user":    user.ToResponse(),
+	})
+}
+
+// ActivateUser は無効化したユーザーを再び有効にします（users:write 権限が必要）
+// POST /api/v1/users/:id/activate
+func (h *UserHandler) ActivateUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidUserID)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	if user.IsActive {
+		c.JSON(http.StatusOK, gin.H{
+			"message": i18n.T(c, "user.already_active"),
+			"user":    user.ToResponse(),
+		})
+		return
+	}
+
+	if err := h.db.Model(&user).Update("is_active", true).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserActivateFailed.WithCause(err))
+		return
+	}
+
+	// 無効状態のキャッシュを破棄し、次のログインから即座に利用できるようにする
+	h.revocations.ForgetUser(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "user.activated"),
+		"user":    user.ToResponse(),
+	})
+}
+
+// ListDeletedUsers は削除済み（ソフトデリート）のユーザー一覧を取得します（users:read 権限が必要）
+// GET /api/v1/users/deleted
+func (h *UserHandler) ListDeletedUsers(c *gin.Context) {
+	// ページネーション
+	pageReq, err := pagination.Parse(c, pagination.DefaultPageSize, pagination.MaxPageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	query := h.db.Unscoped().Model(&models.User{}).Where("deleted_at IS NOT NULL")
+
+	// 削除日時の新しい順に取得
+	keys := []pagination.Key{
+		{Name: "deleted_at", Column: "users.deleted_at", Desc: true},
+		{Name: "id", Column: "users.id", Desc: true},
+	}
+	users, page, err := pagination.Find(query, pageReq, keys, func(user *models.User) []interface{} {
+		return []interface{}{user.DeletedAt.Time, user.ID}
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrUserFetchFailed.WithCause(err))
+		return
+	}
+
+	userResponses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		userResponses = append(userResponses, user.ToResponse())
+	}
+
+	response := page.Fields()
+	response["users"] = userResponses
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreUser は削除済みのユーザーを復元します（users:write 権限が必要）
+// 削除前に発行されたトークンは無効のままのため、ユーザーは再ログインが必要です
+// POST /api/v1/users/:id/restore
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	user, ok := h.findDeletedUser(c)
+	if !ok {
+		return
+	}
+
+	// 個人情報を削除したユーザーはログインできないため復元しない
+	if user.IsAnonymized() {
+		apperr.Abort(c, apperr.ErrUserAnonymized)
+		return
+	}
+
+	if err := h.db.Unscoped().Model(user).Update("deleted_at", nil).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserRestoreFailed.WithCause(err))
+		return
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+
+	// 削除状態のキャッシュを破棄
+	h.revocations.ForgetUser(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "user.restored"),
+		"user":    user.ToResponse(),
+	})
+}
+
+// PurgeUser は削除済みのユーザーを完全に削除します（users:write 権限が必要）
+// 認証に関するレコードも全て削除し、ユーザー名とメールアドレスを再び登録できるようにします
+// 注文履歴は保持する必要があるため、注文のあるユーザーは完全削除できません
+// DELETE /api/v1/users/:id/purge
+func (h *UserHandler) PurgeUser(c *gin.Context) {
+	user, ok := h.findDeletedUser(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var orders int64
+		if err := tx.Unscoped().Model(&models.Order{}).Where("user_id = ?", user.ID).Count(&orders).Error; err != nil {
+			return err
+		}
+		if orders > 0 {
+			return errUserHasOrders
+		}
+
+		// ユーザーに紐付くレコードを削除
+		if err := deleteUserAuthRecords(tx, user.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RevokedToken{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(user).Error
+	})
+	if err == errUserHasOrders {
+		apperr.Abort(c, apperr.ErrUserHasOrders)
+		return
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.ErrUserPurgeFailed.WithCause(err))
+		return
+	}
+
+	h.revocations.ForgetUser(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "user.purged"),
+	})
+}
+
+// findTargetUser はパスパラメータのユーザーを取得します（削除済みユーザーは対象外）
+// 自分自身を対象にした場合は、管理者が自分のアカウントを操作できなくなるのを防ぐためエラーにします
+func (h *UserHandler) findTargetUser(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidUserID)
+		return nil, false
+	}
+
+	if uint(id) == c.GetUint("user_id") {
+		apperr.Abort(c, apperr.ErrSelfOperation)
+		return nil, false
+	}
+
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// findDeletedUser はパスパラメータの削除済みユーザーを取得します
+func (h *UserHandler) findDeletedUser(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidUserID)
+		return nil, false
+	}
+
+	var user models.User
+	if err := h.db.Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrDeletedUserNotFound)
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// checkLastAdmin は最後の有効な管理者を無効化・削除しようとしていないかを確認します
+// 管理者が不在になる場合は apperr.ErrLastAdmin を返します
+// ユーザーの無効化・削除と同じトランザクション内で呼び出してください
+func checkLastAdmin(tx *gorm.DB, user *models.User) error {
+	last, err := isLastAdmin(tx, user)
+	if err != nil {
+		return apperr.ErrUserFetchFailed.WithCause(err)
+	}
+	if last {
+		return apperr.ErrLastAdmin
+	}
+	return nil
+}
+
+// isLastAdmin はユーザーが唯一の有効な管理者かどうかを返します
+// 複数の管理者が同時に無効化・削除されて管理者が不在にならないよう、有効な管理者の行をロックします
+// トランザクション内で呼び出し、ユーザーの変更もコミットまでに行ってください
+func isLastAdmin(tx *gorm.DB, user *models.User) (bool, error) {
+	if user.Role != models.RoleAdmin || !user.IsActive {
+		return false, nil
+	}
+
+	// 集計（COUNT）の結果はロックできないため、IDを取得してロックする（デッドロックを防ぐためIDの順）
+	var admins []uint
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.User{}).
+		Where("role = ? AND is_active = ?", models.RoleAdmin, true).
+		Order("id").
+		Pluck("id", &admins).Error; err != nil {
+		return false, err
+	}
+	for _, id := range admins {
+		if id != user.ID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -2,11 +2,17 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
 	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/mailer"
 	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/pagination"
 	"go_learning/web/gin-app/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -15,15 +21,25 @@ import (
 
 // UserHandler はユーザー関連のハンドラーをまとめる構造体です
 type UserHandler struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db          *gorm.DB
+	cfg         *config.Config
+	keys        *auth.KeyManager
+	revocations *auth.RevocationStore
+	mailer      mailer.Mailer
+	guard       *auth.LoginGuard
+	policy      *auth.PasswordPolicy
 }
 
 // NewUserHandler は新しいUserHandlerを作成します
-func NewUserHandler(db *gorm.DB, cfg *config.Config) *UserHandler {
+func NewUserHandler(db *gorm.DB, cfg *config.Config, keys *auth.KeyManager, revocations *auth.RevocationStore, mail mailer.Mailer, guard *auth.LoginGuard, policy *auth.PasswordPolicy) *UserHandler {
 	return &UserHandler{
-		db:  db,
-		cfg: cfg,
+		db:          db,
+		cfg:         cfg,
+		keys:        keys,
+		revocations: revocations,
+		mailer:      mail,
+		guard:       guard,
+		policy:      policy,
 	}
 }
 
@@ -34,26 +50,21 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 	// リクエストボディのバリデーション
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "入力値が無効です: " + err.Error(),
-		})
+		utils.RespondValidationError(c, err)
 		return
 	}
 
 	// ユーザー名の重複チェック
+	// 削除済みユーザーも一意制約の対象のため、完全削除されるまでは同じ値を使用できない
 	var existingUser models.User
-	if err := h.db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "このユーザー名は既に使用されています",
-		})
+	if err := h.db.Unscoped().Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
+		apperr.Abort(c, apperr.ErrUsernameTaken)
 		return
 	}
 
 	// メールアドレスの重複チェック
-	if err := h.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "このメールアドレスは既に使用されています",
-		})
+	if err := h.db.Unscoped().Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		apperr.Abort(c, apperr.ErrEmailTaken)
 		return
 	}
 
@@ -64,19 +75,30 @@ func (h *UserHandler) Register(c *gin.Context) {
 		Password:  req.Password, // BeforeCreateフックで自動的にハッシュ化されます
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
+		Locale:    req.Locale,
 		Role:      "user", // デフォルトはuserロール
 		IsActive:  true,
 	}
 
-	if err := h.db.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "ユーザーの作成に失敗しました",
-		})
+	// 作成と同時にパスワードを履歴に記録
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return h.policy.Record(tx, &user)
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrUserCreateFailed.WithCause(err))
 		return
 	}
 
+	// メールアドレス確認メールの送信
+	if err := sendVerificationEmail(h.db, h.cfg, h.mailer, &user, userLocale(c, &user)); err != nil {
+		log.Printf("確認メールの送信に失敗しました: user_id=%d: %v", user.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "ユーザー登録が完了しました",
+		"message": i18n.T(c, "user.registered"),
 		"user":    user.ToResponse(),
 	})
 }
@@ -87,50 +109,95 @@ func (h *UserHandler) Login(c *gin.Context) {
 	var req models.UserLoginRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "入力値が無効です: " + err.Error(),
-		})
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	clientIP := c.ClientIP()
+
+	// IPアドレスごとのログイン試行制限のチェック
+	if wait, locked := h.guard.CheckIP(clientIP); locked {
+		respondLoginLocked(c, wait)
 		return
 	}
 
 	// ユーザーの検索（ユーザー名またはメールアドレス）
 	var user models.User
 	if err := h.db.Where("username = ? OR email = ?", req.Username, req.Username).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "ユーザー名またはパスワードが正しくありません",
-		})
+		// 存在しないユーザーでも、実在するアカウントと同じ応答時間・ロック動作にする
+		if wait, locked := h.guard.CheckUnknownUser(req.Username); locked {
+			respondLoginLocked(c, wait)
+			return
+		}
+		h.guard.EqualizeTiming(req.Password)
+		h.guard.RecordUnknownUserFailure(req.Username, clientIP)
+
+		apperr.Abort(c, apperr.ErrInvalidCredentials)
+		return
+	}
+
+	// アカウントロックのチェック
+	if wait, locked := h.guard.CheckUser(&user); locked {
+		respondLoginLocked(c, wait)
 		return
 	}
 
 	// パスワードの検証
 	if !user.CheckPassword(req.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "ユーザー名またはパスワードが正しくありません",
-		})
+		if err := h.guard.RecordUserFailure(user.ID, clientIP); err != nil {
+			log.Printf("ログイン失敗の記録に失敗しました: %v", err)
+		}
+		apperr.Abort(c, apperr.ErrInvalidCredentials)
 		return
 	}
 
 	// アクティブユーザーのチェック
 	if !user.IsActive {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "このアカウントは無効化されています",
+		apperr.Abort(c, apperr.ErrAccountDisabled)
+		return
+	}
+
+	// メールアドレス確認済みのチェック（設定で有効な場合のみ）
+	if h.cfg.Auth.RequireEmailVerification && !user.IsEmailVerified() {
+		apperr.Abort(c, apperr.ErrEmailNotVerified)
+		return
+	}
+
+	// 二要素認証が有効な場合は、コード入力待ちのトークンのみを返す
+	// POST /api/v1/auth/login/2fa でコードを検証した後にアクセストークンを発行します
+	if user.IsTwoFactorEnabled() {
+		mfaToken, err := utils.GenerateMFAToken(user.ID, user.Username, h.cfg.Auth.MFATokenTTL, h.cfg.JWT, h.keys)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrTokenGenerateFailed.WithCause(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":      i18n.T(c, "login.mfa_code_required"),
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   int64(h.cfg.Auth.MFATokenTTL.Seconds()),
 		})
 		return
 	}
 
-	// JWTトークンの生成
-	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role, h.cfg.JWT)
+	// ログイン成功時は失敗回数をリセット
+	// 二要素認証が有効な場合は、コードの検証に成功するまでリセットしません
+	resetLoginFailures(h.guard, &user)
+
+	// アクセストークンとリフレッシュトークンの生成
+	pair, err := issueTokenPair(c, h.db, h.cfg, h.keys, &user, "", false)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "トークンの生成に失敗しました",
-		})
+		apperr.Abort(c, apperr.ErrTokenGenerateFailed.WithCause(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "ログインに成功しました",
-		"token":   token,
-		"user":    user.ToResponse(),
+		"message":       i18n.T(c, "login.succeeded"),
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"expires_in":    pair.ExpiresIn,
+		"user":          user.ToResponse(),
 	})
 }
 
@@ -140,17 +207,13 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	// ミドルウェアで設定されたユーザーIDを取得
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "認証が必要です",
-		})
+		apperr.Abort(c, apperr.ErrAuthRequired)
 		return
 	}
 
 	var user models.User
 	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "ユーザーが見つかりません",
-		})
+		apperr.Abort(c, apperr.ErrUserNotFound)
 		return
 	}
 
@@ -164,23 +227,27 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	var req models.UserUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "入力値が無効です: " + err.Error(),
-		})
+		utils.RespondValidationError(c, err)
 		return
 	}
 
 	var user models.User
 	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "ユーザーが見つかりません",
-		})
+		apperr.Abort(c, apperr.ErrUserNotFound)
 		return
 	}
 
 	// 更新するフィールドのみ適用
-	if req.Email != "" {
+	// メールアドレスを変更した場合は未確認に戻し、新しいアドレスで再確認する
+	emailChanged := req.Email != "" && req.Email != user.Email
+	if emailChanged {
+		var existingUser models.User
+		if err := h.db.Unscoped().Where("email = ? AND id <> ?", req.Email, user.ID).First(&existingUser).Error; err == nil {
+			apperr.Abort(c, apperr.ErrEmailTaken)
+			return
+		}
 		user.Email = req.Email
+		user.EmailVerifiedAt = nil
 	}
 	if req.FirstName != "" {
 		user.FirstName = req.FirstName
@@ -188,70 +255,94 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	if req.LastName != "" {
 		user.LastName = req.LastName
 	}
-	if req.IsActive != nil {
-		user.IsActive = *req.IsActive
+	localeChanged := req.Locale != "" && req.Locale != user.Locale
+	if localeChanged {
+		user.Locale = req.Locale
 	}
 
-	if err := h.db.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "プロフィールの更新に失敗しました",
-		})
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if req.IsActive != nil {
+			// 自分のアカウントの無効化で管理者が不在にならないようにする
+			if !*req.IsActive {
+				if err := checkLastAdmin(tx, &user); err != nil {
+					return err
+				}
+			}
+			user.IsActive = *req.IsActive
+		}
+		return tx.Save(&user).Error
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrProfileUpdateFailed)
 		return
 	}
 
+	// アカウントを無効化した場合は発行済みのトークンを使えなくする
+	if !user.IsActive {
+		h.revokeUserSessions(user.ID)
+	}
+
+	// 言語を変更した場合は、このレスポンスと次のリクエストから新しい言語を使用する
+	if localeChanged {
+		h.revocations.ForgetUser(user.ID)
+		i18n.SetLocale(c, user.Locale)
+	}
+
+	// 新しいメールアドレスに確認メールを送信
+	if emailChanged {
+		if err := sendVerificationEmail(h.db, h.cfg, h.mailer, &user, userLocale(c, &user)); err != nil {
+			log.Printf("確認メールの送信に失敗しました: user_id=%d: %v", user.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "プロフィールを更新しました",
+		"message": i18n.T(c, "user.profile_updated"),
 		"user":    user.ToResponse(),
 	})
 }
 
-// ListUsers は全ユーザーのリストを取得します（管理者のみ）
+// ListUsers は全ユーザーのリストを新しい順に取得します（users:read 権限が必要）
 // GET /api/v1/users
 func (h *UserHandler) ListUsers(c *gin.Context) {
 	// ページネーション
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	offset := (page - 1) * pageSize
-
-	var users []models.User
-	var total int64
-
-	// 総数を取得
-	h.db.Model(&models.User{}).Count(&total)
+	pageReq, err := pagination.Parse(c, pagination.DefaultPageSize, pagination.MaxPageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
 
-	// ページネーション付きでユーザーを取得
-	if err := h.db.Limit(pageSize).Offset(offset).Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "ユーザーの取得に失敗しました",
-		})
+	// ユーザーを新しい順に取得（同じ作成日時の場合はIDの降順）
+	keys := []pagination.Key{
+		{Name: "created_at", Column: "users.created_at", Desc: true},
+		{Name: "id", Column: "users.id", Desc: true},
+	}
+	users, page, err := pagination.Find(h.db.Model(&models.User{}), pageReq, keys, func(user *models.User) []interface{} {
+		return []interface{}{user.CreatedAt, user.ID}
+	})
+	if err != nil {
+		apperr.Abort(c, apperr.ErrUserFetchFailed.WithCause(err))
 		return
 	}
 
 	// レスポンスに変換
-	var userResponses []models.UserResponse
+	userResponses := make([]models.UserResponse, 0, len(users))
 	for _, user := range users {
 		userResponses = append(userResponses, user.ToResponse())
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"users":      userResponses,
-		"total":      total,
-		"page":       page,
-		"page_size":  pageSize,
-		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-	})
+	response := page.Fields()
+	response["users"] = userResponses
+	c.JSON(http.StatusOK, response)
 }
 
-// GetUser は特定のユーザー情報を取得します（管理者のみ）
+// GetUser は特定のユーザー情報を取得します（users:read 権限が必要）
 // GET /api/v1/users/:id
 func (h *UserHandler) GetUser(c *gin.Context) {
 	id := c.Param("id")
 
 	var user models.User
 	if err := h.db.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "ユーザーが見つかりません",
-		})
+		apperr.Abort(c, apperr.ErrUserNotFound)
 		return
 	}
 
@@ -259,18 +350,67 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 }
 
 // DeleteUser はユーザーを削除します（ソフトデリート）
+// 削除したユーザーは POST /users/:id/restore で復元、DELETE /users/:id/purge で完全削除できます
 // DELETE /api/v1/users/:id
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	id := c.Param("id")
+	user, ok := h.findTargetUser(c)
+	if !ok {
+		return
+	}
 
-	if err := h.db.Delete(&models.User{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "ユーザーの削除に失敗しました",
-		})
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkLastAdmin(tx, user); err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrUserDeleteFailed)
+		return
+	}
+
+	// 削除したユーザーの発行済みトークンを使えなくする
+	h.revokeUserSessions(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "user.deleted"),
+	})
+}
+
+// UnlockUser はログイン失敗によるアカウントロックを解除します（users:write 権限が必要）
+// POST /api/v1/users/:id/unlock
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidUserID)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	if err := h.guard.Unlock(user.ID); err != nil {
+		apperr.Abort(c, apperr.ErrUnlockFailed.WithCause(err))
 		return
 	}
 
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "ユーザーを削除しました",
+		"message": i18n.T(c, "user.unlocked"),
+		"user":    user.ToResponse(),
 	})
 }
+
+// revokeUserSessions はユーザーの全てのセッションを無効化します
+// 発行済みのアクセストークンとリフレッシュトークンは以降のリクエストで拒否されます
+func (h *UserHandler) revokeUserSessions(userID uint) {
+	if err := h.revocations.RevokeUserSessions(userID); err != nil {
+		log.Printf("セッションの無効化に失敗しました: user_id=%d: %v", userID, err)
+	}
+}
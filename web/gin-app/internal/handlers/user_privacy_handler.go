@@ -0,0 +1,277 @@
+// Package handlers はHTTPリクエストを処理するハンドラー関数を提供します
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// エクスポートの形式
+const (
+	exportFormatJSON = "json"
+	exportFormatZIP  = "zip"
+)
+
+// ExportMyData はログイン中のユーザーの個人データをエクスポートします
+// プロフィール、ログインセッション、注文（明細を含む）を1つのファイルとして返します
+// format=zip の場合は profile.json, sessions.json, orders.json を含むZIPアーカイブを返します
+// GET /api/v1/users/profile/export
+func (h *UserHandler) ExportMyData(c *gin.Context) {
+	format := c.DefaultQuery("format", exportFormatJSON)
+	if format != exportFormatJSON && format != exportFormatZIP {
+		apperr.Abort(c, apperr.ErrInvalidExportFormat)
+		return
+	}
+
+	// 1. エクスポートするデータの取得
+	var user models.User
+	if err := h.db.First(&user, c.GetUint("user_id")).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	export := models.UserDataExport{
+		ExportedAt: time.Now(),
+		Profile:    user.ToResponse(),
+		Sessions:   []models.Session{},
+		Orders:     []models.Order{},
+	}
+	if err := h.db.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&export.Sessions).Error; err != nil {
+		apperr.Abort(c, apperr.ErrExportFailed.WithCause(err))
+		return
+	}
+	if err := h.db.Preload("OrderItems.Product").Preload("OrderItems.Variant").Where("user_id = ?", user.ID).
+		Order("created_at DESC").Find(&export.Orders).Error; err != nil {
+		apperr.Abort(c, apperr.ErrExportFailed.WithCause(err))
+		return
+	}
+
+	// 2. 指定された形式でダウンロードさせる
+	filename := fmt.Sprintf("user-%d-export-%s", user.ID, export.ExportedAt.Format("20060102"))
+	c.Header("Cache-Control", "no-store")
+
+	if format == exportFormatJSON {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.IndentedJSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := buildExportArchive(&export)
+	if err != nil {
+		log.Printf("エクスポートファイルの作成に失敗しました: user_id=%d: %v", user.ID, err)
+		apperr.Abort(c, apperr.ErrExportFailed.WithCause(err))
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// EraseMyData はログイン中のユーザー本人の個人情報を削除します
+// 誤操作や盗まれたトークンによる削除を防ぐため、現在のパスワードの入力が必要です
+// POST /api/v1/users/profile/erase
+func (h *UserHandler) EraseMyData(c *gin.Context) {
+	var req models.UserEraseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondValidationError(c, err)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, c.GetUint("user_id")).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	// 1. パスワードの確認（総当たりを防ぐため、ログイン失敗として記録）
+	if wait, locked := h.guard.CheckUser(&user); locked {
+		respondLoginLocked(c, wait)
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		if err := h.guard.RecordUserFailure(user.ID, c.ClientIP()); err != nil {
+			log.Printf("ログイン失敗の記録に失敗しました: %v", err)
+		}
+		apperr.Abort(c, apperr.ErrPasswordIncorrect)
+		return
+	}
+
+	// 2. 最後の管理者でないことを確認して個人情報を削除
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkLastAdmin(tx, &user); err != nil {
+			return err
+		}
+		return eraseUser(tx, &user)
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrEraseFailed)
+		return
+	}
+	h.revocations.ForgetUser(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "user.self_erased"),
+	})
+}
+
+// EraseUser は指定したユーザーの個人情報を削除します（users:write 権限が必要）
+// 削除済み（ソフトデリート）のユーザーも対象にできます
+// 注文履歴があり完全削除できないユーザーの個人情報を削除する場合にも使用します
+// POST /api/v1/users/:id/erase
+func (h *UserHandler) EraseUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidUserID)
+		return
+	}
+
+	if uint(id) == c.GetUint("user_id") {
+		apperr.Abort(c, apperr.ErrSelfOperation)
+		return
+	}
+
+	var user models.User
+	if err := h.db.Unscoped().First(&user, id).Error; err != nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	if user.IsAnonymized() {
+		c.JSON(http.StatusOK, gin.H{
+			"message": i18n.T(c, "user.already_erased"),
+			"user":    user.ToResponse(),
+		})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkLastAdmin(tx, &user); err != nil {
+			return err
+		}
+		return eraseUser(tx, &user)
+	})
+	if err != nil {
+		apperr.AbortOr(c, err, apperr.ErrEraseFailed)
+		return
+	}
+	h.revocations.ForgetUser(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.T(c, "user.erased"),
+		"user":    user.ToResponse(),
+	})
+}
+
+// eraseUser はユーザーの個人情報を匿名化し、ユーザーを削除済みにします
+// 注文は会計上の記録として番号・金額・明細を保持し、住所のみを削除します
+// ログインセッションや認証用のトークンなど、個人に紐付く認証情報は全て削除します
+func eraseUser(db *gorm.DB, user *models.User) error {
+	now := time.Now()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 1. ユーザーの匿名化
+		user.Anonymize(now)
+		if err := tx.Unscoped().Save(user).Error; err != nil {
+			return err
+		}
+
+		// 2. 注文の住所を削除
+		if err := tx.Unscoped().Model(&models.Order{}).Where("user_id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"shipping_address": models.ErasedAddress,
+				"billing_address":  models.ErasedAddress,
+			}).Error; err != nil {
+			return err
+		}
+
+		// 3. 認証情報の削除
+		if err := deleteUserAuthRecords(tx, user.ID); err != nil {
+			return err
+		}
+
+		// 4. 監査ログに記録されたユーザー名を置き換え（操作の記録自体は保持する）
+		if err := tx.Model(&models.AuditLog{}).Where("actor_id = ?", user.ID).
+			Update("actor_name", user.Username).Error; err != nil {
+			return err
+		}
+
+		// 5. 削除済みにする（既に削除済みの場合は削除日時を維持）
+		if !user.DeletedAt.Valid {
+			if err := tx.Delete(user).Error; err != nil {
+				return err
+			}
+			user.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		}
+
+		return nil
+	})
+}
+
+// deleteUserAuthRecords はユーザーに紐付く認証情報（セッション、トークン、パスワード履歴等）を削除します
+func deleteUserAuthRecords(tx *gorm.DB, userID uint) error {
+	// 外部キー制約のあるものを先に削除
+	for _, model := range []interface{}{
+		&models.RefreshToken{},
+		&models.Session{},
+		&models.UserToken{},
+		&models.RecoveryCode{},
+		&models.PasswordHistory{},
+	} {
+		if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildExportArchive はエクスポートするデータをZIPアーカイブにまとめます
+func buildExportArchive(export *models.UserDataExport) ([]byte, error) {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"sessions.json", export.Sessions},
+		{"orders.json", export.Orders},
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := w.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,177 @@
+// Package i18n はAPIのメッセージの多言語対応を提供します
+// メッセージカタログ（locales/*.json）はバイナリに埋め込み、起動時に読み込みます
+// リクエストの言語は Accept-Language ヘッダー、またはユーザーが設定した言語から決定します
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultLocale はメッセージが見つからない場合に使用する言語です
+const DefaultLocale = "ja"
+
+// ContextKey はリクエストの言語を保存するコンテキストのキーです
+const ContextKey = "locale"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs は言語ごとのメッセージカタログです（言語 -> メッセージキー -> メッセージ）
+var catalogs = mustLoadCatalogs()
+
+// mustLoadCatalogs は埋め込んだメッセージカタログを読み込みます
+// カタログはバイナリに埋め込まれるため、読み込みに失敗した場合は起動時に panic します
+func mustLoadCatalogs() map[string]map[string]string {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic("i18n: メッセージカタログの読み込みに失敗しました: " + err.Error())
+	}
+
+	loaded := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic("i18n: メッセージカタログの読み込みに失敗しました: " + err.Error())
+		}
+
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic("i18n: " + file.Name() + " の形式が正しくありません: " + err.Error())
+		}
+		loaded[strings.TrimSuffix(file.Name(), path.Ext(file.Name()))] = messages
+	}
+
+	if _, ok := loaded[DefaultLocale]; !ok {
+		panic("i18n: デフォルトの言語 " + DefaultLocale + " のメッセージカタログがありません")
+	}
+	return loaded
+}
+
+// Supported は対応している言語の一覧を返します
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// IsSupported は言語に対応しているかどうかを返します
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Translate はメッセージキーに対応する指定した言語のメッセージを返します
+// 指定した言語にメッセージがない場合はデフォルトの言語、それもない場合はキーをそのまま返します
+// args を指定した場合は fmt.Sprintf の形式でメッセージに埋め込みます
+func Translate(locale, key string, args ...interface{}) string {
+	text, ok := catalogs[locale][key]
+	if !ok {
+		text, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Negotiate は Accept-Language ヘッダーの値から使用する言語を決定します
+// 品質値（q）の高い順に、完全一致または主言語（en-US の en）が一致する言語を選びます
+// 対応する言語がない場合はデフォルトの言語を返します
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag: tag, quality: quality})
+	}
+
+	// 同じ品質値の場合はヘッダーに記載された順を優先する
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return DefaultLocale
+		}
+		if IsSupported(c.tag) {
+			return c.tag
+		}
+		if primary, _, found := strings.Cut(c.tag, "-"); found && IsSupported(primary) {
+			return primary
+		}
+	}
+	return DefaultLocale
+}
+
+// SetLocale はリクエストの言語を設定し、Content-Language ヘッダーに反映します
+func SetLocale(c *gin.Context, locale string) {
+	c.Set(ContextKey, locale)
+	c.Header("Content-Language", locale)
+}
+
+// Locale はリクエストの言語を返します（設定されていない場合はデフォルトの言語）
+func Locale(c *gin.Context) string {
+	if locale := c.GetString(ContextKey); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// T はメッセージキーに対応するリクエストの言語のメッセージを返します
+// ハンドラーのレスポンスの message 等で使用します
+func T(c *gin.Context, key string, args ...interface{}) string {
+	return Translate(Locale(c), key, args...)
+}
+
+// Message は言語を決める前のメッセージです
+// メッセージを生成する処理がリクエストの言語を知らない場合（パスワードポリシーの検証等）に使用します
+type Message struct {
+	Key  string        // メッセージキー
+	Args []interface{} // メッセージに埋め込む値
+}
+
+// NewMessage は新しいMessageを作成します
+func NewMessage(key string, args ...interface{}) Message {
+	return Message{Key: key, Args: args}
+}
+
+// Translate は指定した言語のメッセージを返します
+func (m Message) Translate(locale string) string {
+	return Translate(locale, m.Key, m.Args...)
+}
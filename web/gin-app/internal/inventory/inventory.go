@@ -0,0 +1,168 @@
+// Package inventory は在庫の変更と、在庫の移動（台帳）の記録・照合を提供します
+package inventory
+
+import (
+	"errors"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNegativeStock は変更後の在庫が負になる場合のエラーです
+var ErrNegativeStock = errors.New("inventory: 在庫が不足しています")
+
+// Actor は在庫を変更したユーザーです
+type Actor struct {
+	ID   *uint  // ユーザーのID（APIキー・システムの場合は nil）
+	Name string // ユーザー名（APIキーの場合は api_key:<名前>）
+}
+
+// Change は在庫の変更です
+// VariantID を指定した場合はバリエーションの在庫、指定しない場合は商品の在庫を変更します
+type Change struct {
+	ProductID uint
+	VariantID *uint
+	Delta     int    // 在庫の増減（Set の場合は使用しません）
+	Reason    string // models.StockReason*
+	OrderID   *uint
+	Actor     Actor
+	Note      string
+}
+
+// Apply は在庫を Delta だけ増減し、在庫の移動を記録します
+// 同時に変更されないよう、変更する行をロックします。トランザクション内で呼び出してください
+// 変更後の在庫が負になる場合は ErrNegativeStock を返し、Delta が0の場合は何もしません（nil を返します）
+func Apply(tx *gorm.DB, change Change) (*models.StockMovement, error) {
+	return apply(tx, change, func(stock int) int { return stock + change.Delta })
+}
+
+// Set は在庫を stock にし、現在の在庫との差を在庫の移動として記録します
+// 在庫が変わらない場合は何もしません（nil を返します）
+func Set(tx *gorm.DB, change Change, stock int) (*models.StockMovement, error) {
+	return apply(tx, change, func(int) int { return stock })
+}
+
+// Record は呼び出し元で変更した在庫の移動を記録します
+// 商品・バリエーションの作成時の在庫等、在庫を行の作成と同時に設定した場合に使用します
+// balance には移動後の在庫を指定します。Delta が0の場合は何もしません（nil を返します）
+func Record(tx *gorm.DB, change Change, balance int) (*models.StockMovement, error) {
+	if change.Delta == 0 {
+		return nil, nil
+	}
+
+	movement := models.StockMovement{
+		ProductID: change.ProductID,
+		VariantID: change.VariantID,
+		Delta:     change.Delta,
+		Balance:   balance,
+		Reason:    change.Reason,
+		OrderID:   change.OrderID,
+		ActorID:   change.Actor.ID,
+		ActorName: change.Actor.Name,
+		Note:      change.Note,
+	}
+	if err := tx.Create(&movement).Error; err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// SyncProductStock はバリエーションがある商品の在庫を、バリエーションの在庫の合計に更新します
+// 商品の在庫による絞り込みや、在庫切れ時の非アクティブ化（BeforeSave フック）をそのまま使えるようにします
+// 在庫はバリエーションの在庫の移動として記録済みのため、商品の在庫の移動は記録しません
+func SyncProductStock(tx *gorm.DB, productID uint) error {
+	var total int64
+	if err := tx.Model(&models.ProductVariant{}).
+		Where("product_id = ?", productID).
+		Select("COALESCE(SUM(stock), 0)").
+		Scan(&total).Error; err != nil {
+		return err
+	}
+
+	var product models.Product
+	if err := tx.First(&product, productID).Error; err != nil {
+		return err
+	}
+	product.Stock = int(total)
+	return tx.Save(&product).Error
+}
+
+// DetachProductStock は商品の最初のバリエーションを作成する前に呼び出し、商品の在庫を在庫の移動で0にします
+// バリエーションがある商品の在庫はバリエーションの在庫の合計になるため、それまでの商品の在庫を取り消します
+// 商品の在庫は SyncProductStock で更新するため、ここでは変更しません（非アクティブにしないため）
+func DetachProductStock(tx *gorm.DB, productID uint, actor Actor) error {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	var variants int64
+	if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", productID).Count(&variants).Error; err != nil {
+		return err
+	}
+	if variants > 0 {
+		return nil
+	}
+
+	_, err := Record(tx, Change{
+		ProductID: productID,
+		Delta:     -product.Stock,
+		Reason:    models.StockReasonAdjustment,
+		Actor:     actor,
+		Note:      "バリエーションの在庫での管理に移行",
+	}, 0)
+	return err
+}
+
+// apply は行をロックして在庫を next で計算した値に変更し、在庫の移動を記録します
+func apply(tx *gorm.DB, change Change, next func(stock int) int) (*models.StockMovement, error) {
+	locked := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+
+	// 1. バリエーションの在庫の変更（商品の在庫はバリエーションの在庫の合計に更新）
+	if change.VariantID != nil {
+		var variant models.ProductVariant
+		if err := locked.Where("product_id = ?", change.ProductID).First(&variant, *change.VariantID).Error; err != nil {
+			return nil, err
+		}
+		stock := next(variant.Stock)
+		change.Delta = stock - variant.Stock
+		if change.Delta == 0 {
+			return nil, nil
+		}
+		if stock < 0 {
+			return nil, ErrNegativeStock
+		}
+
+		variant.Stock = stock
+		if err := tx.Save(&variant).Error; err != nil {
+			return nil, err
+		}
+		movement, err := Record(tx, change, stock)
+		if err != nil {
+			return nil, err
+		}
+		return movement, SyncProductStock(tx, change.ProductID)
+	}
+
+	// 2. 商品の在庫の変更（保存時に BeforeSave フックで在庫切れの商品を非アクティブにする）
+	var product models.Product
+	if err := locked.First(&product, change.ProductID).Error; err != nil {
+		return nil, err
+	}
+	stock := next(product.Stock)
+	change.Delta = stock - product.Stock
+	if change.Delta == 0 {
+		return nil, nil
+	}
+	if stock < 0 {
+		return nil, ErrNegativeStock
+	}
+
+	product.Stock = stock
+	if err := tx.Save(&product).Error; err != nil {
+		return nil, err
+	}
+	return Record(tx, change, stock)
+}
@@ -0,0 +1,218 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder は実行したSQLを記録するロガーです
+type sqlRecorder struct {
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface      { return r }
+func (r *sqlRecorder) Info(context.Context, string, ...interface{})  {}
+func (r *sqlRecorder) Warn(context.Context, string, ...interface{})  {}
+func (r *sqlRecorder) Error(context.Context, string, ...interface{}) {}
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// newDryRunDB はSQLを実行しないデータベース接続を作成します
+// 商品・バリエーションの検索結果は、在庫が stock の商品（ID 1）・バリエーション（ID 3）とします
+func newDryRunDB(t *testing.T, stock int) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+	recorder := &sqlRecorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1 user=test dbname=test"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Callback().Query().After("gorm:query").Register("test:rows", func(tx *gorm.DB) {
+		switch dest := tx.Statement.Dest.(type) {
+		case *models.Product:
+			dest.ID, dest.Stock, dest.IsActive = 1, stock, stock > 0
+		case *models.ProductVariant:
+			dest.ID, dest.ProductID, dest.Stock = 3, 1, stock
+		default:
+			return
+		}
+		tx.RowsAffected = 1
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return db, recorder
+}
+
+// TestApply は在庫の変更で、行をロックしてから在庫を保存し、在庫の移動を記録することを確認します
+// 変更前の在庫は3とします
+func TestApply(t *testing.T) {
+	variantID := uint(3)
+	userID := uint(7)
+
+	tests := []struct {
+		name        string
+		change      Change
+		set         *int // Set の場合の在庫
+		wantErr     error
+		wantDelta   int // 記録する在庫の移動の増減（0の場合は記録しない）
+		wantBalance int
+		wantSQL     []string // 実行するSQL（先頭の一致）
+	}{
+		{
+			name:        "product_increase",
+			change:      Change{ProductID: 1, Delta: 5, Reason: models.StockReasonAdjustment, Actor: Actor{ID: &userID, Name: "admin"}},
+			wantDelta:   5,
+			wantBalance: 8,
+			wantSQL:     []string{`SELECT * FROM "products"`, `UPDATE "products"`, `INSERT INTO "stock_movements"`},
+		},
+		{
+			name:        "product_sell_out",
+			change:      Change{ProductID: 1, Delta: -3, Reason: models.StockReasonSale},
+			wantDelta:   -3,
+			wantBalance: 0,
+			wantSQL:     []string{`SELECT * FROM "products"`, `UPDATE "products"`, `INSERT INTO "stock_movements"`},
+		},
+		{
+			name:    "product_negative",
+			change:  Change{ProductID: 1, Delta: -4, Reason: models.StockReasonSale},
+			wantErr: ErrNegativeStock,
+			wantSQL: []string{`SELECT * FROM "products"`},
+		},
+		{
+			name:    "product_zero_delta",
+			change:  Change{ProductID: 1, Reason: models.StockReasonAdjustment},
+			wantSQL: []string{`SELECT * FROM "products"`},
+		},
+		{
+			name:        "product_set",
+			change:      Change{ProductID: 1, Delta: 100, Reason: models.StockReasonAdjustment},
+			set:         intPtr(1),
+			wantDelta:   -2,
+			wantBalance: 1,
+			wantSQL:     []string{`SELECT * FROM "products"`, `UPDATE "products"`, `INSERT INTO "stock_movements"`},
+		},
+		{
+			name:    "product_set_unchanged",
+			change:  Change{ProductID: 1, Reason: models.StockReasonAdjustment},
+			set:     intPtr(3),
+			wantSQL: []string{`SELECT * FROM "products"`},
+		},
+		{
+			name:    "product_set_negative",
+			change:  Change{ProductID: 1, Reason: models.StockReasonAdjustment},
+			set:     intPtr(-1),
+			wantErr: ErrNegativeStock,
+			wantSQL: []string{`SELECT * FROM "products"`},
+		},
+		{
+			// 商品の在庫（バリエーションの在庫の合計）はSQLを実行しないと取得できないため、
+			// 合計の取得までを確認する
+			name:        "variant_increase",
+			change:      Change{ProductID: 1, VariantID: &variantID, Delta: 2, Reason: models.StockReasonReturn},
+			wantErr:     gorm.ErrDryRunModeUnsupported,
+			wantDelta:   2,
+			wantBalance: 5,
+			wantSQL: []string{
+				`SELECT * FROM "product_variants"`, `UPDATE "product_variants"`, `INSERT INTO "stock_movements"`,
+				`SELECT COALESCE(SUM(stock), 0) FROM "product_variants"`,
+			},
+		},
+		{
+			name:    "variant_negative",
+			change:  Change{ProductID: 1, VariantID: &variantID, Delta: -4, Reason: models.StockReasonSale},
+			wantErr: ErrNegativeStock,
+			wantSQL: []string{`SELECT * FROM "product_variants"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, recorder := newDryRunDB(t, 3)
+
+			var movement *models.StockMovement
+			var err error
+			if tt.set != nil {
+				movement, err = Set(db, tt.change, *tt.set)
+			} else {
+				movement, err = Apply(db, tt.change)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err: got %v, want %v", err, tt.wantErr)
+			}
+
+			if len(recorder.statements) != len(tt.wantSQL) {
+				t.Fatalf("SQL: got %q, want %d statements", recorder.statements, len(tt.wantSQL))
+			}
+			for i, want := range tt.wantSQL {
+				if !strings.HasPrefix(recorder.statements[i], want) {
+					t.Errorf("SQL[%d]: got %q, want prefix %q", i, recorder.statements[i], want)
+				}
+			}
+			// 変更する行は最初にロックする
+			if !strings.HasSuffix(recorder.statements[0], "FOR UPDATE") {
+				t.Errorf("行をロックしていません: %q", recorder.statements[0])
+			}
+
+			if tt.wantDelta == 0 {
+				if movement != nil {
+					t.Errorf("movement: got %+v, want nil", movement)
+				}
+				return
+			}
+			if movement == nil || movement.Delta != tt.wantDelta || movement.Balance != tt.wantBalance ||
+				movement.Reason != tt.change.Reason || movement.ActorName != tt.change.Actor.Name {
+				t.Errorf("movement: got %+v, want delta %d, balance %d", movement, tt.wantDelta, tt.wantBalance)
+			}
+		})
+	}
+}
+
+// TestRecordZeroDelta は増減がない場合に在庫の移動を記録しないことを確認します
+func TestRecordZeroDelta(t *testing.T) {
+	db, recorder := newDryRunDB(t, 0)
+
+	movement, err := Record(db, Change{ProductID: 1, Reason: models.StockReasonInitial}, 0)
+	if err != nil || movement != nil {
+		t.Errorf("got (%+v, %v), want (nil, nil)", movement, err)
+	}
+	if len(recorder.statements) != 0 {
+		t.Errorf("SQL: got %q", recorder.statements)
+	}
+}
+
+// TestDriftDiff は現在の在庫から在庫の移動の合計を引いた差を返すことを確認します
+func TestDriftDiff(t *testing.T) {
+	tests := []struct {
+		stock, ledger, want int
+	}{
+		{10, 10, 0},
+		{12, 10, 2},
+		{3, 8, -5},
+		{0, -2, 2},
+	}
+	for _, tt := range tests {
+		if got := (Drift{Stock: tt.stock, LedgerStock: tt.ledger}).Diff(); got != tt.want {
+			t.Errorf("Drift{%d, %d}.Diff(): got %d, want %d", tt.stock, tt.ledger, got, tt.want)
+		}
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
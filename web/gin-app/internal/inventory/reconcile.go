@@ -0,0 +1,102 @@
+// Package inventory は在庫の変更と、在庫の移動（台帳）の記録・照合を提供します
+package inventory
+
+import (
+	"go_learning/web/gin-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Drift は現在の在庫と、在庫の移動の合計から再計算した在庫の差です
+type Drift struct {
+	ProductID   uint
+	VariantID   *uint // バリエーションの在庫の場合のみ
+	SKU         string
+	Stock       int // 現在の在庫
+	LedgerStock int // 在庫の移動の合計
+}
+
+// Diff は現在の在庫と再計算した在庫の差（現在の在庫 - 再計算した在庫）を返します
+func (d Drift) Diff() int {
+	return d.Stock - d.LedgerStock
+}
+
+// Reconcile は在庫の移動の合計から在庫を再計算し、現在の在庫と一致しない商品・バリエーションを返します
+// 商品の在庫はその商品の全ての移動（バリエーションの移動を含む）の合計、
+// バリエーションの在庫はそのバリエーションの移動の合計と比較します（削除済みの商品・バリエーションは除く）
+func Reconcile(db *gorm.DB) ([]Drift, error) {
+	var drifts []Drift
+
+	// 1. 商品の在庫
+	if err := db.Model(&models.Product{}).
+		Select("products.id AS product_id, products.sku, products.stock, COALESCE(SUM(stock_movements.delta), 0) AS ledger_stock").
+		Joins("LEFT JOIN stock_movements ON stock_movements.product_id = products.id").
+		Group("products.id").
+		Having("products.stock <> COALESCE(SUM(stock_movements.delta), 0)").
+		Order("products.id").
+		Scan(&drifts).Error; err != nil {
+		return nil, err
+	}
+
+	// 2. バリエーションの在庫
+	var variantDrifts []Drift
+	if err := db.Model(&models.ProductVariant{}).
+		Select("product_variants.product_id, product_variants.id AS variant_id, product_variants.sku, product_variants.stock, " +
+			"COALESCE(SUM(stock_movements.delta), 0) AS ledger_stock").
+		Joins("LEFT JOIN stock_movements ON stock_movements.variant_id = product_variants.id").
+		Group("product_variants.id").
+		Having("product_variants.stock <> COALESCE(SUM(stock_movements.delta), 0)").
+		Order("product_variants.product_id, product_variants.id").
+		Scan(&variantDrifts).Error; err != nil {
+		return nil, err
+	}
+
+	return append(drifts, variantDrifts...), nil
+}
+
+// Repair は在庫を、在庫の移動の合計から再計算した在庫に修正します
+// 在庫の移動は正しいものとして扱うため、在庫の移動は記録しません
+// バリエーションを修正した商品の在庫は、バリエーションの在庫の合計に更新します
+func Repair(tx *gorm.DB, drifts []Drift) error {
+	synced := make(map[uint]bool)
+	for _, d := range drifts {
+		if d.VariantID != nil {
+			if err := tx.Model(&models.ProductVariant{}).Where("id = ?", *d.VariantID).
+				UpdateColumn("stock", d.LedgerStock).Error; err != nil {
+				return err
+			}
+			if err := SyncProductStock(tx, d.ProductID); err != nil {
+				return err
+			}
+			synced[d.ProductID] = true
+		}
+	}
+
+	for _, d := range drifts {
+		if d.VariantID != nil || synced[d.ProductID] {
+			continue
+		}
+
+		// バリエーションがある商品の在庫はバリエーションの在庫の合計とする
+		var variants int64
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", d.ProductID).Count(&variants).Error; err != nil {
+			return err
+		}
+		if variants > 0 {
+			if err := SyncProductStock(tx, d.ProductID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var product models.Product
+		if err := tx.First(&product, d.ProductID).Error; err != nil {
+			return err
+		}
+		product.Stock = d.LedgerStock
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Package mailer はメール送信機能を提供します
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer はメールを送信せずにファイルまたは標準出力へ書き出すMailerです
+// ローカル開発環境でメールの内容を確認するために使用します
+type LogMailer struct {
+	from string
+	out  io.Writer
+	mu   sync.Mutex // 複数のメールが混ざらないように書き込みを直列化
+}
+
+// NewLogMailer は新しいLogMailerを作成します
+// path が空の場合は標準出力に、指定された場合はそのファイルに追記します
+func NewLogMailer(from, path string) (*LogMailer, error) {
+	var out io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("メール出力ファイルを開けません: %w", err)
+		}
+		out = f
+	}
+
+	return &LogMailer{from: from, out: out}, nil
+}
+
+// Send はメールの内容を書き出します
+func (m *LogMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.out,
+		"----- MAIL %s -----\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n-------------------\n",
+		time.Now().Format(time.RFC3339), m.from, msg.To, msg.Subject, msg.Body,
+	)
+	return err
+}
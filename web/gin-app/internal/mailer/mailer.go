@@ -0,0 +1,35 @@
+// Package mailer はメール送信機能を提供します
+// 送信方式は Mailer インターフェースで抽象化されており、
+// 設定に応じてファイル/標準出力への書き出しやSMTP送信を切り替えられます
+package mailer
+
+import (
+	"fmt"
+
+	"go_learning/web/gin-app/internal/config"
+)
+
+// Message は送信するメールの内容です
+type Message struct {
+	To      string // 宛先アドレス
+	Subject string // 件名
+	Body    string // 本文（プレーンテキスト）
+}
+
+// Mailer はメール送信を行うインターフェースです
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New は設定に応じたMailerを作成します
+// MAIL_DRIVER=log の場合は LogMailer、smtp の場合は SMTPMailer を返します
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "log":
+		return NewLogMailer(cfg.From, cfg.FilePath)
+	case "smtp":
+		return NewSMTPMailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("不明なメール送信方式です: %s", cfg.Driver)
+	}
+}
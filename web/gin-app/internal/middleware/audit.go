@@ -0,0 +1,48 @@
+// Package middleware はHTTPリクエストの前処理・後処理を提供します
+package middleware
+
+import (
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationHeader はなりすまし中のレスポンスに付与するヘッダーです
+// 値はなりすましを行っているユーザーのユーザー名です
+const ImpersonationHeader = "X-Impersonated-By"
+
+// auditPathMaxLength は監査ログに記録するパスの最大長です
+const auditPathMaxLength = 500
+
+// ImpersonationAuditMiddleware はなりすまし中のリクエストを監査ログに記録するミドルウェアです
+// 認証はルートごとに行われるため、全てのルートより前に登録し、
+// レスポンス後にコンテキストのなりすまし情報を確認して記録します
+func ImpersonationAuditMiddleware(audit *auth.AuditLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		actorID, impersonating := c.Get("actor_id")
+		if !impersonating {
+			return
+		}
+
+		path := c.Request.URL.RequestURI()
+		if len(path) > auditPathMaxLength {
+			path = path[:auditPathMaxLength]
+		}
+
+		// 記録に失敗してもレスポンスは返却済みのため、AuditLogger のログ出力のみとする
+		_ = audit.Record(&models.AuditLog{
+			Action:     models.AuditActionImpersonationRequest,
+			ActorID:    actorID.(uint),
+			ActorName:  c.GetString("actor_username"),
+			UserID:     c.GetUint("user_id"),
+			Method:     c.Request.Method,
+			Path:       path,
+			StatusCode: responseStatus(c),
+			IPAddress:  c.ClientIP(),
+			RequestID:  c.GetString("request_id"),
+		})
+	}
+}
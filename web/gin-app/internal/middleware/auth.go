@@ -2,83 +2,145 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
 	"strings"
+	"time"
 
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
 	"go_learning/web/gin-app/internal/config"
+	"go_learning/web/gin-app/internal/models"
 	"go_learning/web/gin-app/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware はJWTトークンによる認証を行うミドルウェアです
+// AuthMiddleware はJWTトークンまたはAPIキーによる認証を行うミドルウェアです
 // リクエストヘッダーの Authorization: Bearer <token> からトークンを取得し、
 // 検証に成功した場合はユーザー情報をコンテキストに設定します
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// 失効済み（ログアウト済み）のトークンや、無効化・削除されたユーザーのトークンは拒否します
+// X-API-Key ヘッダーがある場合は、サービス間連携用のAPIキーとして検証します
+// ログインセッションに紐付くトークンの場合は、セッションの最終アクセス日時を更新します
+func AuthMiddleware(keys *auth.KeyManager, revocations *auth.RevocationStore, sessions *auth.SessionStore, apiKeys *auth.APIKeyStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 0. APIキーによる認証（サービス間連携用）
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			apiKey, err := apiKeys.Authenticate(key)
+			if err != nil {
+				apperr.Abort(c, apiKeyError(err))
+				return
+			}
+
+			setAPIKey(c, apiKey)
+			c.Next()
+			return
+		}
+
 		// 1. Authorizationヘッダーの取得
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "認証ヘッダーがありません",
-			})
-			c.Abort() // 以降の処理を中断
+			apperr.Abort(c, apperr.ErrAuthHeaderMissing)
 			return
 		}
 
 		// 2. "Bearer <token>" 形式のチェック
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "無効な認証ヘッダー形式です",
-			})
-			c.Abort()
+			apperr.Abort(c, apperr.ErrAuthHeaderInvalid)
 			return
 		}
 
 		tokenString := parts[1]
 
-		// 3. JWTトークンの検証
-		claims, err := utils.ValidateJWT(tokenString, cfg.JWT.SecretKey)
+		// 3. JWTトークンの検証（署名・有効期限・失効・ユーザー状態）
+		claims, err := authenticateToken(tokenString, keys, revocations)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "無効なトークンです: " + err.Error(),
-			})
-			c.Abort()
+			apperr.Abort(c, err)
 			return
 		}
 
-		// 4. ユーザー情報をコンテキストに設定
+		// 4. ユーザー情報と言語の設定をコンテキストに設定
 		// ハンドラーでc.Get("user_id")等で取得可能になります
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("role", claims.Role)
+		setClaims(c, claims)
+		applyUserLocale(c, revocations, claims)
+		if claims.SessionID != "" {
+			sessions.Touch(claims.SessionID)
+		}
 
 		// 5. 次のミドルウェアまたはハンドラーを実行
 		c.Next()
 	}
 }
 
-// AdminMiddleware は管理者権限をチェックするミドルウェアです
+// RequirePermission は指定した権限を全て持っているかをチェックするミドルウェアです
 // AuthMiddleware の後に実行する必要があります
-func AdminMiddleware() gin.HandlerFunc {
+// ユーザーのロールに割り当てられた権限は PermissionStore から取得します
+// AUTH_REQUIRE_ADMIN_2FA が有効な場合は、二要素認証を経て発行されたトークンも必要です
+func RequirePermission(cfg *config.Config, permissions *auth.PermissionStore, required ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// コンテキストからロールを取得
-		role, exists := c.Get("role")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "認証が必要です",
-			})
-			c.Abort()
+		// 認証済みかのチェック（ユーザーまたはAPIキー）
+		_, isUser := c.Get("role")
+		_, isAPIKey := c.Get("api_key_id")
+		if !isUser && !isAPIKey {
+			apperr.Abort(c, apperr.ErrAuthRequired)
 			return
 		}
 
-		// 管理者権限のチェック
-		if role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "管理者権限が必要です",
-			})
-			c.Abort()
+		// 権限のチェック
+		for _, permission := range required {
+			if !HasPermission(c, permissions, permission) {
+				apperr.Abort(c, apperr.ErrPermissionDenied.With("permission", permission))
+				return
+			}
+		}
+
+		// 二要素認証のチェック（APIキーは対話的なログインではないため対象外）
+		if cfg.Auth.RequireAdmin2FA && !isAPIKey && !c.GetBool("mfa") {
+			apperr.Abort(c, apperr.ErrMFARequired)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HasPermission はリクエストの認証主体が指定した権限を持つかどうかを返します
+// APIキーの場合はキーのスコープ、ユーザーの場合はロールの権限で判定します
+func HasPermission(c *gin.Context, permissions *auth.PermissionStore, permission string) bool {
+	if scopes, ok := c.Get("api_key_scopes"); ok {
+		for _, scope := range scopes.([]string) {
+			if scope == permission {
+				return true
+			}
+		}
+		return false
+	}
+
+	return permissions.HasPermission(c.GetString("role"), permission)
+}
+
+// RequireUser はユーザーとしてのログインを必須にするミドルウェアです
+// AuthMiddleware の後に実行する必要があります
+// プロフィールや注文作成など、APIキーでは利用できないエンドポイントで使用します
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("user_id"); !exists {
+			apperr.Abort(c, apperr.ErrUserLoginRequired)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RejectImpersonation はなりすまし中のリクエストを拒否するミドルウェアです
+// AuthMiddleware の後に実行する必要があります
+// パスワード変更やアカウント削除など、本人以外が行うべきでない操作に使用します
+func RejectImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonating := c.Get("actor_id"); impersonating {
+			apperr.Abort(c, apperr.ErrImpersonationForbidden)
 			return
 		}
 
@@ -89,7 +151,7 @@ func AdminMiddleware() gin.HandlerFunc {
 // OptionalAuthMiddleware はオプショナルな認証ミドルウェアです
 // トークンがあれば検証し、なければ次の処理に進みます
 // 公開/非公開コンテンツを同じエンドポイントで扱う場合に便利です
-func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+func OptionalAuthMiddleware(keys *auth.KeyManager, revocations *auth.RevocationStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -101,15 +163,118 @@ func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) == 2 && parts[0] == "Bearer" {
 			tokenString := parts[1]
-			claims, err := utils.ValidateJWT(tokenString, cfg.JWT.SecretKey)
+			claims, err := authenticateToken(tokenString, keys, revocations)
 			if err == nil {
 				// 有効なトークンの場合のみコンテキストに設定
-				c.Set("user_id", claims.UserID)
-				c.Set("username", claims.Username)
-				c.Set("role", claims.Role)
+				setClaims(c, claims)
+				applyUserLocale(c, revocations, claims)
 			}
 		}
 
 		c.Next()
 	}
 }
+
+// authenticateToken はJWTトークンを検証し、サーバー側の状態も含めて利用可能かを判定します
+// 署名と有効期限に加えて、jti・ログインセッションの失効とユーザーの有効状態をチェックします
+// 返すエラーは全て *apperr.AppError です
+func authenticateToken(tokenString string, keys *auth.KeyManager, revocations *auth.RevocationStore) (*utils.JWTClaims, error) {
+	claims, err := utils.ValidateJWT(tokenString, keys)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, apperr.ErrTokenExpired.WithCause(err)
+		}
+		return nil, apperr.ErrTokenInvalid.WithCause(err)
+	}
+
+	// 二要素認証待ちトークン等、アクセストークン以外は受け付けない
+	if claims.TokenType != utils.TokenTypeAccess {
+		return nil, apperr.ErrTokenInvalid.WithCause(errors.New("アクセストークンではありません"))
+	}
+
+	// jtiのないトークンは失効させられないため受け付けない
+	if claims.ID == "" {
+		return nil, apperr.ErrTokenInvalid.WithCause(errors.New("トークンIDがありません"))
+	}
+
+	if revocations.IsRevoked(claims.ID) {
+		return nil, apperr.ErrTokenRevoked
+	}
+
+	// ログアウト等で失効したセッションのトークンは、ローテーション前のものも含めて拒否する
+	if claims.SessionID != "" && revocations.IsSessionRevoked(claims.SessionID) {
+		return nil, apperr.ErrSessionRevoked
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	if err := revocations.CheckUser(claims.UserID, issuedAt); err != nil {
+		return nil, userStateError(err)
+	}
+
+	// なりすまし用トークンは、なりすましを行っているユーザーも利用可能である必要がある
+	if claims.Actor != nil {
+		if err := revocations.CheckUser(claims.Actor.UserID, issuedAt); err != nil {
+			return nil, userStateError(err)
+		}
+	}
+
+	return claims, nil
+}
+
+// userStateError は RevocationStore.CheckUser のエラーをレスポンス用のエラーに変換します
+// ユーザーの状態を確認できなかった場合（データベースのエラー）は INTERNAL_ERROR にします
+func userStateError(err error) *apperr.AppError {
+	switch {
+	case errors.Is(err, auth.ErrSessionsRevoked):
+		return apperr.ErrTokenInvalidated
+	case errors.Is(err, auth.ErrUserInactive):
+		return apperr.ErrAccountUnavailable.WithCause(err)
+	}
+	return apperr.ErrUserFetchFailed.WithCause(err)
+}
+
+// setClaims はトークンのクレームをコンテキストに設定します
+func setClaims(c *gin.Context, claims *utils.JWTClaims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Set("jti", claims.ID)
+	c.Set("mfa", claims.MFA)
+	c.Set("sid", claims.SessionID)
+	if claims.ExpiresAt != nil {
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+	}
+
+	// なりすまし中であることをクライアントに明示する
+	if claims.Actor != nil {
+		c.Set("actor_id", claims.Actor.UserID)
+		c.Set("actor_username", claims.Actor.Username)
+		c.Header(ImpersonationHeader, claims.Actor.Username)
+	}
+}
+
+// setAPIKey はAPIキーの情報をコンテキストに設定します
+// APIキーはユーザーに紐付かないため user_id と role は設定しません
+func setAPIKey(c *gin.Context, apiKey *models.APIKey) {
+	c.Set("api_key_id", apiKey.ID)
+	c.Set("api_key_scopes", apiKey.Scopes)
+	c.Set("username", "api_key:"+apiKey.Name)
+}
+
+// apiKeyError はAPIキー認証のエラーをレスポンス用のエラーに変換します
+// データベースエラー等の詳細はクライアントに返しません
+func apiKeyError(err error) *apperr.AppError {
+	switch {
+	case errors.Is(err, auth.ErrAPIKeyInvalid):
+		return apperr.ErrAPIKeyInvalid
+	case errors.Is(err, auth.ErrAPIKeyExpired):
+		return apperr.ErrAPIKeyExpired
+	case errors.Is(err, auth.ErrAPIKeyRevoked):
+		return apperr.ErrAPIKeyRevoked
+	default:
+		return apperr.ErrAPIKeyVerifyFailed.WithCause(err)
+	}
+}
@@ -42,6 +42,7 @@ func CORSMiddleware() gin.HandlerFunc {
 		ExposeHeaders: []string{
 			"Content-Length",
 			"Content-Type",
+			ImpersonationHeader, // なりすまし中の表示に使用
 		},
 
 		// クレデンシャル（Cookie等）の送信を許可
@@ -0,0 +1,85 @@
+// Package middleware はHTTPリクエストの前処理・後処理を提供します
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType はエラーレスポンスの Content-Type です（RFC 7807）
+const ProblemContentType = "application/problem+json"
+
+// ErrorTypeBase はエラーレスポンスの type に使用するURIの接頭辞です
+// エラーコードを付けたURIで、エラーコードの説明を取得できます
+const ErrorTypeBase = "/api/v1/errors/"
+
+// ErrorHandlerMiddleware はハンドラーが記録したエラーをレスポンスに変換するミドルウェアです
+// apperr.Abort で記録されたエラーを application/problem+json 形式で返します
+// AppError 以外のエラーは詳細を隠して INTERNAL_ERROR として返します
+func ErrorHandlerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		// エラーがない場合や、ハンドラーが既にレスポンスを返している場合は何もしない
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		WriteProblem(c, apperr.From(c.Errors.Last().Err))
+	}
+}
+
+// responseStatus はレスポンスのステータスコードを返します
+// ErrorHandlerMiddleware より内側のミドルウェアでは、エラーレスポンスはまだ書き込まれていないため、
+// 記録されたエラーのステータスコードを返します
+func responseStatus(c *gin.Context) int {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return c.Writer.Status()
+	}
+	return apperr.From(c.Errors.Last().Err).Status
+}
+
+// RecoveryMiddleware はパニックから復旧し、500 Internal Server Error を返すミドルウェアです
+// gin.Recovery と同様にスタックトレースをログに出力し、
+// レスポンスは他のエラーと同じ application/problem+json 形式（リクエストIDを含む）で返します
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		if c.Writer.Written() {
+			c.Abort()
+			return
+		}
+		WriteProblem(c, apperr.ErrInternal.WithCause(fmt.Errorf("panic: %v", recovered)))
+		c.Abort()
+	})
+}
+
+// WriteProblem はエラーを application/problem+json 形式のレスポンスとして返します
+// メッセージはリクエストの言語で返します
+// 500 Internal Server Error の場合は元のエラーをログに出力します（クライアントには返しません）
+func WriteProblem(c *gin.Context, err *apperr.AppError) {
+	requestID := c.GetString("request_id")
+	if err.Status >= http.StatusInternalServerError {
+		log.Printf("サーバーエラー: request_id=%s %s %s: %v", requestID, c.Request.Method, c.Request.URL.Path, err)
+	}
+
+	message := err.Message(i18n.Locale(c))
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(err.Status, utils.ErrorResponse{
+		Type:       ErrorTypeBase + err.Code,
+		Title:      message,
+		Status:     err.Status,
+		Code:       err.Code,
+		Instance:   c.Request.URL.Path,
+		RequestID:  requestID,
+		Error:      message,
+		Details:    err.Details,
+		Extensions: err.Extensions,
+	})
+}
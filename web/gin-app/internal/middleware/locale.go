@@ -0,0 +1,36 @@
+// Package middleware はHTTPリクエストの前処理・後処理を提供します
+package middleware
+
+import (
+	"go_learning/web/gin-app/internal/auth"
+	"go_learning/web/gin-app/internal/i18n"
+	"go_learning/web/gin-app/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware はリクエストの言語を決定するミドルウェアです
+// Accept-Language ヘッダーから対応している言語を選び、エラーメッセージ等に使用します
+// 選んだ言語は Content-Language ヘッダーで返します
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		i18n.SetLocale(c, i18n.Negotiate(c.GetHeader("Accept-Language")))
+
+		// レスポンスが Accept-Language によって変わることをキャッシュに伝える
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		c.Next()
+	}
+}
+
+// applyUserLocale はユーザーが設定した言語をリクエストの言語にします
+// ユーザーの設定は Accept-Language より優先します
+// なりすまし中は、操作している管理者が読めるよう Accept-Language の言語を使用します
+func applyUserLocale(c *gin.Context, revocations *auth.RevocationStore, claims *utils.JWTClaims) {
+	if claims.Actor != nil {
+		return
+	}
+	if locale := revocations.UserLocale(claims.UserID); i18n.IsSupported(locale) {
+		i18n.SetLocale(c, locale)
+	}
+}
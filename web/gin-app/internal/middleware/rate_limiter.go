@@ -2,10 +2,11 @@
 package middleware
 
 import (
-	"net/http"
 	"sync"
 	"time"
 
+	"go_learning/web/gin-app/internal/apperr"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -47,10 +48,7 @@ func (rl *RateLimiter) RateLimitMiddleware() gin.HandlerFunc {
 
 		// レート制限チェック
 		if !rl.allowRequest(ip) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "リクエスト数が多すぎます。しばらく待ってから再試行してください。",
-			})
-			c.Abort()
+			apperr.Abort(c, apperr.ErrRateLimited)
 			return
 		}
 
@@ -0,0 +1,53 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// APIKey はサービス間連携用のAPIキーを表すモデルです
+// 倉庫システムやバッチ処理など、ユーザーとしてログインしないクライアントが使用します
+// キー本体は保存せず、SHA-256ハッシュと表示用のプレフィックスのみを保存します
+type APIKey struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name      string   `gorm:"not null;size:100" json:"name"`           // 用途を表す名前
+	Prefix    string   `gorm:"not null;size:20" json:"prefix"`          // 表示用のキー先頭部分
+	KeyHash   string   `gorm:"uniqueIndex;not null;size:64" json:"-"`   // キーのハッシュ値
+	Scopes    []string `gorm:"serializer:json;type:text" json:"scopes"` // 許可する権限（例: orders:update_status）
+	CreatedBy uint     `gorm:"not null;index" json:"created_by"`        // 作成したユーザーのID
+
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`   // 有効期限（nilの場合は無期限）
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // 最後に使用された日時
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`   // 失効日時
+}
+
+// APIKeyCreateRequest はAPIキー作成時のリクエストボディです
+// expires_in_days を省略した場合は無期限のキーになります
+type APIKeyCreateRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
+}
+
+// IsExpired はAPIキーが有効期限切れかどうかを返します
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// IsRevoked はAPIキーが失効済みかどうかを返します
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// HasScope はAPIキーが指定した権限を持つかどうかを返します
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
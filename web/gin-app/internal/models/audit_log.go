@@ -0,0 +1,37 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// 監査ログのアクション
+const (
+	AuditActionImpersonationStart   = "impersonation.start"   // なりすましの開始（トークンの発行）
+	AuditActionImpersonationRequest = "impersonation.request" // なりすまし中のリクエスト
+)
+
+// AuditLog は監査ログを表すモデルです
+// 誰が（ActorID）、誰に対して（UserID）、どのような操作を行ったかを記録します
+// 記録したログは変更・削除しません
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	Action     string `gorm:"not null;size:50;index" json:"action"` // 操作の種類（例: impersonation.request）
+	ActorID    uint   `gorm:"not null;index" json:"actor_id"`       // 操作を行ったユーザーのID
+	ActorName  string `gorm:"size:50" json:"actor_name"`            // 操作を行ったユーザーのユーザー名（記録時点）
+	UserID     uint   `gorm:"index" json:"user_id"`                 // 操作の対象ユーザーのID
+	Method     string `gorm:"size:10" json:"method,omitempty"`      // HTTPメソッド
+	Path       string `gorm:"size:500" json:"path,omitempty"`       // リクエストのパス（クエリを含む）
+	StatusCode int    `json:"status_code,omitempty"`                // レスポンスのステータスコード
+	IPAddress  string `gorm:"size:45" json:"ip_address"`            // クライアントのIPアドレス
+	RequestID  string `gorm:"size:64" json:"request_id,omitempty"`  // リクエストID（X-Request-ID）
+	Details    string `gorm:"size:500" json:"details,omitempty"`    // 補足情報（なりすましの理由等）
+}
+
+// ImpersonateRequest はなりすまし開始時のリクエストボディです
+// 理由は監査ログに記録されます
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
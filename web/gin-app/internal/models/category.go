@@ -0,0 +1,107 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"go_learning/web/gin-app/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// defaultCategorySlug は名前から英数字のスラッグを作成できない場合（日本語の名前等）のスラッグです
+const defaultCategorySlug = "category"
+
+// Category は商品カテゴリーを表すモデルです
+// parent_id で親カテゴリーを指定し、階層構造（ツリー）を表します
+type Category struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string `gorm:"not null;size:50" json:"name"`              // カテゴリー名
+	Slug        string `gorm:"uniqueIndex;not null;size:100" json:"slug"` // URL等で使用する識別子（一意）
+	Description string `gorm:"type:text" json:"description"`              // 説明
+	ParentID    *uint  `gorm:"index" json:"parent_id"`                    // 親カテゴリーのID（最上位は null）
+	SortOrder   int    `gorm:"not null;default:0" json:"sort_order"`      // 同じ親の中での表示順（小さい順）
+}
+
+// CategoryNode はカテゴリーツリーの1つのカテゴリーです
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"` // 子カテゴリー（表示順）
+}
+
+// CategoryCreateRequest はカテゴリー作成時のリクエストボディです
+// slug を省略した場合は名前から作成します
+type CategoryCreateRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=50"`
+	Slug        string `json:"slug" binding:"omitempty,slug"`
+	Description string `json:"description" binding:"max=1000"`
+	ParentID    *uint  `json:"parent_id"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+// CategoryUpdateRequest はカテゴリー更新時のリクエストボディです
+// parent_id に 0 を指定した場合は最上位のカテゴリーにします
+type CategoryUpdateRequest struct {
+	Name        string  `json:"name" binding:"omitempty,min=1,max=50"`
+	Slug        string  `json:"slug" binding:"omitempty,slug"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	ParentID    *uint   `json:"parent_id"`
+	SortOrder   *int    `json:"sort_order"`
+}
+
+// BeforeCreate は作成前に実行されるGORMフックです
+// スラッグが指定されていない場合は、名前から重複しないスラッグを作成します
+func (c *Category) BeforeCreate(tx *gorm.DB) error {
+	if c.Slug != "" {
+		return nil
+	}
+
+	base := utils.Slugify(c.Name)
+	if base == "" {
+		base = defaultCategorySlug
+	}
+
+	// 同じスラッグがある場合は末尾に連番を付ける（例: books-2）
+	var taken []string
+	if err := tx.Session(&gorm.Session{NewDB: true}).Model(&Category{}).
+		Where("slug = ? OR slug LIKE ?", base, base+"-%").
+		Pluck("slug", &taken).Error; err != nil {
+		return err
+	}
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	c.Slug = base
+	for n := 2; used[c.Slug]; n++ {
+		c.Slug = base + "-" + strconv.Itoa(n)
+	}
+	return nil
+}
+
+// BuildCategoryTree はカテゴリーの一覧からツリーを作成し、最上位のカテゴリーを返します
+// categories は表示順（sort_order, name）に並べて渡してください。子カテゴリーもその順に並びます
+func BuildCategoryTree(categories []Category) []*CategoryNode {
+	nodes := make(map[uint]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{Category: category, Children: []*CategoryNode{}}
+	}
+
+	roots := []*CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
@@ -43,11 +43,18 @@ type OrderItem struct {
 	ProductID uint           `gorm:"not null;index" json:"product_id"`
 	Product   Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"` // リレーション
 
+	VariantID *uint           `gorm:"index" json:"variant_id,omitempty"`                   // バリエーションのID（バリエーションがある商品のみ）
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID" json:"variant,omitempty"`       // リレーション
+
 	Quantity  int            `gorm:"not null" json:"quantity"`                 // 数量
 	Price     float64        `gorm:"not null;type:decimal(10,2)" json:"price"` // 単価（注文時の価格）
 	Subtotal  float64        `gorm:"type:decimal(10,2)" json:"subtotal"`       // 小計
 }
 
+// ErasedAddress は個人情報の削除後に住所の代わりに設定する値です
+// 注文番号・金額・明細は会計上の記録として保持し、住所のみを削除します
+const ErasedAddress = "（削除済み）"
+
 // OrderStatus は注文ステータスの定数です
 const (
 	OrderStatusPending   = "pending"    // 保留中
@@ -66,8 +73,9 @@ type OrderCreateRequest struct {
 
 // OrderItemRequest は注文明細のリクエストです
 type OrderItemRequest struct {
-	ProductID uint `json:"product_id" binding:"required,gt=0"`
-	Quantity  int  `json:"quantity" binding:"required,gt=0"`
+	ProductID uint  `json:"product_id" binding:"required,gt=0"`
+	VariantID *uint `json:"variant_id" binding:"omitempty,gt=0"` // バリエーションがある商品の場合は必須
+	Quantity  int   `json:"quantity" binding:"required,gt=0"`
 }
 
 // OrderUpdateStatusRequest は注文ステータス更新のリクエストです
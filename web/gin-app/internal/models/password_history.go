@@ -0,0 +1,27 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// PasswordHistory は過去に設定されたパスワードのハッシュ値を表すモデルです
+// パスワードの変更時に直近のパスワードが再利用されていないかの確認に使用します
+// 保持する件数は AUTH_PASSWORD_HISTORY で設定し、古いものから削除されます
+type PasswordHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 外部キー: ユーザーID
+	UserID uint `gorm:"not null;index" json:"user_id"`
+	User   User `gorm:"foreignKey:UserID" json:"-"` // リレーション
+
+	PasswordHash string `gorm:"not null;size:255" json:"-"` // bcryptでハッシュ化したパスワード
+}
+
+// PasswordChangeRequest はパスワード変更時のリクエストボディです
+// 新しいパスワードの強度はパスワードポリシーで検証します
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,max=100,strong_password"`
+}
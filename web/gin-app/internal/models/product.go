@@ -19,12 +19,68 @@ type Product struct {
 	Price       float64        `gorm:"not null;type:decimal(10,2)" json:"price"` // 価格（小数点2桁まで）
 	Stock       int            `gorm:"not null;default:0" json:"stock"`          // 在庫数
 	SKU         string         `gorm:"uniqueIndex;size:50" json:"sku"`           // 商品コード（一意）
-	Category    string         `gorm:"size:50" json:"category"`                  // カテゴリー
-	ImageURL    string         `gorm:"size:500" json:"image_url"`                // 商品画像URL
+	CategoryID  *uint          `gorm:"index" json:"category_id"`                 // カテゴリーのID（未分類は null）
+	Category    string         `gorm:"size:50" json:"category"`                  // カテゴリー名（categories.name のコピー。全文検索とファセットで使用）
+	ImageURL    string         `gorm:"size:500" json:"image_url"`                // 商品画像URL（画像をアップロードした場合は代表画像のURL）
 	IsActive    bool           `gorm:"default:true" json:"is_active"`            // 販売中フラグ
 
 	// リレーション: 商品は複数の注文明細に含まれる
 	OrderItems  []OrderItem    `gorm:"foreignKey:ProductID" json:"-"`
+
+	// リレーション: 商品はオプションの種類とバリエーションを持つ（商品詳細でのみ取得）
+	OptionTypes []OptionType     `gorm:"foreignKey:ProductID" json:"option_types,omitempty"`
+	Variants    []ProductVariant `gorm:"foreignKey:ProductID" json:"variants,omitempty"`
+
+	// リレーション: 商品は複数の画像を持つ（商品詳細でのみ取得、表示順）
+	Images      []ProductImage   `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+}
+
+// ProductSearchConfig は商品の全文検索に使用するテキスト検索設定です
+// 日本語の商品名は語幹処理ができないため、単語の分割のみを行う simple を使用します
+const ProductSearchConfig = "simple"
+
+// ProductSearchResult は商品検索の結果です
+// 検索語との関連度と、検索語を強調した商品名・説明を含みます
+type ProductSearchResult struct {
+	Product
+	Rank      float64           `json:"rank"`                // 検索語との関連度（大きいほど関連が高い）
+	Highlight *ProductHighlight `json:"highlight,omitempty"` // 検索語を強調したテキスト
+}
+
+// ProductHighlight は検索語を <mark> タグで囲んだ商品名と説明です
+// HTMLとして表示できるよう、<mark> タグ以外の文字はエスケープ済みです
+type ProductHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"` // 説明の検索語を含む部分の抜粋
+}
+
+// ProductFacets は商品一覧の絞り込み条件ごとの商品数です（ファセット）
+// 各項目の件数は、その項目自体の絞り込みを除いた条件で集計します
+// （例: カテゴリーを指定していても、他のカテゴリーの件数を返します）
+type ProductFacets struct {
+	Categories   []CategoryFacet   `json:"categories"`   // カテゴリーごとの商品数（多い順）
+	PriceRanges  []PriceRangeFacet `json:"price_ranges"` // 価格帯ごとの商品数（安い順）
+	Availability AvailabilityFacet `json:"availability"` // 在庫の有無ごとの商品数
+}
+
+// CategoryFacet はカテゴリーごとの商品数です
+type CategoryFacet struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeFacet は価格帯ごとの商品数です
+// Min 以上 Max 未満の商品数を表します（最も高い価格帯は Max なし）
+type PriceRangeFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// AvailabilityFacet は在庫の有無ごとの商品数です
+type AvailabilityFacet struct {
+	InStock    int64 `json:"in_stock"`
+	OutOfStock int64 `json:"out_of_stock"`
 }
 
 // ProductCreateRequest は商品作成時のリクエストボディです
@@ -33,8 +89,9 @@ type ProductCreateRequest struct {
 	Description string  `json:"description" binding:"max=1000"`               // オプション
 	Price       float64 `json:"price" binding:"required,gt=0"`                // 必須、0より大きい
 	Stock       int     `json:"stock" binding:"required,gte=0"`               // 必須、0以上
-	SKU         string  `json:"sku" binding:"required,min=1,max=50"`          // 必須
-	Category    string  `json:"category" binding:"max=50"`                    // オプション
+	SKU         string  `json:"sku" binding:"required,sku"`                   // 必須、英数字（ハイフン・アンダースコア区切り）
+	CategoryID  *uint   `json:"category_id"`                                  // オプション、カテゴリーのID
+	Category    string  `json:"category" binding:"max=50"`                    // オプション、カテゴリーのスラッグまたは名前（category_id を省略した場合）
 	ImageURL    string  `json:"image_url" binding:"omitempty,url,max=500"`    // オプション、URL形式
 }
 
@@ -44,11 +101,24 @@ type ProductUpdateRequest struct {
 	Description string   `json:"description" binding:"max=1000"`
 	Price       *float64 `json:"price" binding:"omitempty,gt=0"`               // ポインタでnull許可
 	Stock       *int     `json:"stock" binding:"omitempty,gte=0"`
-	Category    string   `json:"category" binding:"max=50"`
+	CategoryID  *uint    `json:"category_id"`                                  // 0 を指定した場合は未分類にする
+	Category    string   `json:"category" binding:"max=50"`                    // カテゴリーのスラッグまたは名前
 	ImageURL    string   `json:"image_url" binding:"omitempty,url,max=500"`
 	IsActive    *bool    `json:"is_active"`
 }
 
+// SetCategory は商品のカテゴリーを設定します（nil の場合は未分類）
+// 全文検索とファセットで使用するため、カテゴリー名もコピーします
+func (p *Product) SetCategory(category *Category) {
+	if category == nil {
+		p.CategoryID = nil
+		p.Category = ""
+		return
+	}
+	p.CategoryID = &category.ID
+	p.Category = category.Name
+}
+
 // BeforeSave は保存前に実行されるGORMフックです
 // 在庫が0の場合は自動的に非アクティブにします
 func (p *Product) BeforeSave(tx *gorm.DB) error {
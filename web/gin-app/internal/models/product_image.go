@@ -0,0 +1,60 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MediaPathPrefix はアップロードしたファイルを配信するURLのパスです（GET /media/*key）
+const MediaPathPrefix = "/media/"
+
+// ProductImage は商品画像を表すモデルです
+// ファイルは BlobStore に保存し、データベースには保存先のキーと画像の情報のみを保存します
+type ProductImage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ProductID    uint   `gorm:"not null;index" json:"product_id"`
+	Position     int    `gorm:"not null;default:0" json:"position"` // 表示順（0 が代表画像）
+	Key          string `gorm:"not null;size:255" json:"-"`         // 元の画像の保存先のキー
+	ThumbnailKey string `gorm:"not null;size:255" json:"-"`         // サムネイルの保存先のキー
+	ContentType  string `gorm:"size:50" json:"content_type"`        // 元の画像のMIMEタイプ
+	Size         int64  `gorm:"not null" json:"size"`               // 元の画像のバイト数
+	Width        int    `gorm:"not null" json:"width"`              // 元の画像の幅（ピクセル）
+	Height       int    `gorm:"not null" json:"height"`             // 元の画像の高さ（ピクセル）
+
+	URL          string `gorm:"-" json:"url"`           // 元の画像のURL（保存しない）
+	ThumbnailURL string `gorm:"-" json:"thumbnail_url"` // サムネイルのURL（保存しない）
+}
+
+// ProductImageOrderRequest は商品画像の並び替えのリクエストボディです
+// image_ids には商品の全ての画像のIDを、表示したい順に指定します
+type ProductImageOrderRequest struct {
+	ImageIDs []uint `json:"image_ids" binding:"required,min=1"`
+}
+
+// MediaURL はキーのファイルを配信するURLを返します
+func MediaURL(key string) string {
+	return MediaPathPrefix + key
+}
+
+// AfterFind は取得後に実行されるGORMフックです
+// 保存先のキーから配信用のURLを設定します
+func (i *ProductImage) AfterFind(tx *gorm.DB) error {
+	i.setURLs()
+	return nil
+}
+
+// AfterCreate は作成後に実行されるGORMフックです
+func (i *ProductImage) AfterCreate(tx *gorm.DB) error {
+	i.setURLs()
+	return nil
+}
+
+// setURLs は保存先のキーから配信用のURLを設定します
+func (i *ProductImage) setURLs() {
+	i.URL = MediaURL(i.Key)
+	i.ThumbnailURL = MediaURL(i.ThumbnailKey)
+}
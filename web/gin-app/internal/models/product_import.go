@@ -0,0 +1,27 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"go_learning/web/gin-app/internal/utils"
+)
+
+// ProductImportResult は商品のインポートの結果です
+// dry_run の場合は、データベースを変更せずにインポートした場合の結果を返します
+type ProductImportResult struct {
+	DryRun          bool                    `json:"dry_run"`
+	Rows            int                     `json:"rows"`             // 読み込んだ行数（ヘッダーと空行を除く）
+	Created         int                     `json:"created"`          // 作成した商品の数
+	Updated         int                     `json:"updated"`          // SKUが一致して更新した商品の数
+	Failed          int                     `json:"failed"`           // エラーの行数
+	Errors          []ProductImportRowError `json:"errors"`           // エラーの行（ファイルの順）
+	ErrorsTruncated bool                    `json:"errors_truncated"` // エラーの行が多いため errors を省略した場合は true
+}
+
+// ProductImportRowError はインポートできなかった行のエラーです
+type ProductImportRowError struct {
+	Line    int                `json:"line"`             // ファイルの行番号（1から）
+	SKU     string             `json:"sku,omitempty"`    // 行のSKU（読み取れた場合のみ）
+	Code    string             `json:"code"`             // エラーコード（VALIDATION_FAILED, SKU_TAKEN 等）
+	Message string             `json:"message"`          // 利用者向けのメッセージ
+	Errors  []utils.FieldError `json:"errors,omitempty"` // フィールド単位の入力エラー（VALIDATION_FAILED の場合）
+}
@@ -0,0 +1,43 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken はリフレッシュトークンを表すモデルです
+// トークン本体は保存せず、SHA-256ハッシュのみを保存します
+// 同じログインから発行されたトークンは FamilyID で紐付けられ、
+// 使用済みトークンが再利用された場合はファミリー全体を失効させます
+type RefreshToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 外部キー: ユーザーID
+	UserID uint `gorm:"not null;index" json:"user_id"`
+	User   User `gorm:"foreignKey:UserID" json:"-"` // リレーション
+
+	TokenHash string     `gorm:"uniqueIndex;not null;size:64" json:"-"`   // トークンのハッシュ値
+	FamilyID  string     `gorm:"index;not null;size:64" json:"family_id"` // トークンファミリーID
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`              // 有効期限
+	UsedAt    *time.Time `json:"used_at,omitempty"`                       // ローテーションで使用済みになった日時
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`                    // 失効日時
+	MFA       bool       `gorm:"not null;default:false" json:"mfa"`       // 二要素認証を経て開始されたファミリーか
+}
+
+// RefreshTokenRequest はトークン更新時のリクエストボディです
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// IsExpired はリフレッシュトークンが有効期限切れかどうかを返します
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsConsumed はリフレッシュトークンが使用済みまたは失効済みかどうかを返します
+// 使用済みトークンが再度提示された場合はトークンの盗用とみなします
+func (t *RefreshToken) IsConsumed() bool {
+	return t.UsedAt != nil || t.RevokedAt != nil
+}
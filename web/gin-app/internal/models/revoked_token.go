@@ -0,0 +1,24 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// RevokedToken は失効させたアクセストークン（JWT）を表すモデルです
+// JWTのjtiクレームを記録し、有効期限まで認証ミドルウェアで拒否します
+// 有効期限を過ぎたレコードは不要になるため定期的に削除されます
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	JTI       string    `gorm:"uniqueIndex;not null;size:64" json:"jti"` // トークンID（jtiクレーム）
+	UserID    uint      `gorm:"index" json:"user_id"`                    // トークンの所有者
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`        // トークン本来の有効期限
+}
+
+// LogoutRequest はログアウト時のリクエストボディです
+// リフレッシュトークンを指定した場合は、そのトークンファミリーも失効させます
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
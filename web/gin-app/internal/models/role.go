@@ -0,0 +1,114 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// 権限名の一覧
+// 権限は "<リソース>:<操作>" の形式で表します
+const (
+	PermissionUsersRead          = "users:read"           // ユーザー情報の閲覧
+	PermissionUsersWrite         = "users:write"          // ユーザーの削除・ロック解除
+	PermissionUsersImpersonate   = "users:impersonate"    // ユーザーへのなりすまし（サポート用）
+	PermissionAuditLogsRead      = "audit_logs:read"      // 監査ログの閲覧
+	PermissionRolesManage        = "roles:manage"         // ロールの作成・編集とユーザーへの割り当て
+	PermissionAPIKeysManage      = "api_keys:manage"      // APIキーの発行・失効
+	PermissionProductsWrite      = "products:write"       // 商品・カテゴリーの作成・更新・削除
+	PermissionOrdersReadAll      = "orders:read_all"      // 全ユーザーの注文の閲覧
+	PermissionOrdersCancelAny    = "orders:cancel_any"    // 全ユーザーの注文のキャンセル
+	PermissionOrdersUpdateStatus = "orders:update_status" // 注文ステータスの更新
+)
+
+// ロール名
+// RoleAdmin は全ての権限を持ち、RoleUser は権限を持たない一般ユーザーです
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Permission は権限を表すモデルです
+type Permission struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Name        string `gorm:"uniqueIndex;not null;size:50" json:"name"` // 権限名（例: orders:update_status）
+	Description string `gorm:"size:255" json:"description"`              // 説明
+}
+
+// Role はロールを表すモデルです
+// ユーザーは users.role にロール名を1つ持ち、ロールに割り当てられた権限を利用できます
+type Role struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string `gorm:"uniqueIndex;not null;size:20" json:"name"` // ロール名（users.role と対応）
+	Description string `gorm:"size:255" json:"description"`              // 説明
+	IsSystem    bool   `gorm:"not null;default:false" json:"is_system"`  // 組み込みロール（削除不可）
+
+	// リレーション: ロールは複数の権限を持つ（多対多）
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+}
+
+// RoleCreateRequest はロール作成時のリクエストボディです
+type RoleCreateRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=20"`
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleUpdateRequest はロール更新時のリクエストボディです
+// permissions を指定した場合は、ロールの権限をその内容で置き換えます
+type RoleUpdateRequest struct {
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// UserRoleRequest はユーザーへのロール割り当て時のリクエストボディです
+type UserRoleRequest struct {
+	Role string `json:"role" binding:"required,max=20"`
+}
+
+// PermissionNames はロールが持つ権限名の一覧を返します
+func (r *Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, p := range r.Permissions {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// DefaultPermissions は組み込みの権限の一覧です
+var DefaultPermissions = []Permission{
+	{Name: PermissionUsersRead, Description: "ユーザー情報の閲覧"},
+	{Name: PermissionUsersWrite, Description: "ユーザーの削除・ロック解除"},
+	{Name: PermissionUsersImpersonate, Description: "ユーザーへのなりすまし"},
+	{Name: PermissionAuditLogsRead, Description: "監査ログの閲覧"},
+	{Name: PermissionRolesManage, Description: "ロールの管理とユーザーへの割り当て"},
+	{Name: PermissionAPIKeysManage, Description: "APIキーの発行・失効"},
+	{Name: PermissionProductsWrite, Description: "商品・カテゴリーの作成・更新・削除"},
+	{Name: PermissionOrdersReadAll, Description: "全ユーザーの注文の閲覧"},
+	{Name: PermissionOrdersCancelAny, Description: "全ユーザーの注文のキャンセル"},
+	{Name: PermissionOrdersUpdateStatus, Description: "注文ステータスの更新"},
+}
+
+// DefaultRoles は初期データとして作成するロールと、その権限の一覧です
+// admin ロールには起動時に全ての権限が割り当てられます
+var DefaultRoles = []struct {
+	Role        Role
+	Permissions []string
+}{
+	{Role: Role{Name: RoleAdmin, Description: "全ての操作が可能な管理者", IsSystem: true}},
+	{Role: Role{Name: RoleUser, Description: "一般ユーザー", IsSystem: true}},
+	{
+		Role:        Role{Name: "support", Description: "カスタマーサポート"},
+		Permissions: []string{PermissionUsersRead, PermissionUsersImpersonate, PermissionOrdersReadAll, PermissionOrdersCancelAny},
+	},
+	{
+		Role:        Role{Name: "warehouse", Description: "倉庫・出荷担当"},
+		Permissions: []string{PermissionOrdersReadAll, PermissionOrdersUpdateStatus},
+	},
+	{
+		Role:        Role{Name: "catalog_manager", Description: "商品カタログ管理者"},
+		Permissions: []string{PermissionProductsWrite},
+	},
+}
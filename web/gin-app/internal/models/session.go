@@ -0,0 +1,37 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// Session はログインセッション（ログイン中の端末）を表すモデルです
+// ログインごとに作成され、同じログインから発行されるリフレッシュトークンのファミリーと対応します
+// アクセストークンには sid クレームとして FamilyID が含まれ、セッションを失効させると
+// そのセッションで発行された全てのトークンが拒否されます
+type Session struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 外部キー: ユーザーID
+	UserID uint `gorm:"not null;index" json:"user_id"`
+	User   User `gorm:"foreignKey:UserID" json:"-"` // リレーション
+
+	FamilyID   string `gorm:"uniqueIndex;not null;size:64" json:"-"` // リフレッシュトークンのファミリーID（sid クレーム）
+	CurrentJTI string `gorm:"not null;size:64" json:"-"`             // 最後に発行したアクセストークンのjti
+	UserAgent  string `gorm:"size:255" json:"user_agent"`            // ログイン時のUser-Agent
+	IPAddress  string `gorm:"size:45" json:"ip_address"`             // ログイン時のIPアドレス
+	MFA        bool   `gorm:"not null;default:false" json:"mfa"`     // 二要素認証を経たログインか
+
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`     // 最後にアクセスした日時
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expires_at"` // 有効期限（最新のリフレッシュトークンの有効期限）
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`             // 失効日時（ログアウト等）
+
+	Current bool `gorm:"-" json:"current"` // リクエストに使用しているセッションか（レスポンス用）
+}
+
+// IsActive はセッションが有効（未失効かつ有効期限内）かどうかを返します
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
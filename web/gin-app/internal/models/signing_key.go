@@ -0,0 +1,20 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// SigningKey はJWTの署名に使用する鍵ペア（RS256/EdDSA）を表すモデルです
+// 秘密鍵は JWT_SECRET から導出した鍵で暗号化して保存します
+// ローテーションで署名に使わなくなった鍵も、発行済みトークンの有効期限までは検証に使用します
+type SigningKey struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	KID        string     `gorm:"uniqueIndex;not null;size:64" json:"kid"` // 鍵ID（JWTヘッダーの kid）
+	Algorithm  string     `gorm:"not null;size:10" json:"algorithm"`       // 署名アルゴリズム（RS256, EdDSA）
+	PrivateKey string     `gorm:"type:text;not null" json:"-"`             // 暗号化した秘密鍵（PKCS#8）
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`                    // 署名に使わなくなった日時
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at,omitempty"`       // 検証にも使わなくなる日時
+}
@@ -0,0 +1,61 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 在庫の移動の理由
+const (
+	StockReasonInitial    = "initial"    // 商品・バリエーションの作成時の在庫
+	StockReasonSale       = "sale"       // 注文による出庫
+	StockReasonCancel     = "cancel"     // 注文のキャンセルによる戻し
+	StockReasonReturn     = "return"     // 返品による入庫
+	StockReasonAdjustment = "adjustment" // 管理者による調整（商品の更新・インポート・棚卸し・バリエーションの追加と削除）
+)
+
+// ErrStockMovementImmutable は記録した在庫の移動を変更・削除しようとした場合のエラーです
+var ErrStockMovementImmutable = errors.New("在庫の移動は変更・削除できません")
+
+// StockMovement は在庫の移動（増減）を表すモデルです
+// 在庫を変更する時は必ず記録し、記録した移動は変更・削除しません（追記のみ）
+// 商品の在庫はその商品の移動の合計と、バリエーションの在庫はそのバリエーションの移動の合計と一致します
+type StockMovement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ProductID uint   `gorm:"not null;index" json:"product_id"`
+	VariantID *uint  `gorm:"index" json:"variant_id"`              // バリエーションの在庫の移動の場合のみ
+	Delta     int    `gorm:"not null" json:"delta"`                // 在庫の増減（出庫は負の値）
+	Balance   int    `gorm:"not null" json:"balance"`              // 移動後の在庫（バリエーションの場合はバリエーションの在庫）
+	Reason    string `gorm:"not null;size:20;index" json:"reason"` // 理由（sale, cancel, return, adjustment, initial）
+	OrderID   *uint  `gorm:"index" json:"order_id"`                // 注文による移動・返品の場合の注文のID
+	ActorID   *uint  `gorm:"index" json:"actor_id"`                // 操作したユーザーのID（APIキー・システムの場合は null）
+	ActorName string `gorm:"size:100" json:"actor_name"`           // 操作したユーザーのユーザー名（記録時点）
+	Note      string `gorm:"size:500" json:"note,omitempty"`       // 補足（調整の理由等）
+}
+
+// StockAdjustmentRequest は在庫の調整のリクエストボディです
+// 返品（reason=return）の場合は、返品された注文の order_id が必要です
+type StockAdjustmentRequest struct {
+	VariantID *uint  `json:"variant_id" binding:"omitempty,gt=0"`                         // バリエーションがある商品の場合は必須
+	Delta     int    `json:"delta" binding:"required"`                                    // 在庫の増減（0以外）
+	Reason    string `json:"reason" binding:"omitempty,oneof=adjustment return"`          // 省略した場合は adjustment
+	OrderID   *uint  `json:"order_id" binding:"required_if=Reason return,omitempty,gt=0"` // 返品の場合は必須
+	Note      string `json:"note" binding:"max=500"`
+}
+
+// BeforeUpdate は更新前に実行されるGORMフックです
+// 在庫の移動は追記のみのため、更新を禁止します
+func (m *StockMovement) BeforeUpdate(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
+
+// BeforeDelete は削除前に実行されるGORMフックです
+// 在庫の移動は追記のみのため、削除を禁止します
+func (m *StockMovement) BeforeDelete(tx *gorm.DB) error {
+	return ErrStockMovementImmutable
+}
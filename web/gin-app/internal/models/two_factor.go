@@ -0,0 +1,40 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// RecoveryCode は二要素認証のリカバリーコードを表すモデルです
+// 認証アプリを紛失した場合に、TOTPコードの代わりに一度だけ使用できます
+// コード本体は保存せず、SHA-256ハッシュのみを保存します
+type RecoveryCode struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 外部キー: ユーザーID
+	UserID uint `gorm:"not null;index" json:"user_id"`
+	User   User `gorm:"foreignKey:UserID" json:"-"` // リレーション
+
+	CodeHash string     `gorm:"not null;size:64;index" json:"-"` // コードのハッシュ値
+	UsedAt   *time.Time `json:"used_at,omitempty"`               // 使用日時
+}
+
+// TwoFactorCodeRequest は二要素認証コードを送信するリクエストボディです
+// code にはTOTPコード（6桁）またはリカバリーコードを指定します
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorDisableRequest は二要素認証を無効化するリクエストボディです
+// 本人確認のため、パスワードと現在のコードの両方が必要です
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorLoginRequest は二段階ログインの2段階目のリクエストボディです
+type TwoFactorLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
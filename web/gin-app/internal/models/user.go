@@ -2,6 +2,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -23,18 +24,43 @@ type User struct {
 	LastName  string         `gorm:"size:50" json:"last_name"`                     // 姓
 	Role      string         `gorm:"size:20;default:'user'" json:"role"`           // ロール（user, admin等）
 	IsActive  bool           `gorm:"default:true" json:"is_active"`                // アクティブフラグ
+	Locale    string         `gorm:"size:10" json:"locale,omitempty"`              // メッセージの言語（未設定の場合は Accept-Language に従う）
+
+	// メールアドレスの確認日時（未確認の場合はnil、メールアドレス変更時にリセット）
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// この日時より前に発行されたトークンは無効（パスワードリセット等で全セッションを無効化）
+	SessionsRevokedAt *time.Time `json:"-"`
+
+	// 二要素認証（TOTP）
+	TOTPSecret         string     `gorm:"size:64" json:"-"`                 // TOTP共有シークレット（Base32）
+	TOTPLastCounter    int64      `gorm:"not null;default:0" json:"-"`      // 最後に使用されたタイムステップ（コードの再利用防止）
+	TwoFactorEnabledAt *time.Time `json:"two_factor_enabled_at,omitempty"` // 二要素認証の有効化日時
+
+	// ログイン失敗によるロック
+	FailedLoginAttempts int        `gorm:"not null;default:0" json:"-"` // 連続ログイン失敗回数
+	LastFailedLoginAt   *time.Time `json:"-"`                           // 最後にログインに失敗した日時
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`      // この日時までログイン不可
+
+	// 個人情報の削除（匿名化）日時。匿名化したユーザーは復元できません
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty"`
 
 	// リレーション: 1ユーザーは複数の注文を持つ
 	Orders    []Order        `gorm:"foreignKey:UserID" json:"orders,omitempty"`
 }
 
+// unusablePassword は匿名化したユーザーのパスワードです
+// bcrypt のハッシュとして解釈できないため、どのパスワードとも一致しません
+const unusablePassword = "!"
+
 // UserCreateRequest はユーザー作成時のリクエストボディです
 type UserCreateRequest struct {
-	Username  string `json:"username" binding:"required,min=3,max=50"`        // 必須、3〜50文字
+	Username  string `json:"username" binding:"required,username"`           // 必須、3〜20文字の英数字とアンダースコア
 	Email     string `json:"email" binding:"required,email,max=100"`          // 必須、メール形式
-	Password  string `json:"password" binding:"required,min=8,max=100"`       // 必須、8文字以上
+	Password  string `json:"password" binding:"required,max=100,strong_password"` // 必須、パスワードポリシーを満たすこと
 	FirstName string `json:"first_name" binding:"max=50"`                     // オプション
 	LastName  string `json:"last_name" binding:"max=50"`                      // オプション
+	Locale    string `json:"locale" binding:"omitempty,locale"`               // オプション、メッセージの言語（ja, en）
 }
 
 // UserUpdateRequest はユーザー更新時のリクエストボディです
@@ -42,6 +68,7 @@ type UserUpdateRequest struct {
 	Email     string `json:"email" binding:"omitempty,email,max=100"`         // オプション
 	FirstName string `json:"first_name" binding:"max=50"`                     // オプション
 	LastName  string `json:"last_name" binding:"max=50"`                      // オプション
+	Locale    string `json:"locale" binding:"omitempty,locale"`               // オプション、メッセージの言語（ja, en）
 	IsActive  *bool  `json:"is_active"`                                       // オプション（ポインタでnull許可）
 }
 
@@ -53,15 +80,35 @@ type UserLoginRequest struct {
 
 // UserResponse はユーザー情報のレスポンスです（パスワードを除外）
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Role      string    `json:"role"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               uint       `json:"id"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	Role             string     `json:"role"`
+	IsActive         bool       `json:"is_active"`
+	Locale           string     `json:"locale,omitempty"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	LockedUntil      *time.Time `json:"locked_until,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"` // 削除済みユーザーの場合のみ
+	AnonymizedAt     *time.Time `json:"anonymized_at,omitempty"` // 個人情報を削除したユーザーの場合のみ
+}
+
+// UserEraseRequest は本人による個人情報の削除のリクエストボディです
+// 誤操作や第三者による削除を防ぐため、現在のパスワードの入力を必須とします
+type UserEraseRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// UserDataExport は本人のデータのエクスポート（GET /users/profile/export）の内容です
+type UserDataExport struct {
+	ExportedAt time.Time    `json:"exported_at"`
+	Profile    UserResponse `json:"profile"`
+	Sessions   []Session    `json:"sessions"`
+	Orders     []Order      `json:"orders"`
 }
 
 // BeforeCreate はユーザー作成前に自動実行されるGORMフックです
@@ -78,24 +125,83 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// SetPassword はパスワードをハッシュ化して設定します
+// BeforeCreate フックは作成時にしか実行されないため、既存ユーザーのパスワード変更時に使用します
+func (u *User) SetPassword(password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hashedPassword)
+	return nil
+}
+
 // CheckPassword は入力されたパスワードが正しいかを検証します
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
 
+// IsEmailVerified はメールアドレスが確認済みかどうかを返します
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsAnonymized は個人情報を削除済みかどうかを返します
+func (u *User) IsAnonymized() bool {
+	return u.AnonymizedAt != nil
+}
+
+// Anonymize はユーザーの個人情報を削除し、ログインできない状態にします
+// ユーザー名とメールアドレスは一意制約があるため、IDから生成した値に置き換えます
+// 注文の集計等のため、ID・ロール・作成日時は保持します
+func (u *User) Anonymize(now time.Time) {
+	u.Username = fmt.Sprintf("deleted_user_%d", u.ID)
+	u.Email = fmt.Sprintf("deleted_user_%d@deleted.invalid", u.ID)
+	u.Password = unusablePassword
+	u.FirstName = ""
+	u.LastName = ""
+	u.Locale = ""
+	u.IsActive = false
+	u.EmailVerifiedAt = nil
+	u.SessionsRevokedAt = &now
+	u.TOTPSecret = ""
+	u.TOTPLastCounter = 0
+	u.TwoFactorEnabledAt = nil
+	u.FailedLoginAttempts = 0
+	u.LastFailedLoginAt = nil
+	u.LockedUntil = nil
+	u.AnonymizedAt = &now
+}
+
+// IsTwoFactorEnabled は二要素認証が有効かどうかを返します
+func (u *User) IsTwoFactorEnabled() bool {
+	return u.TwoFactorEnabledAt != nil
+}
+
 // ToResponse はUserモデルをUserResponseに変換します
 // パスワードなどの機密情報を除外してクライアントに返します
 func (u *User) ToResponse() UserResponse {
+	var deletedAt *time.Time
+	if u.DeletedAt.Valid {
+		deletedAt = &u.DeletedAt.Time
+	}
+
 	return UserResponse{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		FirstName: u.FirstName,
-		LastName:  u.LastName,
-		Role:      u.Role,
-		IsActive:  u.IsActive,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:               u.ID,
+		Username:         u.Username,
+		Email:            u.Email,
+		FirstName:        u.FirstName,
+		LastName:         u.LastName,
+		Role:             u.Role,
+		IsActive:         u.IsActive,
+		Locale:           u.Locale,
+		EmailVerifiedAt:  u.EmailVerifiedAt,
+		TwoFactorEnabled: u.IsTwoFactorEnabled(),
+		LockedUntil:      u.LockedUntil,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+		DeletedAt:        deletedAt,
+		AnonymizedAt:     u.AnonymizedAt,
 	}
 }
@@ -0,0 +1,56 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+)
+
+// UserToken はメールで送付する使い捨てトークンを表すモデルです
+// パスワードリセット等の用途ごとに Purpose で区別します
+// トークン本体は保存せず、SHA-256ハッシュのみを保存します
+type UserToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 外部キー: ユーザーID
+	UserID uint `gorm:"not null;index" json:"user_id"`
+	User   User `gorm:"foreignKey:UserID" json:"-"` // リレーション
+
+	Purpose   string     `gorm:"not null;size:30;index" json:"purpose"` // 用途
+	TokenHash string     `gorm:"uniqueIndex;not null;size:64" json:"-"` // トークンのハッシュ値
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`            // 有効期限
+	UsedAt    *time.Time `json:"used_at,omitempty"`                     // 使用日時（使用済みなら再利用不可）
+}
+
+// UserTokenPurpose はトークンの用途の定数です
+const (
+	TokenPurposePasswordReset     = "password_reset"     // パスワードリセット
+	TokenPurposeEmailVerification = "email_verification" // メールアドレス確認
+)
+
+// ForgotPasswordRequest はパスワードリセット申請のリクエストボディです
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+// ResetPasswordRequest はパスワードリセット実行のリクエストボディです
+// パスワードの強度はパスワードポリシーで検証します（直近のパスワードの再利用はハンドラーで確認）
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,max=100,strong_password"`
+}
+
+// VerifyEmailRequest はメールアドレス確認のリクエストボディです
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest は確認メール再送のリクエストボディです
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+// IsUsable はトークンが未使用かつ有効期限内かどうかを返します
+func (t *UserToken) IsUsable() bool {
+	return t.UsedAt == nil && time.Now().Before(t.ExpiresAt)
+}
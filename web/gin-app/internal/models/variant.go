@@ -0,0 +1,78 @@
+// Package models はデータベースのテーブル構造を定義します
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OptionType は商品のオプションの種類です（例: サイズ、色）
+// 商品ごとに定義し、最初のバリエーションを作成した時に作成します
+type OptionType struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	ProductID uint   `gorm:"not null;uniqueIndex:idx_option_types_product_name" json:"product_id"`
+	Name      string `gorm:"not null;size:50;uniqueIndex:idx_option_types_product_name" json:"name"` // オプション名（例: Size）
+	Position  int    `gorm:"not null;default:0" json:"position"`                                     // 表示順
+
+	// リレーション: オプションの種類は複数の値を持つ
+	Values []OptionValue `gorm:"foreignKey:OptionTypeID" json:"values"`
+}
+
+// OptionValue はオプションの値です（例: M、Red）
+type OptionValue struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	OptionTypeID uint   `gorm:"not null;uniqueIndex:idx_option_values_type_value" json:"option_type_id"`
+	Value        string `gorm:"not null;size:50;uniqueIndex:idx_option_values_type_value" json:"value"` // 値（例: M）
+	Position     int    `gorm:"not null;default:0" json:"position"`                                     // 表示順
+}
+
+// ProductVariant は商品のバリエーション（オプションの値の組み合わせ）を表すモデルです
+// SKU と在庫はバリエーションごとに管理し、価格は指定した場合のみ商品の価格を上書きします
+type ProductVariant struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	ProductID uint     `gorm:"not null;index" json:"product_id"`
+	SKU       string   `gorm:"uniqueIndex;not null;size:50" json:"sku"` // 商品コード（一意）
+	Title     string   `gorm:"size:255" json:"title"`                   // オプションの値を表示順に並べた名前（例: Red / M）
+	Price     *float64 `gorm:"type:decimal(10,2)" json:"price"`         // 価格（null の場合は商品の価格）
+	Stock     int      `gorm:"not null;default:0" json:"stock"`         // 在庫数
+	IsActive  bool     `gorm:"not null;default:true" json:"is_active"`  // 販売中フラグ
+	OptionKey string   `gorm:"size:255;index" json:"-"`                 // オプションの値のIDの組み合わせ（重複の確認に使用）
+
+	// リレーション: バリエーションはオプションの種類ごとに1つの値を持つ（多対多）
+	OptionValues []OptionValue `gorm:"many2many:product_variant_option_values" json:"option_values"`
+}
+
+// VariantOptionRequest はバリエーションのオプションの指定です
+type VariantOptionRequest struct {
+	Name  string `json:"name" binding:"required,max=50"`  // オプション名（例: Size）
+	Value string `json:"value" binding:"required,max=50"` // 値（例: M）
+}
+
+// ProductVariantCreateRequest はバリエーション作成時のリクエストボディです
+type ProductVariantCreateRequest struct {
+	SKU     string                 `json:"sku" binding:"required,sku"`
+	Price   *float64               `json:"price" binding:"omitempty,gt=0"` // 省略した場合は商品の価格
+	Stock   int                    `json:"stock" binding:"gte=0"`
+	Options []VariantOptionRequest `json:"options" binding:"required,min=1,max=5,dive"`
+}
+
+// ProductVariantUpdateRequest はバリエーション更新時のリクエストボディです
+// price に 0 を指定した場合は商品の価格に戻します。SKU とオプションは変更できません
+type ProductVariantUpdateRequest struct {
+	Price    *float64 `json:"price" binding:"omitempty,gte=0"`
+	Stock    *int     `json:"stock" binding:"omitempty,gte=0"`
+	IsActive *bool    `json:"is_active"`
+}
+
+// UnitPrice はバリエーションの販売価格を返します（価格を指定していない場合は商品の価格）
+func (v *ProductVariant) UnitPrice(productPrice float64) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return productPrice
+}
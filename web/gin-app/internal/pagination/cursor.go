@@ -0,0 +1,122 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// カーソルに保存する値の型
+// JSON では数値と日時の型が失われるため、型と文字列表現の組で保存します
+const (
+	valueString = "s"
+	valueInt    = "i"
+	valueUint   = "u"
+	valueFloat  = "f"
+	valueTime   = "t"
+)
+
+// cursor はページの位置を表すカーソルです
+// クライアントには base64url でエンコードした JSON を不透明なトークンとして返します
+type cursor struct {
+	Signature string        `json:"k"`           // 発行時の並び順（keySignature）
+	Backward  bool          `json:"b,omitempty"` // 前のページを取得するカーソルの場合 true
+	Values    []cursorValue `json:"v"`           // 位置となる項目の並び替えの値
+}
+
+// cursorValue はカーソルに保存する並び替えの値です
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// encodeCursor は項目の並び替えの値からカーソルを生成します
+func encodeCursor(signature string, values []interface{}, backward bool) (string, error) {
+	cur := cursor{Signature: signature, Backward: backward}
+	for _, v := range values {
+		value, err := newCursorValue(v)
+		if err != nil {
+			return "", err
+		}
+		cur.Values = append(cur.Values, value)
+	}
+
+	data, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor はクライアントから受け取ったカーソルを復元します
+func decodeCursor(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var cur cursor
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, err
+	}
+	if cur.Signature == "" || len(cur.Values) == 0 {
+		return nil, errors.New("pagination: カーソルの形式が正しくありません")
+	}
+	return &cur, nil
+}
+
+// values はカーソルに保存した値を元の型に戻して返します
+func (c *cursor) values() ([]interface{}, error) {
+	values := make([]interface{}, 0, len(c.Values))
+	for _, v := range c.Values {
+		value, err := v.decode()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// newCursorValue は並び替えの値を型と文字列表現の組に変換します
+func newCursorValue(v interface{}) (cursorValue, error) {
+	switch value := v.(type) {
+	case string:
+		return cursorValue{Type: valueString, Value: value}, nil
+	case int:
+		return cursorValue{Type: valueInt, Value: strconv.FormatInt(int64(value), 10)}, nil
+	case int64:
+		return cursorValue{Type: valueInt, Value: strconv.FormatInt(value, 10)}, nil
+	case uint:
+		return cursorValue{Type: valueUint, Value: strconv.FormatUint(uint64(value), 10)}, nil
+	case uint64:
+		return cursorValue{Type: valueUint, Value: strconv.FormatUint(value, 10)}, nil
+	case float64:
+		return cursorValue{Type: valueFloat, Value: strconv.FormatFloat(value, 'g', -1, 64)}, nil
+	case time.Time:
+		return cursorValue{Type: valueTime, Value: value.UTC().Format(time.RFC3339Nano)}, nil
+	default:
+		return cursorValue{}, fmt.Errorf("pagination: カーソルに保存できない型です: %T", v)
+	}
+}
+
+// decode は文字列表現から元の型の値に戻します
+func (v cursorValue) decode() (interface{}, error) {
+	switch v.Type {
+	case valueString:
+		return v.Value, nil
+	case valueInt:
+		return strconv.ParseInt(v.Value, 10, 64)
+	case valueUint:
+		return strconv.ParseUint(v.Value, 10, 64)
+	case valueFloat:
+		return strconv.ParseFloat(v.Value, 64)
+	case valueTime:
+		return time.Parse(time.RFC3339Nano, v.Value)
+	default:
+		return nil, fmt.Errorf("pagination: 不明な値の型です: %s", v.Type)
+	}
+}
@@ -0,0 +1,237 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go_learning/web/gin-app/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCursorRoundTrip はカーソルに保存した値が元の型で復元されることを確認します
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.FixedZone("JST", 9*60*60))
+
+	tests := []struct {
+		name     string
+		values   []interface{}
+		backward bool
+		want     []interface{}
+	}{
+		{"string", []interface{}{"Tシャツ, 黒"}, false, []interface{}{"Tシャツ, 黒"}},
+		{"int", []interface{}{-5, int64(7)}, false, []interface{}{int64(-5), int64(7)}},
+		{"uint", []interface{}{uint(42), uint64(1 << 40)}, true, []interface{}{uint64(42), uint64(1 << 40)}},
+		{"float", []interface{}{1980.5}, false, []interface{}{1980.5}},
+		{"time_as_utc", []interface{}{created, uint(1)}, false, []interface{}{created.UTC(), uint64(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := encodeCursor("-created_at,-id", tt.values, tt.backward)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cur, err := decodeCursor(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cur.Signature != "-created_at,-id" || cur.Backward != tt.backward {
+				t.Errorf("signature/backward: got %q/%v", cur.Signature, cur.Backward)
+			}
+			got, err := cur.values()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEncodeCursorUnsupportedType はカーソルに保存できない型をエラーにすることを確認します
+func TestEncodeCursorUnsupportedType(t *testing.T) {
+	if _, err := encodeCursor("id", []interface{}{true}, false); err == nil {
+		t.Error("bool がエラーになっていません")
+	}
+}
+
+// TestDecodeCursorInvalid はクライアントが改ざん・作成した不正なカーソルを拒否することを確認します
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not_base64", "!!!"},
+		{"not_json", encode("cursor")},
+		{"no_signature", encode(`{"v":[{"t":"u","v":"1"}]}`)},
+		{"no_values", encode(`{"k":"id","v":[]}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.token); err == nil {
+				t.Error("エラーになっていません")
+			}
+		})
+	}
+
+	// 形式は正しいが値を復元できないカーソル
+	values := []struct {
+		name  string
+		token string
+	}{
+		{"unknown_type", encode(`{"k":"id","v":[{"t":"x","v":"1"}]}`)},
+		{"invalid_uint", encode(`{"k":"id","v":[{"t":"u","v":"-1"}]}`)},
+		{"invalid_time", encode(`{"k":"id","v":[{"t":"t","v":"yesterday"}]}`)},
+	}
+	for _, tt := range values {
+		t.Run(tt.name, func(t *testing.T) {
+			cur, err := decodeCursor(tt.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cur.values(); err == nil {
+				t.Error("エラーになっていません")
+			}
+		})
+	}
+}
+
+// TestFindRejectsForeignCursor は異なる並び順・項目数で発行されたカーソルを拒否することを確認します
+func TestFindRejectsForeignCursor(t *testing.T) {
+	keys := []Key{
+		{Name: "created_at", Column: "created_at", Desc: true},
+		{Name: "id", Column: "id", Desc: true},
+	}
+
+	tests := []struct {
+		name      string
+		signature string
+		values    []interface{}
+	}{
+		{"other_order", "created_at,id", []interface{}{time.Now(), uint(1)}},
+		{"other_key", "-price,-id", []interface{}{1.0, uint(1)}},
+		{"missing_value", "-created_at,-id", []interface{}{time.Now()}},
+		{"undecodable_value", "-created_at,-id", []interface{}{time.Now(), "abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := encodeCursor(tt.signature, tt.values, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cur, err := decodeCursor(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.name == "undecodable_value" {
+				cur.Values[1].Type = "x"
+			}
+
+			// カーソルの確認で失敗するため、データベースには問い合わせない
+			_, _, err = Find[struct{}](nil, &Request{PageSize: 10, cursor: cur}, keys,
+				func(*struct{}) []interface{} { return nil })
+			var appErr *apperr.AppError
+			if !errors.As(err, &appErr) || appErr.Code != "INVALID_QUERY_PARAMETER" {
+				t.Errorf("got %v, want INVALID_QUERY_PARAMETER", err)
+			}
+		})
+	}
+}
+
+// TestKeySignature は並び順の文字列に項目名と降順を含めることを確認します
+func TestKeySignature(t *testing.T) {
+	got := keySignature([]Key{{Name: "price"}, {Name: "name", Desc: true}, {Name: "id", Desc: true}})
+	if got != "price,-name,-id" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// TestKeysetCondition はカーソルの位置より後・前の項目に絞り込む条件を確認します
+func TestKeysetCondition(t *testing.T) {
+	keys := []Key{
+		{Name: "price", Column: "price"},
+		{Name: "id", Column: "id", Desc: true},
+	}
+
+	tests := []struct {
+		name     string
+		backward bool
+		wantSQL  string
+	}{
+		{"forward", false, "((price > ?) OR (price = ? AND id < ?))"},
+		{"backward", true, "((price < ?) OR (price = ? AND id > ?))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr := keysetCondition(keys, []interface{}{100.0, uint64(7)}, tt.backward)
+			if expr.SQL != tt.wantSQL {
+				t.Errorf("SQL: got %q, want %q", expr.SQL, tt.wantSQL)
+			}
+			wantVars := []interface{}{100.0, 100.0, uint64(7)}
+			if !reflect.DeepEqual(expr.Vars, wantVars) {
+				t.Errorf("vars: got %#v, want %#v", expr.Vars, wantVars)
+			}
+		})
+	}
+}
+
+// TestParse はクエリパラメータの読み取りと、不正な値のエラーを確認します
+func TestParse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token, _ := encodeCursor("-id", []interface{}{uint(5)}, false)
+
+	tests := []struct {
+		name         string
+		query        string
+		wantSize     int
+		wantPage     int
+		wantTotal    bool
+		wantCursor   bool
+		wantErrParam string
+	}{
+		{"default", "", 10, 0, false, false, ""},
+		{"page_size_capped", "page_size=1000", 100, 0, false, false, ""},
+		{"page", "page=3&page_size=20", 20, 3, true, false, ""},
+		{"page_without_total", "page=2&include_total=false", 10, 2, false, false, ""},
+		{"cursor", "cursor=" + token + "&include_total=true", 10, 0, true, true, ""},
+		{"page_size_zero", "page_size=0", 0, 0, false, false, "page_size"},
+		{"page_invalid", "page=abc", 0, 0, false, false, "page"},
+		{"page_and_cursor", "page=1&cursor=" + token, 0, 0, false, false, "cursor"},
+		{"cursor_invalid", "cursor=abc", 0, 0, false, false, "cursor"},
+		{"include_total_invalid", "include_total=maybe", 0, 0, false, false, "include_total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+
+			req, err := Parse(c, DefaultPageSize, MaxPageSize)
+			if tt.wantErrParam != "" {
+				var appErr *apperr.AppError
+				if !errors.As(err, &appErr) || !reflect.DeepEqual(appErr.Args, []interface{}{tt.wantErrParam}) {
+					t.Errorf("got %v, want INVALID_QUERY_PARAMETER(%s)", err, tt.wantErrParam)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.PageSize != tt.wantSize || req.Page != tt.wantPage ||
+				req.IncludeTotal != tt.wantTotal || (req.cursor != nil) != tt.wantCursor {
+				t.Errorf("got %+v", req)
+			}
+		})
+	}
+}
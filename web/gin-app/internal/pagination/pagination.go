@@ -0,0 +1,283 @@
+// Package pagination は一覧APIのページネーションを提供します
+// 並び替えの項目の値で次のページの位置を指定するカーソル方式（キーセットページネーション）と、
+// 従来のページ番号方式（page / page_size）の両方に対応します
+// カーソル方式は OFFSET を使用しないため、ページが深くなっても速度が落ちません
+package pagination
+
+import (
+	"strconv"
+	"strings"
+
+	"go_learning/web/gin-app/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ページサイズの既定値
+const (
+	DefaultPageSize = 10  // page_size を省略した場合の件数
+	MaxPageSize     = 100 // page_size の上限（超える場合は上限の件数を返します）
+)
+
+// Key は並び替えの項目です
+// カーソル方式では、最後に並べた Key の値が一意になるよう、主キーを最後に含めてください
+// 値が NULL になるカラムは使用できません
+type Key struct {
+	Name   string        // 項目名（カーソルが同じ並び順で発行されたかの確認に使用）
+	Column string        // 並び替えるカラムまたはSQLの式
+	Vars   []interface{} // Column の式に埋め込む値
+	Desc   bool          // 降順の場合 true
+}
+
+// Request はリクエストで指定されたページの情報です
+type Request struct {
+	PageSize     int     // 1ページあたりの件数
+	Page         int     // ページ番号（ページ番号方式の場合のみ。カーソル方式では0）
+	IncludeTotal bool    // 総数を集計するか
+	cursor       *cursor // 前のページのレスポンスで返したカーソル（最初のページでは nil）
+}
+
+// Parse はクエリパラメータからページの情報を読み取ります
+// page を指定した場合はページ番号方式、それ以外はカーソル方式（cursor）になります
+// 総数の集計は include_total で指定します（ページ番号方式は従来と同じく既定で集計します）
+// defaultSize には page_size を省略した場合の件数、maxSize には上限を指定します
+func Parse(c *gin.Context, defaultSize, maxSize int) (*Request, error) {
+	req := &Request{PageSize: defaultSize}
+
+	if value := c.Query("page_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 {
+			return nil, apperr.ErrInvalidQueryParameter.WithArgs("page_size")
+		}
+		req.PageSize = size
+	}
+	if req.PageSize > maxSize {
+		req.PageSize = maxSize
+	}
+
+	if value := c.Query("page"); value != "" {
+		if c.Query("cursor") != "" {
+			return nil, apperr.ErrInvalidQueryParameter.WithArgs("cursor")
+		}
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return nil, apperr.ErrInvalidQueryParameter.WithArgs("page")
+		}
+		req.Page = page
+		req.IncludeTotal = true
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cur, err := decodeCursor(value)
+		if err != nil {
+			return nil, apperr.ErrInvalidQueryParameter.WithArgs("cursor")
+		}
+		req.cursor = cur
+	}
+
+	if value := c.Query("include_total"); value != "" {
+		include, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, apperr.ErrInvalidQueryParameter.WithArgs("include_total")
+		}
+		req.IncludeTotal = include
+	}
+
+	return req, nil
+}
+
+// Paged はページ番号方式かどうかを返します
+func (r *Request) Paged() bool {
+	return r.Page > 0
+}
+
+// FirstPage は最初のページかどうかを返します
+func (r *Request) FirstPage() bool {
+	if r.Paged() {
+		return r.Page == 1
+	}
+	return r.cursor == nil
+}
+
+// Page は取得したページの情報です
+type Page struct {
+	request    *Request
+	HasMore    bool   // 次のページがあるか
+	NextCursor string // 次のページのカーソル（カーソル方式で次のページがある場合のみ）
+	PrevCursor string // 前のページのカーソル（カーソル方式で前のページがある場合のみ）
+	Total      *int64 // 総数（集計した場合のみ）
+}
+
+// Find はクエリに並び替えとページの条件を追加して1ページ分の項目を取得します
+// values には項目から keys の順に並び替えの値を取り出す関数を指定します（カーソルの生成に使用）
+// scopes は項目の取得のみに適用します（Preload や Select など、総数の集計に含めない処理）
+// 次のページの有無を判定するため、ページサイズより1件多く取得します
+func Find[T any](query *gorm.DB, req *Request, keys []Key, values func(item *T) []interface{}, scopes ...func(*gorm.DB) *gorm.DB) ([]T, *Page, error) {
+	page := &Page{request: req}
+	signature := keySignature(keys)
+
+	// 1. 総数の集計（指定された場合のみ）
+	if req.IncludeTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, nil, err
+		}
+		page.Total = &total
+	}
+
+	// 2. ページの条件
+	backward := false
+	if req.Paged() {
+		query = query.Offset((req.Page - 1) * req.PageSize)
+	} else if req.cursor != nil {
+		if req.cursor.Signature != signature || len(req.cursor.Values) != len(keys) {
+			return nil, nil, apperr.ErrInvalidQueryParameter.WithArgs("cursor")
+		}
+		after, err := req.cursor.values()
+		if err != nil {
+			return nil, nil, apperr.ErrInvalidQueryParameter.WithArgs("cursor")
+		}
+		// 前のページは逆順に並べて取得し、取得後に元の順序に戻す
+		backward = req.cursor.Backward
+		query = query.Where(keysetCondition(keys, after, backward))
+	}
+
+	// 3. 取得
+	var items []T
+	if err := query.
+		Scopes(scopes...).
+		Clauses(clause.OrderBy{Expression: orderByExpr(keys, backward)}).
+		Limit(req.PageSize + 1).
+		Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+
+	page.HasMore = len(items) > req.PageSize
+	if page.HasMore {
+		items = items[:req.PageSize]
+	}
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	// 4. 前後のページのカーソル
+	// 逆方向に取得した場合、HasMore は前のページの有無を表す
+	if !req.Paged() && len(items) > 0 {
+		hasNext, hasPrev := page.HasMore, req.cursor != nil
+		if backward {
+			hasNext, hasPrev = true, page.HasMore
+			page.HasMore = hasNext
+		}
+
+		var err error
+		if hasNext {
+			if page.NextCursor, err = encodeCursor(signature, values(&items[len(items)-1]), false); err != nil {
+				return nil, nil, err
+			}
+		}
+		if hasPrev {
+			if page.PrevCursor, err = encodeCursor(signature, values(&items[0]), true); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return items, page, nil
+}
+
+// Fields はレスポンスに含めるページの情報を返します
+// 一覧の項目はハンドラーが追加します（例: fields["orders"] = orders）
+func (p *Page) Fields() gin.H {
+	fields := gin.H{
+		"page_size": p.request.PageSize,
+		"has_more":  p.HasMore,
+	}
+
+	if p.request.Paged() {
+		fields["page"] = p.request.Page
+	} else {
+		fields["next_cursor"] = nullableString(p.NextCursor)
+		fields["prev_cursor"] = nullableString(p.PrevCursor)
+	}
+
+	if p.Total != nil {
+		fields["total"] = *p.Total
+		if p.request.Paged() {
+			fields["total_pages"] = (*p.Total + int64(p.request.PageSize) - 1) / int64(p.request.PageSize)
+		}
+	}
+	return fields
+}
+
+// orderByExpr は並び替えの項目を ORDER BY の式に変換します
+// reverse の場合は全ての項目の昇順・降順を反転します
+func orderByExpr(keys []Key, reverse bool) clause.Expr {
+	parts := make([]string, 0, len(keys))
+	var vars []interface{}
+	for _, k := range keys {
+		direction := "ASC"
+		if k.Desc != reverse {
+			direction = "DESC"
+		}
+		parts = append(parts, k.Column+" "+direction)
+		vars = append(vars, k.Vars...)
+	}
+	return clause.Expr{SQL: strings.Join(parts, ", "), Vars: vars, WithoutParentheses: true}
+}
+
+// keysetCondition はカーソルの位置より後（backward の場合は前）の項目に絞り込む条件を生成します
+// 昇順・降順が混在しても使用できるよう、次の形式の条件にします
+// (k1 > v1) OR (k1 = v1 AND k2 < v2) OR (k1 = v1 AND k2 = v2 AND k3 > v3) ...
+func keysetCondition(keys []Key, after []interface{}, backward bool) clause.Expr {
+	var (
+		alternatives []string
+		vars         []interface{}
+	)
+	for i, k := range keys {
+		var terms []string
+		var termVars []interface{}
+		for j := 0; j < i; j++ {
+			terms = append(terms, keys[j].Column+" = ?")
+			termVars = append(termVars, keys[j].Vars...)
+			termVars = append(termVars, after[j])
+		}
+
+		operator := ">"
+		if k.Desc != backward {
+			operator = "<"
+		}
+		terms = append(terms, k.Column+" "+operator+" ?")
+		termVars = append(termVars, k.Vars...)
+		termVars = append(termVars, after[i])
+
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+		vars = append(vars, termVars...)
+	}
+	return clause.Expr{SQL: "(" + strings.Join(alternatives, " OR ") + ")", Vars: vars}
+}
+
+// keySignature は並び順を表す文字列を返します（例: price,-name,-id）
+// 異なる並び順で発行されたカーソルを拒否するために使用します
+func keySignature(keys []Key) string {
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k.Desc {
+			names = append(names, "-"+k.Name)
+		} else {
+			names = append(names, k.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// nullableString は空文字を JSON の null にします
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -6,17 +6,22 @@ import (
 	"net/http"
 	"time"
 
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/auth"
 	"go_learning/web/gin-app/internal/config"
 	"go_learning/web/gin-app/internal/database"
 	"go_learning/web/gin-app/internal/handlers"
+	"go_learning/web/gin-app/internal/mailer"
 	"go_learning/web/gin-app/internal/middleware"
+	"go_learning/web/gin-app/internal/models"
+	"go_learning/web/gin-app/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // SetupRouter はGinルーターを設定し、全てのルートを登録します
-func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
+func SetupRouter(db *gorm.DB, cfg *config.Config, mail mailer.Mailer, blobs storage.BlobStore, keys *auth.KeyManager, passwordPolicy *auth.PasswordPolicy) *gin.Engine {
 	// Ginのモードを設定（debug, release, test）
 	gin.SetMode(cfg.Server.Mode)
 
@@ -24,19 +29,55 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 	r := gin.New()
 
 	// グローバルミドルウェアの設定
-	r.Use(gin.Recovery())                          // パニック時の自動復旧
+	r.Use(middleware.RecoveryMiddleware())         // パニック時の自動復旧（problem+json で500を返す）
 	r.Use(middleware.LoggerMiddleware())           // カスタムロガー
 	r.Use(middleware.RequestIDMiddleware())        // リクエストID生成
+	r.Use(middleware.LocaleMiddleware())           // メッセージの言語の決定（Accept-Language）
+	r.Use(middleware.ErrorHandlerMiddleware())     // エラーレスポンスの生成（problem+json）
 	r.Use(middleware.CORSMiddleware())             // CORS設定
 
 	// レートリミッターの設定（1分間に100リクエストまで）
 	rateLimiter := middleware.NewRateLimiter(100, 1*time.Minute)
 	r.Use(rateLimiter.RateLimitMiddleware())
 
+	// トークン失効ストアの初期化（ログアウト済みトークン・無効ユーザーの判定）
+	revocations := auth.NewRevocationStore(db, cfg.JWT.UserCacheTTL)
+
+	// ログインセッション（端末ごとのログイン状態）の管理
+	sessions := auth.NewSessionStore(db, revocations, cfg.JWT.Expiration)
+
+	// ログイン試行の制限（総当たり攻撃対策・アカウントロック）
+	loginGuard := auth.NewLoginGuard(db, cfg.Auth)
+
+	// ロールごとの権限のキャッシュ（権限ベースのアクセス制御）
+	permissions := auth.NewPermissionStore(db, cfg.JWT.UserCacheTTL)
+
+	// サービス間連携用のAPIキー（X-API-Key ヘッダー）
+	apiKeys := auth.NewAPIKeyStore(db)
+
+	// 監査ログ（なりすまし等の操作の記録）
+	audit := auth.NewAuditLogger(db)
+	r.Use(middleware.ImpersonationAuditMiddleware(audit)) // なりすまし中のリクエストを全て記録
+
+	// 権限チェックミドルウェアの生成関数
+	requirePermission := func(required ...string) gin.HandlerFunc {
+		return middleware.RequirePermission(cfg, permissions, required...)
+	}
+
 	// ハンドラーの初期化
-	userHandler := handlers.NewUserHandler(db, cfg)
+	userHandler := handlers.NewUserHandler(db, cfg, keys, revocations, mail, loginGuard, passwordPolicy)
+	authHandler := handlers.NewAuthHandler(db, cfg, keys, revocations, mail, loginGuard, sessions, passwordPolicy)
 	productHandler := handlers.NewProductHandler(db)
-	orderHandler := handlers.NewOrderHandler(db)
+	productImageHandler := handlers.NewProductImageHandler(db, blobs, cfg.Storage)
+	mediaHandler := handlers.NewMediaHandler(blobs, cfg.Storage)
+	categoryHandler := handlers.NewCategoryHandler(db)
+	orderHandler := handlers.NewOrderHandler(db, permissions)
+	roleHandler := handlers.NewRoleHandler(db, permissions, revocations)
+	apiKeyHandler := handlers.NewAPIKeyHandler(db, permissions)
+	sessionHandler := handlers.NewSessionHandler(db, revocations, sessions)
+	impersonationHandler := handlers.NewImpersonationHandler(db, cfg, keys, permissions, audit)
+	auditLogHandler := handlers.NewAuditLogHandler(db)
+	errorCatalogHandler := handlers.NewErrorCatalogHandler()
 
 	// ヘルスチェックエンドポイント
 	r.GET("/health", func(c *gin.Context) {
@@ -67,6 +108,19 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		})
 	})
 
+	// JWKSエンドポイント（他のサービスがトークンを検証するための公開鍵）
+	// HS256 の場合、共有の秘密鍵は公開できないため keys は空になります
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, gin.H{
+			"keys": keys.JWKS(),
+		})
+	})
+
+	// アップロードしたファイル（商品画像）の配信
+	r.GET("/media/*key", mediaHandler.ServeFile)
+	r.HEAD("/media/*key", mediaHandler.ServeFile)
+
 	// API v1 グループ
 	v1 := r.Group("/api/v1")
 	{
@@ -75,62 +129,174 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		{
 			auth.POST("/register", userHandler.Register) // ユーザー登録
 			auth.POST("/login", userHandler.Login)       // ログイン
+			auth.POST("/login/2fa", authHandler.VerifyTwoFactorLogin) // 二要素認証コードの検証
+			auth.POST("/refresh", authHandler.Refresh)   // トークン更新
+			auth.POST("/logout", middleware.AuthMiddleware(keys, revocations, sessions, apiKeys), middleware.RequireUser(), authHandler.Logout) // ログアウト
+			auth.POST("/password/forgot", authHandler.ForgotPassword) // パスワードリセット申請
+			auth.POST("/password/reset", authHandler.ResetPassword)   // パスワード再設定
+			auth.POST("/email/verify", authHandler.VerifyEmail)        // メールアドレス確認
+			auth.POST("/email/resend", authHandler.ResendVerification) // 確認メール再送
 		}
 
 		// ユーザーエンドポイント
 		users := v1.Group("/users")
 		{
 			// 認証が必要なエンドポイント
-			users.Use(middleware.AuthMiddleware(cfg))
-			users.GET("/profile", userHandler.GetProfile)       // 自分のプロフィール取得
-			users.PUT("/profile", userHandler.UpdateProfile)    // プロフィール更新
+			users.Use(middleware.AuthMiddleware(keys, revocations, sessions, apiKeys))
+
+			// なりすまし中は実行できない操作（本人以外が行うべきでない操作）
+			rejectImpersonation := middleware.RejectImpersonation()
 
-			// 管理者のみアクセス可能
-			admin := users.Group("")
-			admin.Use(middleware.AdminMiddleware())
+			// 本人のプロフィール（APIキーでは利用不可）
+			profile := users.Group("/profile")
+			profile.Use(middleware.RequireUser())
 			{
-				admin.GET("", userHandler.ListUsers)          // 全ユーザー一覧
-				admin.GET("/:id", userHandler.GetUser)        // 特定ユーザー取得
-				admin.DELETE("/:id", userHandler.DeleteUser)  // ユーザー削除
+				profile.GET("", userHandler.GetProfile)       // 自分のプロフィール取得
+				profile.PUT("", rejectImpersonation, userHandler.UpdateProfile)    // プロフィール更新
+				profile.PUT("/password", rejectImpersonation, authHandler.ChangePassword) // パスワード変更
+				profile.GET("/export", rejectImpersonation, userHandler.ExportMyData)     // 個人データのエクスポート
+				profile.POST("/erase", rejectImpersonation, userHandler.EraseMyData)      // アカウントと個人情報の削除
+
+				// 二要素認証（TOTP）の設定
+				twoFactor := profile.Group("/2fa")
+				twoFactor.Use(rejectImpersonation)
+				{
+					twoFactor.POST("/setup", userHandler.SetupTwoFactor)                   // 登録開始
+					twoFactor.POST("/enable", userHandler.EnableTwoFactor)                 // 有効化
+					twoFactor.POST("/disable", userHandler.DisableTwoFactor)               // 無効化
+					twoFactor.POST("/recovery-codes", userHandler.RegenerateRecoveryCodes) // リカバリーコード再発行
+				}
+
+				// ログイン中のセッション（端末）の管理
+				profile.GET("/sessions", sessionHandler.ListMySessions)           // セッション一覧
+				profile.DELETE("/sessions", rejectImpersonation, sessionHandler.RevokeAllMySessions)   // 全ての端末からログアウト
+				profile.DELETE("/sessions/:id", rejectImpersonation, sessionHandler.RevokeMySession)   // セッションを指定してログアウト
 			}
+
+			// 権限を持つスタッフのみアクセス可能
+			users.GET("", requirePermission(models.PermissionUsersRead), userHandler.ListUsers)                  // 全ユーザー一覧
+			users.GET("/deleted", requirePermission(models.PermissionUsersRead), userHandler.ListDeletedUsers)   // 削除済みユーザー一覧
+			users.GET("/:id", requirePermission(models.PermissionUsersRead), userHandler.GetUser)                // 特定ユーザー取得
+			users.DELETE("/:id", rejectImpersonation, requirePermission(models.PermissionUsersWrite), userHandler.DeleteUser) // ユーザー削除
+			users.POST("/:id/unlock", requirePermission(models.PermissionUsersWrite), userHandler.UnlockUser)    // アカウントロック解除
+			users.POST("/:id/deactivate", requirePermission(models.PermissionUsersWrite), userHandler.DeactivateUser) // ユーザーの無効化
+			users.POST("/:id/activate", requirePermission(models.PermissionUsersWrite), userHandler.ActivateUser)     // ユーザーの有効化
+			users.POST("/:id/restore", requirePermission(models.PermissionUsersWrite), userHandler.RestoreUser)       // 削除済みユーザーの復元
+			users.DELETE("/:id/purge", rejectImpersonation, requirePermission(models.PermissionUsersWrite), userHandler.PurgeUser) // 削除済みユーザーの完全削除
+			users.POST("/:id/erase", rejectImpersonation, requirePermission(models.PermissionUsersWrite), userHandler.EraseUser)  // 個人情報の削除（匿名化）
+			users.PUT("/:id/role", middleware.RequireUser(), requirePermission(models.PermissionRolesManage), roleHandler.AssignUserRole) // ロールの割り当て
+			users.GET("/:id/sessions", requirePermission(models.PermissionUsersRead), sessionHandler.ListUserSessions)                          // セッション一覧
+			users.DELETE("/:id/sessions", requirePermission(models.PermissionUsersWrite), sessionHandler.RevokeAllUserSessions)                // 全セッションの強制ログアウト
+			users.DELETE("/:id/sessions/:session_id", requirePermission(models.PermissionUsersWrite), sessionHandler.RevokeUserSession)        // セッションの強制ログアウト
+			users.POST("/:id/impersonate", middleware.RequireUser(), rejectImpersonation, requirePermission(models.PermissionUsersImpersonate), impersonationHandler.ImpersonateUser) // なりすまし
 		}
 
 		// 商品エンドポイント
 		products := v1.Group("/products")
 		{
 			// 公開エンドポイント（認証不要）
-			products.GET("", productHandler.ListProducts)              // 商品一覧
-			products.GET("/:id", productHandler.GetProduct)            // 商品詳細
-			products.GET("/categories", productHandler.GetCategories)  // カテゴリー一覧
+			products.GET("", productHandler.ListProducts)               // 商品一覧
+			products.GET("/:id", productHandler.GetProduct)             // 商品詳細
+			products.GET("/categories", productHandler.GetCategories)   // カテゴリー一覧
+			products.GET("/:id/variants", productHandler.ListVariants)  // バリエーション一覧
+			products.GET("/:id/images", productImageHandler.ListImages) // 商品画像一覧
 
-			// 管理者のみアクセス可能
+			// products:write 権限が必要
 			admin := products.Group("")
-			admin.Use(middleware.AuthMiddleware(cfg))
-			admin.Use(middleware.AdminMiddleware())
+			admin.Use(middleware.AuthMiddleware(keys, revocations, sessions, apiKeys))
+			admin.Use(requirePermission(models.PermissionProductsWrite))
 			{
 				admin.POST("", productHandler.CreateProduct)           // 商品作成
 				admin.PUT("/:id", productHandler.UpdateProduct)        // 商品更新
 				admin.DELETE("/:id", productHandler.DeleteProduct)     // 商品削除
+
+				admin.POST("/:id/variants", productHandler.CreateVariant)               // バリエーション作成
+				admin.PUT("/:id/variants/:variant_id", productHandler.UpdateVariant)    // バリエーション更新
+				admin.DELETE("/:id/variants/:variant_id", productHandler.DeleteVariant) // バリエーション削除
+
+				admin.POST("/:id/images", productImageHandler.UploadImages)            // 商品画像アップロード
+				admin.PUT("/:id/images/order", productImageHandler.ReorderImages)      // 商品画像の並び替え
+				admin.DELETE("/:id/images/:image_id", productImageHandler.DeleteImage) // 商品画像削除
+
+				admin.POST("/import", productHandler.ImportProducts) // 商品のインポート（CSV・NDJSON）
+				admin.GET("/export", productHandler.ExportProducts)  // 商品のエクスポート（CSV・NDJSON）
+
+				admin.POST("/:id/stock/adjustments", productHandler.AdjustStock)     // 在庫の調整（棚卸し・返品）
+				admin.GET("/:id/stock/movements", productHandler.ListStockMovements) // 在庫の移動の履歴
+			}
+		}
+
+		// カテゴリーエンドポイント
+		categories := v1.Group("/categories")
+		{
+			// 公開エンドポイント（認証不要）
+			categories.GET("", categoryHandler.ListCategories) // カテゴリーツリー
+			categories.GET("/:id", categoryHandler.GetCategory) // カテゴリー詳細（IDまたはスラッグ）
+
+			// products:write 権限が必要
+			admin := categories.Group("")
+			admin.Use(middleware.AuthMiddleware(keys, revocations, sessions, apiKeys))
+			admin.Use(requirePermission(models.PermissionProductsWrite))
+			{
+				admin.POST("", categoryHandler.CreateCategory)       // カテゴリー作成
+				admin.PUT("/:id", categoryHandler.UpdateCategory)    // カテゴリー更新
+				admin.DELETE("/:id", categoryHandler.DeleteCategory) // カテゴリー削除
 			}
 		}
 
 		// 注文エンドポイント（全て認証が必要）
 		orders := v1.Group("/orders")
-		orders.Use(middleware.AuthMiddleware(cfg))
+		orders.Use(middleware.AuthMiddleware(keys, revocations, sessions, apiKeys))
 		{
-			orders.POST("", orderHandler.CreateOrder)                  // 注文作成
+			orders.POST("", middleware.RequireUser(), orderHandler.CreateOrder) // 注文作成
 			orders.GET("", orderHandler.ListOrders)                    // 注文一覧
 			orders.GET("/:id", orderHandler.GetOrder)                  // 注文詳細
 			orders.POST("/:id/cancel", orderHandler.CancelOrder)       // 注文キャンセル
 
-			// 管理者のみアクセス可能
-			admin := orders.Group("")
-			admin.Use(middleware.AdminMiddleware())
-			{
-				admin.PATCH("/:id/status", orderHandler.UpdateOrderStatus) // ステータス更新
-			}
+			// orders:update_status 権限が必要
+			orders.PATCH("/:id/status", requirePermission(models.PermissionOrdersUpdateStatus), orderHandler.UpdateOrderStatus) // ステータス更新
+		}
+
+		// ロールと権限の管理エンドポイント（roles:manage 権限が必要、APIキーでは利用不可）
+		roles := v1.Group("/roles")
+		roles.Use(middleware.AuthMiddleware(keys, revocations, sessions, apiKeys))
+		roles.Use(middleware.RequireUser())
+		roles.Use(requirePermission(models.PermissionRolesManage))
+		{
+			roles.GET("", roleHandler.ListRoles)          // ロール一覧
+			roles.POST("", roleHandler.CreateRole)        // ロール作成
+			roles.PUT("/:id", roleHandler.UpdateRole)     // ロール更新
+			roles.DELETE("/:id", roleHandler.DeleteRole)  // ロール削除
+		}
+		v1.GET("/permissions",
+			middleware.AuthMiddleware(keys, revocations, sessions, apiKeys),
+			middleware.RequireUser(),
+			requirePermission(models.PermissionRolesManage),
+			roleHandler.ListPermissions) // 権限一覧
+
+		// APIキーの管理エンドポイント（api_keys:manage 権限が必要、APIキーでは利用不可）
+		apiKeyRoutes := v1.Group("/api-keys")
+		apiKeyRoutes.Use(middleware.AuthMiddleware(keys, revocations, sessions, apiKeys))
+		apiKeyRoutes.Use(middleware.RequireUser())
+		apiKeyRoutes.Use(requirePermission(models.PermissionAPIKeysManage))
+		{
+			apiKeyRoutes.GET("", apiKeyHandler.ListAPIKeys)         // APIキー一覧
+			apiKeyRoutes.POST("", apiKeyHandler.CreateAPIKey)       // APIキー発行
+			apiKeyRoutes.GET("/:id", apiKeyHandler.GetAPIKey)       // APIキー詳細
+			apiKeyRoutes.DELETE("/:id", apiKeyHandler.RevokeAPIKey) // APIキー失効
 		}
 
+		// 監査ログの閲覧エンドポイント（audit_logs:read 権限が必要、APIキーでは利用不可）
+		v1.GET("/audit-logs",
+			middleware.AuthMiddleware(keys, revocations, sessions, apiKeys),
+			middleware.RequireUser(),
+			requirePermission(models.PermissionAuditLogsRead),
+			auditLogHandler.ListAuditLogs) // 監査ログ一覧
+
+		// エラーコードの一覧（エラーレスポンスの type の参照先、認証不要）
+		v1.GET("/errors", errorCatalogHandler.ListErrorCodes)     // エラーコード一覧
+		v1.GET("/errors/:code", errorCatalogHandler.GetErrorCode) // エラーコードの説明
+
 		// APIドキュメントエンドポイント
 		v1.GET("/docs", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{
@@ -140,28 +306,99 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 					"auth": gin.H{
 						"POST /api/v1/auth/register": "ユーザー登録",
 						"POST /api/v1/auth/login":    "ログイン",
+						"POST /api/v1/auth/login/2fa": "二要素認証コードの検証",
+						"POST /api/v1/auth/refresh":  "トークン更新",
+						"POST /api/v1/auth/logout":   "ログアウト（認証必要）",
+						"POST /api/v1/auth/password/forgot": "パスワードリセット申請",
+						"POST /api/v1/auth/password/reset":  "パスワード再設定",
+						"POST /api/v1/auth/email/verify":    "メールアドレス確認",
+						"POST /api/v1/auth/email/resend":    "確認メール再送",
 					},
 					"users": gin.H{
 						"GET /api/v1/users/profile":    "プロフィール取得（認証必要）",
 						"PUT /api/v1/users/profile":    "プロフィール更新（認証必要）",
-						"GET /api/v1/users":            "全ユーザー一覧（管理者のみ）",
-						"GET /api/v1/users/:id":        "ユーザー詳細（管理者のみ）",
-						"DELETE /api/v1/users/:id":     "ユーザー削除（管理者のみ）",
+						"PUT /api/v1/users/profile/password":            "パスワード変更（認証必要）",
+						"GET /api/v1/users/profile/export":              "個人データのエクスポート（認証必要）",
+						"POST /api/v1/users/profile/erase":              "アカウントと個人情報の削除（認証必要）",
+						"POST /api/v1/users/profile/2fa/setup":          "二要素認証の登録開始（認証必要）",
+						"POST /api/v1/users/profile/2fa/enable":         "二要素認証の有効化（認証必要）",
+						"POST /api/v1/users/profile/2fa/disable":        "二要素認証の無効化（認証必要）",
+						"POST /api/v1/users/profile/2fa/recovery-codes": "リカバリーコード再発行（認証必要）",
+						"GET /api/v1/users/profile/sessions":            "ログイン中のセッション一覧（認証必要）",
+						"DELETE /api/v1/users/profile/sessions":         "全ての端末からログアウト（認証必要）",
+						"DELETE /api/v1/users/profile/sessions/:id":     "セッションを指定してログアウト（認証必要）",
+						"GET /api/v1/users":            "全ユーザー一覧（users:read）",
+						"GET /api/v1/users/deleted":    "削除済みユーザー一覧（users:read）",
+						"GET /api/v1/users/:id":        "ユーザー詳細（users:read）",
+						"DELETE /api/v1/users/:id":     "ユーザー削除（users:write）",
+						"POST /api/v1/users/:id/unlock": "アカウントロック解除（users:write）",
+						"POST /api/v1/users/:id/deactivate": "ユーザーの無効化（users:write）",
+						"POST /api/v1/users/:id/activate":   "ユーザーの有効化（users:write）",
+						"POST /api/v1/users/:id/restore":    "削除済みユーザーの復元（users:write）",
+						"DELETE /api/v1/users/:id/purge":    "削除済みユーザーの完全削除（users:write）",
+						"POST /api/v1/users/:id/erase":      "個人情報の削除（users:write）",
+						"PUT /api/v1/users/:id/role":   "ロールの割り当て（roles:manage）",
+						"GET /api/v1/users/:id/sessions":                "ユーザーのセッション一覧（users:read）",
+						"DELETE /api/v1/users/:id/sessions":             "ユーザーの全セッションを終了（users:write）",
+						"DELETE /api/v1/users/:id/sessions/:session_id": "ユーザーのセッションを終了（users:write）",
+						"POST /api/v1/users/:id/impersonate":            "ユーザーへのなりすまし（users:impersonate）",
 					},
 					"products": gin.H{
-						"GET /api/v1/products":              "商品一覧",
-						"GET /api/v1/products/:id":          "商品詳細",
-						"GET /api/v1/products/categories":   "カテゴリー一覧",
-						"POST /api/v1/products":             "商品作成（管理者のみ）",
-						"PUT /api/v1/products/:id":          "商品更新（管理者のみ）",
-						"DELETE /api/v1/products/:id":       "商品削除（管理者のみ）",
+						"GET /api/v1/products":                             "商品一覧（search で全文検索、絞り込み・sort・facets）",
+						"GET /api/v1/products/:id":                         "商品詳細（オプションの種類とバリエーションを含む）",
+						"GET /api/v1/products/categories":                  "カテゴリー名の一覧",
+						"POST /api/v1/products":                            "商品作成（products:write）",
+						"PUT /api/v1/products/:id":                         "商品更新（products:write）",
+						"DELETE /api/v1/products/:id":                      "商品削除（products:write）",
+						"GET /api/v1/products/:id/variants":                "バリエーション一覧",
+						"POST /api/v1/products/:id/variants":               "バリエーション作成（products:write）",
+						"PUT /api/v1/products/:id/variants/:variant_id":    "バリエーション更新（products:write）",
+						"DELETE /api/v1/products/:id/variants/:variant_id": "バリエーション削除（products:write）",
+						"GET /api/v1/products/:id/images":                  "商品画像一覧",
+						"POST /api/v1/products/:id/images":                 "商品画像アップロード（multipart/form-data、products:write）",
+						"PUT /api/v1/products/:id/images/order":            "商品画像の並び替え（products:write）",
+						"DELETE /api/v1/products/:id/images/:image_id":     "商品画像削除（products:write）",
+						"POST /api/v1/products/import":                     "商品のインポート（CSV・NDJSON、SKUで作成または更新、dry_run、products:write）",
+						"GET /api/v1/products/export":                      "商品のエクスポート（CSV・NDJSON、商品一覧と同じ絞り込み、products:write）",
+						"POST /api/v1/products/:id/stock/adjustments":      "在庫の調整（adjustment・return、在庫の移動に記録、products:write）",
+						"GET /api/v1/products/:id/stock/movements":         "在庫の移動の履歴（variant_id・reason・order_id で絞り込み、products:write）",
+					},
+					"categories": gin.H{
+						"GET /api/v1/categories":        "カテゴリーツリー",
+						"GET /api/v1/categories/:id":    "カテゴリー詳細（IDまたはスラッグ、パンくずリストと子カテゴリー）",
+						"POST /api/v1/categories":       "カテゴリー作成（products:write）",
+						"PUT /api/v1/categories/:id":    "カテゴリー更新（products:write）",
+						"DELETE /api/v1/categories/:id": "カテゴリー削除（products:write）",
 					},
 					"orders": gin.H{
 						"POST /api/v1/orders":               "注文作成（認証必要）",
 						"GET /api/v1/orders":                "注文一覧（認証必要）",
 						"GET /api/v1/orders/:id":            "注文詳細（認証必要）",
 						"POST /api/v1/orders/:id/cancel":    "注文キャンセル（認証必要）",
-						"PATCH /api/v1/orders/:id/status":   "ステータス更新（管理者のみ）",
+						"PATCH /api/v1/orders/:id/status":   "ステータス更新（orders:update_status）",
+					},
+					"roles": gin.H{
+						"GET /api/v1/roles":        "ロール一覧（roles:manage）",
+						"POST /api/v1/roles":       "ロール作成（roles:manage）",
+						"PUT /api/v1/roles/:id":    "ロール更新（roles:manage）",
+						"DELETE /api/v1/roles/:id": "ロール削除（roles:manage）",
+						"GET /api/v1/permissions":  "権限一覧（roles:manage）",
+					},
+					"api_keys": gin.H{
+						"GET /api/v1/api-keys":        "APIキー一覧（api_keys:manage）",
+						"POST /api/v1/api-keys":       "APIキー発行（api_keys:manage）",
+						"GET /api/v1/api-keys/:id":    "APIキー詳細（api_keys:manage）",
+						"DELETE /api/v1/api-keys/:id": "APIキー失効（api_keys:manage）",
+					},
+					"audit_logs": gin.H{
+						"GET /api/v1/audit-logs": "監査ログ一覧（audit_logs:read）",
+					},
+					"errors": gin.H{
+						"GET /api/v1/errors":       "エラーコード一覧",
+						"GET /api/v1/errors/:code": "エラーコードの説明",
+					},
+					"media": gin.H{
+						"GET /media/*key": "アップロードした商品画像・サムネイルの配信",
 					},
 				},
 			})
@@ -170,10 +407,7 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 
 	// 404エラーハンドリング
 	r.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "エンドポイントが見つかりません",
-			"path":  c.Request.URL.Path,
-		})
+		apperr.Abort(c, apperr.ErrEndpointNotFound.With("path", c.Request.URL.Path))
 	})
 
 	return r
@@ -0,0 +1,75 @@
+// Package storage はアップロードしたファイル（商品画像等）の保存機能を提供します
+// 保存先は BlobStore インターフェースで抽象化されており、
+// 設定に応じてローカルのディレクトリやS3互換のオブジェクトストレージ（MinIO等）を切り替えられます
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go_learning/web/gin-app/internal/config"
+)
+
+// ErrNotFound は指定したキーのファイルが存在しない場合のエラーです
+var ErrNotFound = errors.New("storage: ファイルが見つかりません")
+
+// ErrInvalidKey はキーに使用できない文字やパス（.. 等）が含まれる場合のエラーです
+var ErrInvalidKey = errors.New("storage: キーの形式が正しくありません")
+
+// Object は保存したファイルの情報です
+type Object struct {
+	Size        int64     // バイト数
+	ContentType string    // MIMEタイプ
+	ModTime     time.Time // 更新日時
+	ETag        string    // キャッシュの検証に使用する値（引用符を含む）
+}
+
+// BlobStore はファイルの保存先のインターフェースです
+// キーは "products/1/xxxx.jpg" のように / で区切った英数字・記号（. _ -）のパスです
+type BlobStore interface {
+	// Put はファイルを保存します（同じキーのファイルは上書きします）
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get はファイルを取得します。呼び出し元は ReadCloser を閉じてください
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete はファイルを削除します（存在しない場合もエラーにしません）
+	Delete(ctx context.Context, key string) error
+}
+
+// New は設定に応じたBlobStoreを作成します
+// STORAGE_DRIVER=local の場合は LocalStore、s3 の場合は S3Store を返します
+func New(cfg config.StorageConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStore(cfg.LocalPath)
+	case "s3":
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("不明なファイルの保存先です: %s", cfg.Driver)
+	}
+}
+
+// ValidKey はキーが BlobStore で使用できる形式かを返します
+// ディレクトリの外を指すパス（..）や絶対パスを防ぐため、使用できる文字を制限します
+func ValidKey(key string) bool {
+	if key == "" || len(key) > 255 {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, r := range segment {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			case r == '.' || r == '_' || r == '-':
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+// Package utils は汎用的なユーティリティ関数を提供します
+package utils
+
+import (
+	"image"
+)
+
+// Thumbnail は画像を長辺が maxSize ピクセル以下になるよう縮小した画像を返します
+// 縦横比は維持し、元の画像が小さい場合は拡大しません
+// 縮小には面積平均法（縮小先の1ピクセルに対応する元の画像のピクセルの平均）を使用します
+func Thumbnail(src image.Image, maxSize int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// 1. 縮小後のサイズを計算
+	dstWidth, dstHeight := width, height
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			dstWidth, dstHeight = maxSize, height*maxSize/width
+		} else {
+			dstWidth, dstHeight = width*maxSize/height, maxSize
+		}
+	}
+	dstWidth, dstHeight = max(dstWidth, 1), max(dstHeight, 1)
+
+	// 2. 縮小先のピクセルごとに、対応する範囲の元のピクセルを平均
+	// RGBA() はアルファ乗算済みの値を返すため、そのまま平均して image.RGBA に格納できます
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := max(bounds.Min.Y+(y+1)*height/dstHeight, y0+1)
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := max(bounds.Min.X+(x+1)*width/dstWidth, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
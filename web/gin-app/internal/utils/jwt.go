@@ -10,36 +10,125 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// トークンの種類
+// 認証ミドルウェアはアクセストークンのみを受け付けます
+const (
+	TokenTypeAccess     = "access"      // APIアクセス用のトークン
+	TokenTypeMFAPending = "mfa_pending" // パスワード認証済みで二要素認証待ちのトークン
+)
+
 // JWTClaims はJWTトークンに含まれる情報（クレーム）を定義します
+// トークンID（jti）は RegisteredClaims.ID に格納され、ログアウト時の失効管理に使用します
 type JWTClaims struct {
-	UserID   uint   `json:"user_id"`   // ユーザーID
-	Username string `json:"username"`  // ユーザー名
-	Role     string `json:"role"`      // ロール（user, admin等）
-	jwt.RegisteredClaims                // 標準クレーム（exp, iat等）
+	UserID               uint   `json:"user_id"`       // ユーザーID
+	Username             string `json:"username"`      // ユーザー名
+	Role                 string `json:"role"`          // ロール（user, admin等）
+	TokenType            string `json:"token_type"`    // トークンの種類（access, mfa_pending）
+	MFA                  bool   `json:"mfa,omitempty"` // 二要素認証を経て発行されたか
+	SessionID            string `json:"sid,omitempty"` // ログインセッションのID（セッション単位の失効に使用）
+	Actor                *Actor `json:"act,omitempty"` // なりすましを行っているユーザー（なりすまし用トークンのみ）
+	jwt.RegisteredClaims        // 標準クレーム（exp, iat等）
 }
 
-// GenerateJWT はJWTトークンを生成します
+// Actor はなりすまし用トークンを発行した本来のユーザーを表します
+// RFC 8693 の act（actor）クレームに相当し、トークンのユーザー情報はなりすまされたユーザーになります
+type Actor struct {
+	UserID   uint   `json:"user_id"` // なりすましを行っているユーザーのID
+	Username string `json:"sub"`     // なりすましを行っているユーザーのユーザー名
+}
+
+// KeyProvider はJWTの署名と検証に使用する鍵を提供するインターフェースです
+// HS256 では共有の秘密鍵を、RS256/EdDSA ではヘッダーの kid で識別される鍵ペアを使用します
+type KeyProvider interface {
+	// SigningKey は新しいトークンの署名に使う鍵を返します（kid はHS256の場合は空）
+	SigningKey() (kid string, method jwt.SigningMethod, key interface{}, err error)
+
+	// VerificationKey はトークンヘッダーの kid と alg に対応する検証用の鍵を返します
+	// 鍵のアルゴリズムと alg が一致しない場合はエラーを返す必要があります
+	VerificationKey(kid, alg string) (interface{}, error)
+}
+
+// supportedAlgorithms は検証時に受け付ける署名アルゴリズムです
+// "none" 等のアルゴリズムを指定したトークンはパースの段階で拒否されます
+var supportedAlgorithms = []string{"HS256", "RS256", "EdDSA"}
+
+// TokenOptions はアクセストークンに含める追加情報です
+type TokenOptions struct {
+	MFA       bool          // 二要素認証を経てログインした場合はtrue
+	SessionID string        // トークンを発行したログインセッションのID
+	TokenID   string        // トークンID（jti）。空の場合は自動生成します
+	Actor     *Actor        // なりすましを行っているユーザー（なりすまし用トークンの場合のみ）
+	TTL       time.Duration // 有効期間。0の場合は JWT_EXPIRATION_HOURS を使用します
+}
+
+// GenerateJWT はJWTトークン（アクセストークン）を生成します
 // ユーザーの認証情報を含む署名付きトークンを返します
-func GenerateJWT(userID uint, username, role string, cfg config.JWTConfig) (string, error) {
-	// クレームの作成
+func GenerateJWT(userID uint, username, role string, cfg config.JWTConfig, keys KeyProvider, opts TokenOptions) (string, error) {
+	claims := JWTClaims{
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		TokenType: TokenTypeAccess,
+		MFA:       opts.MFA,
+		SessionID: opts.SessionID,
+		Actor:     opts.Actor,
+	}
+	claims.ID = opts.TokenID
+
+	ttl := cfg.Expiration
+	if opts.TTL > 0 {
+		ttl = opts.TTL
+	}
+	return signClaims(claims, username, ttl, cfg, keys)
+}
+
+// GenerateMFAToken は二要素認証待ちのトークンを生成します
+// このトークンではAPIにアクセスできず、二要素認証の検証エンドポイントでのみ使用できます
+func GenerateMFAToken(userID uint, username string, ttl time.Duration, cfg config.JWTConfig, keys KeyProvider) (string, error) {
 	claims := JWTClaims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.Expiration)), // 有効期限
-			IssuedAt:  jwt.NewNumericDate(time.Now()),                     // 発行時刻
-			NotBefore: jwt.NewNumericDate(time.Now()),                     // 有効開始時刻
-			Issuer:    cfg.Issuer,                                         // 発行者
-			Subject:   username,                                           // サブジェクト（ユーザー名）
-		},
+		UserID:    userID,
+		Username:  username,
+		TokenType: TokenTypeMFAPending,
+	}
+	return signClaims(claims, username, ttl, cfg, keys)
+}
+
+// signClaims は標準クレームを設定してトークンに署名します
+func signClaims(claims JWTClaims, subject string, ttl time.Duration, cfg config.JWTConfig, keys KeyProvider) (string, error) {
+	// トークンID（jti）の生成（呼び出し側で指定されていない場合）
+	jti := claims.ID
+	if jti == "" {
+		var err error
+		jti, err = GenerateSecureToken(16)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	// トークンの作成（HS256アルゴリズムを使用）
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)), // 有効期限
+		IssuedAt:  jwt.NewNumericDate(now),          // 発行時刻
+		NotBefore: jwt.NewNumericDate(now),          // 有効開始時刻
+		Issuer:    cfg.Issuer,                       // 発行者
+		Subject:   subject,                          // サブジェクト（ユーザー名）
+		ID:        jti,                              // トークンID（失効管理用）
+	}
+
+	// 署名に使う鍵の取得（アルゴリズムは設定による）
+	kid, method, key, err := keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	// トークンの作成（検証側が鍵を選べるよう、ヘッダーに kid を含める）
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 
 	// 秘密鍵で署名してトークン文字列を生成
-	tokenString, err := token.SignedString([]byte(cfg.SecretKey))
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", err
 	}
@@ -49,15 +138,13 @@ func GenerateJWT(userID uint, username, role string, cfg config.JWTConfig) (stri
 
 // ValidateJWT はJWTトークンを検証し、クレームを返します
 // トークンが無効または期限切れの場合はエラーを返します
-func ValidateJWT(tokenString, secretKey string) (*JWTClaims, error) {
+// 署名アルゴリズムと鍵の組み合わせは KeyProvider が検証します
+func ValidateJWT(tokenString string, keys KeyProvider) (*JWTClaims, error) {
 	// トークンのパース
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// 署名アルゴリズムの検証
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("無効な署名アルゴリズムです")
-		}
-		return []byte(secretKey), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		return keys.VerificationKey(kid, token.Method.Alg())
+	}, jwt.WithValidMethods(supportedAlgorithms))
 
 	if err != nil {
 		return nil, err
@@ -70,29 +157,3 @@ func ValidateJWT(tokenString, secretKey string) (*JWTClaims, error) {
 
 	return nil, errors.New("無効なトークンです")
 }
-
-// RefreshToken は既存のトークンから新しいトークンを生成します
-// 有効期限が近いトークンをリフレッシュする際に使用します
-func RefreshToken(oldToken string, cfg config.JWTConfig) (string, error) {
-	// 古いトークンの検証（有効期限切れでもクレームは取得）
-	claims, err := ValidateJWT(oldToken, cfg.SecretKey)
-	if err != nil {
-		// 期限切れの場合も許可（リフレッシュのため）
-		token, _ := jwt.ParseWithClaims(oldToken, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(cfg.SecretKey), nil
-		})
-
-		if token != nil {
-			if c, ok := token.Claims.(*JWTClaims); ok {
-				claims = c
-			} else {
-				return "", errors.New("トークンのクレームが取得できません")
-			}
-		} else {
-			return "", errors.New("無効なトークンです")
-		}
-	}
-
-	// 新しいトークンを生成
-	return GenerateJWT(claims.UserID, claims.Username, claims.Role, cfg)
-}
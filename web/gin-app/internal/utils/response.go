@@ -2,16 +2,61 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
+	"sort"
+
+	"go_learning/web/gin-app/internal/apperr"
+	"go_learning/web/gin-app/internal/i18n"
 
 	"github.com/gin-gonic/gin"
 )
 
 // ErrorResponse は標準的なエラーレスポンスの構造です
+// RFC 7807（application/problem+json）の形式に、従来の error フィールドを加えたものです
 type ErrorResponse struct {
-	Error   string      `json:"error"`             // エラーメッセージ
-	Details interface{} `json:"details,omitempty"` // 詳細情報（オプション）
-	Code    string      `json:"code,omitempty"`    // エラーコード（オプション）
+	Type      string      `json:"type,omitempty"`       // エラーの種類を表すURI
+	Title     string      `json:"title,omitempty"`      // エラーの概要
+	Status    int         `json:"status,omitempty"`     // HTTPステータスコード
+	Code      string      `json:"code,omitempty"`       // エラーコード
+	Instance  string      `json:"instance,omitempty"`   // エラーが発生したリクエストのパス
+	RequestID string      `json:"request_id,omitempty"` // リクエストID
+	Error     string      `json:"error"`                // エラーメッセージ
+	Details   interface{} `json:"details,omitempty"`    // 詳細情報（オプション）
+
+	Extensions map[string]interface{} `json:"-"` // 追加のメンバー（permission 等）
+}
+
+// MarshalJSON は追加のメンバーをトップレベルに含めてJSONに変換します
+func (r ErrorResponse) MarshalJSON() ([]byte, error) {
+	type plain ErrorResponse
+	data, err := json.Marshal(plain(r))
+	if err != nil || len(r.Extensions) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(r.Extensions))
+	for key := range r.Extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, key := range keys {
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(r.Extensions[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
 
 // SuccessResponse は標準的な成功レスポンスの構造です
@@ -36,6 +81,12 @@ func RespondWithSuccess(c *gin.Context, statusCode int, message string, data int
 	})
 }
 
+// RespondValidationError はリクエストのバインドに失敗した場合の400 Bad Requestレスポンスを返します
+// 入力エラーをフィールド単位に変換して details に含めます（エラーコード VALIDATION_FAILED）
+func RespondValidationError(c *gin.Context, err error) {
+	apperr.Abort(c, apperr.ErrValidation.WithCause(err).WithDetails(ValidationErrors(err, i18n.Locale(c))))
+}
+
 // BadRequest は400 Bad Requestレスポンスを返します
 func BadRequest(c *gin.Context, message string) {
 	RespondWithError(c, http.StatusBadRequest, message, nil)
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+)
+
+// Slugify は名前からスラッグ（小文字の英数字とハイフン）を作成します
+// 英数字以外の文字はハイフンに置き換え、連続するハイフンは1つにまとめます（先頭と末尾のハイフンは除きます）
+// 英数字を含まない名前（日本語のみ等）の場合は空文字を返します
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if r == '\'' || r == '’' {
+			// アポストロフィは区切りにしない（例: Men's -> mens）
+			continue
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+
+	slug := b.String()
+	if len(slug) > slugMaxLength {
+		slug = strings.TrimRight(slug[:slugMaxLength], "-")
+	}
+	return slug
+}
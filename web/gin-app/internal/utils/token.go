@@ -0,0 +1,48 @@
+// Package utils は汎用的なユーティリティ関数を提供します
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// GenerateSecureToken は暗号学的に安全なランダムトークンを生成します
+// byteLength バイトの乱数をURLセーフなBase64文字列にエンコードして返します
+func GenerateSecureToken(byteLength int) (string, error) {
+	b := make([]byte, byteLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken はトークンをSHA-256でハッシュ化し、16進文字列で返します
+// データベースにはトークンそのものではなくハッシュ値のみを保存します
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateRecoveryCode は二要素認証のリカバリーコードを生成します
+// 読み間違えにくいよう、英小文字と数字のみの "xxxxx-xxxxx" 形式で返します
+func GenerateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// NormalizeRecoveryCode は入力されたリカバリーコードを比較用に正規化します
+// 大文字・小文字、ハイフン、空白の違いを無視します
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	return code
+}
@@ -0,0 +1,105 @@
+// Package utils は汎用的なユーティリティ関数を提供します
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP（RFC 6238）のパラメータ
+// Google Authenticator 等の一般的な認証アプリが対応している標準的な値を使用します
+const (
+	totpPeriod = 30 // タイムステップ（秒）
+	totpDigits = 6  // コードの桁数
+	totpSkew   = 1  // 時刻ずれとして許容する前後のステップ数
+)
+
+// totpEncoding はシークレットのBase32エンコーディング（パディングなし）です
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret はTOTP用の共有シークレットを生成します
+// 160ビットの乱数をBase32文字列にエンコードして返します
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPAuthURI は認証アプリに登録するための otpauth:// URI を生成します
+// QRコードに変換して表示すると、認証アプリで読み取れます
+func TOTPAuthURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", totpDigits))
+	params.Set("period", fmt.Sprintf("%d", totpPeriod))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPCounter は指定時刻のタイムステップ（カウンター値）を返します
+func TOTPCounter(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// GenerateTOTPCode は指定したカウンター値のTOTPコードを生成します
+func GenerateTOTPCode(secret string, counter int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	// HMAC-SHA1(key, counter) を計算（RFC 4226）
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// 動的切り捨て（Dynamic Truncation）
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// ValidateTOTPCode はTOTPコードを検証し、一致したカウンター値を返します
+// 時刻ずれを考慮して前後 totpSkew ステップまで許容します
+// 同じコードの再利用を防ぐため、呼び出し側は返されたカウンター値を記録し、
+// 次回以降はそれより大きい値のみ受け付けてください
+func ValidateTOTPCode(secret, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := TOTPCounter(t)
+	for i := -totpSkew; i <= totpSkew; i++ {
+		counter := current + int64(i)
+		expected, err := GenerateTOTPCode(secret, counter)
+		if err != nil {
+			return 0, false
+		}
+		// タイミング攻撃を防ぐため定数時間で比較
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
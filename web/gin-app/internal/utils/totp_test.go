@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret は RFC 6238 の付録Bのテスト用のシークレット（"12345678901234567890"）のBase32です
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestGenerateTOTPCode は RFC 6238 のテストベクター（SHA1、8桁の下6桁）と一致することを確認します
+func TestGenerateTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		counter := TOTPCounter(time.Unix(tt.unix, 0))
+		got, err := GenerateTOTPCode(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatalf("%d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("%d: got %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+// TestGenerateTOTPCodeSecret はシークレットの大文字・小文字を区別せず、不正なシークレットをエラーにすることを確認します
+func TestGenerateTOTPCodeSecret(t *testing.T) {
+	want, _ := GenerateTOTPCode(rfc6238Secret, 1)
+	got, err := GenerateTOTPCode(strings.ToLower(rfc6238Secret), 1)
+	if err != nil || got != want {
+		t.Errorf("小文字のシークレット: got %q, %v, want %q", got, err, want)
+	}
+	if _, err := GenerateTOTPCode("not base32!", 1); err == nil {
+		t.Error("不正なシークレットがエラーになっていません")
+	}
+}
+
+// TestValidateTOTPCode は前後1ステップの時刻ずれのみ許容し、一致したカウンター値を返すことを確認します
+func TestValidateTOTPCode(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPCounter(now)
+	code := func(counter int64) string {
+		c, err := GenerateTOTPCode(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		wantOK      bool
+		wantCounter int64
+	}{
+		{"current", code(current), true, current},
+		{"previous_step", code(current - 1), true, current - 1},
+		{"next_step", code(current + 1), true, current + 1},
+		{"surrounding_spaces", " " + code(current) + "\n", true, current},
+		{"two_steps_ago", code(current - 2), false, 0},
+		{"two_steps_ahead", code(current + 2), false, 0},
+		{"too_short", code(current)[:5], false, 0},
+		{"too_long", code(current) + "0", false, 0},
+		{"empty", "", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := ValidateTOTPCode(rfc6238Secret, tt.code, now)
+			if ok != tt.wantOK || counter != tt.wantCounter {
+				t.Errorf("got (%d, %v), want (%d, %v)", counter, ok, tt.wantCounter, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestValidateTOTPCodeReplay は同じコードが同じカウンター値を返すことを確認します
+// 呼び出し側は記録済みのカウンター値以下のコードを拒否するため、同じコードは二度使えません
+func TestValidateTOTPCodeReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := GenerateTOTPCode(rfc6238Secret, TOTPCounter(now))
+
+	first, ok := ValidateTOTPCode(rfc6238Secret, code, now)
+	if !ok {
+		t.Fatal("最初の検証に失敗しました")
+	}
+	second, ok := ValidateTOTPCode(rfc6238Secret, code, now.Add(totpPeriod*time.Second))
+	if !ok || second != first {
+		t.Errorf("次のステップでの再検証: got (%d, %v), want (%d, true)", second, ok, first)
+	}
+}
+
+// TestGenerateTOTPSecret は生成したシークレットが160ビットで、毎回異なることを確認します
+func TestGenerateTOTPSecret(t *testing.T) {
+	a, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateTOTPSecret()
+	if a == b {
+		t.Error("同じシークレットが生成されました")
+	}
+	key, err := totpEncoding.DecodeString(a)
+	if err != nil || len(key) != 20 {
+		t.Errorf("シークレット %q: %d バイト, %v", a, len(key), err)
+	}
+}
@@ -2,42 +2,275 @@
 package utils
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
 	"regexp"
-	"unicode"
+	"strconv"
+	"strings"
+
+	"go_learning/web/gin-app/internal/i18n"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
-// IsValidEmail はメールアドレスの形式が正しいかを検証します
-func IsValidEmail(email string) bool {
-	// RFC 5322に準拠した簡易的なメールアドレス検証
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
-	return emailRegex.MatchString(email)
+// カスタムバリデーションのタグ名
+// リクエストボディの構造体の binding タグで使用します（例: binding:"required,username"）
+const (
+	ValidateUsername       = "username"        // ユーザー名の形式（IsValidUsername）
+	ValidateStrongPassword = "strong_password" // パスワードポリシー
+	ValidateSKU            = "sku"             // 商品コード（SKU）の形式
+	ValidateLocale         = "locale"          // 対応している言語（ja, en 等）
+	ValidateSlug           = "slug"            // スラッグの形式（IsValidSlug）
+)
+
+var (
+	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	skuRegex      = regexp.MustCompile(`^[A-Za-z0-9]+([-_][A-Za-z0-9]+)*$`)
+	slugRegex     = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// 最大文字数（データベースのカラムサイズ）
+const (
+	skuMaxLength  = 50  // SKU
+	slugMaxLength = 100 // スラッグ
+)
+
+// PasswordChecker はパスワードがポリシーの要件を満たすかを確認する関数です
+// 満たしていない要件のメッセージを返します（満たしている場合は空）
+type PasswordChecker func(password string) []i18n.Message
+
+// passwordChecker は strong_password の検証に使用する関数です（RegisterValidators で設定）
+var passwordChecker PasswordChecker
+
+// FieldError はフィールド単位の入力エラーです
+// エラーレスポンスの details に含め、クライアントがフォームの項目ごとにエラーを表示できるようにします
+type FieldError struct {
+	Field   string `json:"field,omitempty"` // フィールド名（JSONのキー、ネストした場合は items[0].quantity 形式）
+	Rule    string `json:"rule"`            // 満たしていないルール（required, max, username 等）
+	Message string `json:"message"`         // 利用者向けのメッセージ
 }
 
-// IsStrongPassword はパスワードの強度をチェックします
-// 少なくとも8文字以上で、大文字、小文字、数字を含む必要があります
-func IsStrongPassword(password string) bool {
-	if len(password) < 8 {
-		return false
+// RegisterValidators はGinのバリデーターにカスタムバリデーションを登録します
+// エラーのフィールド名にはJSONのキーを使用するよう設定します
+// ルーターのセットアップ前に一度だけ呼び出してください
+func RegisterValidators(checkPassword PasswordChecker) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("バリデーターの取得に失敗しました")
+	}
+
+	// フィールド名をJSONのキーにする（json:"-" のフィールドは名前なし）
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	passwordChecker = checkPassword
+
+	validations := map[string]validator.Func{
+		ValidateUsername: func(fl validator.FieldLevel) bool {
+			return IsValidUsername(fl.Field().String())
+		},
+		ValidateStrongPassword: func(fl validator.FieldLevel) bool {
+			return len(passwordChecker(fl.Field().String())) == 0
+		},
+		ValidateSKU: func(fl validator.FieldLevel) bool {
+			return IsValidSKU(fl.Field().String())
+		},
+		ValidateLocale: func(fl validator.FieldLevel) bool {
+			return i18n.IsSupported(fl.Field().String())
+		},
+		ValidateSlug: func(fl validator.FieldLevel) bool {
+			return IsValidSlug(fl.Field().String())
+		},
+	}
+	for tag, fn := range validations {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("バリデーション %s の登録に失敗しました: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidationErrors はリクエストのバインドで発生したエラーをフィールド単位のエラーに変換します
+// JSONの構文エラーや型の不一致も、クライアントが扱える形式に変換します
+// メッセージは locale で指定した言語で返します
+func ValidationErrors(err error, locale string) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, fieldErrors(fe, locale)...)
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   jsonFieldPath(typeErr.Field),
+			Rule:    "type",
+			Message: i18n.Translate(locale, "validation.type", i18n.Translate(locale, jsonTypeName(typeErr.Type))),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return []FieldError{{
+			Rule:    "json",
+			Message: i18n.Translate(locale, "validation.json"),
+		}}
+	}
+
+	return []FieldError{{
+		Rule:    "invalid",
+		Message: i18n.Translate(locale, "validation.malformed"),
+	}}
+}
+
+// fieldErrors はバリデーションエラー1件をフィールド単位のエラーに変換します
+// strong_password の場合は、満たしていない要件ごとにエラーを返します
+func fieldErrors(fe validator.FieldError, locale string) []FieldError {
+	field := fieldPath(fe)
+
+	if fe.Tag() == ValidateStrongPassword && passwordChecker != nil {
+		if password, ok := fe.Value().(string); ok {
+			violations := passwordChecker(password)
+			fields := make([]FieldError, 0, len(violations))
+			for _, v := range violations {
+				fields = append(fields, FieldError{Field: field, Rule: fe.Tag(), Message: v.Translate(locale)})
+			}
+			if len(fields) > 0 {
+				return fields
+			}
+		}
+	}
+
+	return []FieldError{{
+		Field:   field,
+		Rule:    fe.Tag(),
+		Message: validationMessage(fe).Translate(locale),
+	}}
+}
+
+// fieldPath はエラーのフィールド名を構造体名を除いたパスで返します
+// 例: OrderCreateRequest.items[0].quantity → items[0].quantity
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// jsonFieldPath はJSONデコードのエラーのフィールド名を items[0].quantity 形式に変換します
+// encoding/json は配列の要素を items.0.quantity の形式で返すため、バリデーションエラーと形式を揃えます
+func jsonFieldPath(field string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil && i > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(part)
 	}
+	return b.String()
+}
 
-	var (
-		hasUpper   = false
-		hasLower   = false
-		hasNumber  = false
-	)
+// validationMessage はバリデーションルールに対応するメッセージを返します
+func validationMessage(fe validator.FieldError) i18n.Message {
+	kind := fe.Kind()
+	if kind == reflect.Ptr {
+		kind = fe.Type().Elem().Kind()
+	}
+	isString := kind == reflect.String
+	isCollection := kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
 
-	for _, char := range password {
+	switch fe.Tag() {
+	case "required", "required_if":
+		return i18n.NewMessage("validation.required")
+	case "email":
+		return i18n.NewMessage("validation.email")
+	case "url":
+		return i18n.NewMessage("validation.url")
+	case "oneof":
+		return i18n.NewMessage("validation.oneof", strings.Join(strings.Fields(fe.Param()), ", "))
+	case "min":
+		switch {
+		case isString:
+			return i18n.NewMessage("validation.min_length", fe.Param())
+		case isCollection:
+			return i18n.NewMessage("validation.min_items", fe.Param())
+		}
+		return i18n.NewMessage("validation.min", fe.Param())
+	case "max":
 		switch {
-		case unicode.IsUpper(char):
-			hasUpper = true
-		case unicode.IsLower(char):
-			hasLower = true
-		case unicode.IsNumber(char):
-			hasNumber = true
+		case isString:
+			return i18n.NewMessage("validation.max_length", fe.Param())
+		case isCollection:
+			return i18n.NewMessage("validation.max_items", fe.Param())
 		}
+		return i18n.NewMessage("validation.max", fe.Param())
+	case "gt":
+		return i18n.NewMessage("validation.gt", fe.Param())
+	case "gte":
+		return i18n.NewMessage("validation.min", fe.Param())
+	case "lt":
+		return i18n.NewMessage("validation.lt", fe.Param())
+	case "lte":
+		return i18n.NewMessage("validation.max", fe.Param())
+	case ValidateUsername:
+		return i18n.NewMessage("validation.username")
+	case ValidateStrongPassword:
+		return i18n.NewMessage("validation.strong_password")
+	case ValidateSKU:
+		return i18n.NewMessage("validation.sku", skuMaxLength)
+	case ValidateLocale:
+		return i18n.NewMessage("validation.locale", strings.Join(i18n.Supported(), ", "))
+	case ValidateSlug:
+		return i18n.NewMessage("validation.slug", slugMaxLength)
 	}
 
-	return hasUpper && hasLower && hasNumber
+	return i18n.NewMessage("validation.invalid")
+}
+
+// jsonTypeName はGoの型に対応するJSONの型名のメッセージキーを返します
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "validation.type_name.string"
+	case reflect.Bool:
+		return "validation.type_name.boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "validation.type_name.integer"
+	case reflect.Float32, reflect.Float64:
+		return "validation.type_name.number"
+	case reflect.Slice, reflect.Array:
+		return "validation.type_name.array"
+	}
+	return "validation.type_name.object"
+}
+
+// IsValidEmail はメールアドレスの形式が正しいかを検証します
+func IsValidEmail(email string) bool {
+	// RFC 5322に準拠した簡易的なメールアドレス検証
+	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+	return emailRegex.MatchString(email)
 }
 
 // IsValidUsername はユーザー名の形式が正しいかを検証します
@@ -46,10 +279,27 @@ func IsValidUsername(username string) bool {
 	if len(username) < 3 || len(username) > 20 {
 		return false
 	}
-	usernameRegex := regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
 	return usernameRegex.MatchString(username)
 }
 
+// IsValidSKU は商品コード（SKU）の形式が正しいかを検証します
+// 50文字以内の英数字で、ハイフン・アンダースコアで区切れます（例: SKU001, TS-BLK-M）
+func IsValidSKU(sku string) bool {
+	if len(sku) > skuMaxLength {
+		return false
+	}
+	return skuRegex.MatchString(sku)
+}
+
+// IsValidSlug はスラッグの形式が正しいかを検証します
+// 100文字以内の小文字の英数字で、ハイフンで区切れます（例: mens-shoes）
+func IsValidSlug(slug string) bool {
+	if len(slug) > slugMaxLength {
+		return false
+	}
+	return slugRegex.MatchString(slug)
+}
+
 // SanitizeInput は入力文字列から危険な文字を除去します
 // XSS攻撃を防ぐための簡易的なサニタイズ
 func SanitizeInput(input string) string {